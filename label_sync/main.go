@@ -314,7 +314,7 @@ func (ud *UpdateData) DoUpdates(gc *github.Client) error {
 					return err
 				}
 			} else if item.Why == "invalid_color" || item.Why == "invalid_name" {
-				err := gc.UpdateRepoLabel(org, repo, item.RequiredLabel.Name, item.RequiredLabel.Color)
+				err := gc.UpdateRepoLabel(org, repo, item.RequiredLabel.Name, item.RequiredLabel.Color, "")
 				if err != nil {
 					return err
 				}
@@ -363,7 +363,11 @@ func (c *Client) DeleteStaleComments(org, repo string, number int, comments []Is
 // results.  An error is returned if encountered in making calls to
 // github or marshalling objects.
 func (c *Client) readPaginatedResults(path string, newObj func() interface{}, accumulate func(interface{})) error {
-	url := fmt.Sprintf("%s%s?per_page=100", c.base, path)
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	url := fmt.Sprintf("%s%s%sper_page=100", c.base, path, sep)
 	for url != "" {
 		resp, err := c.requestRetry(http.MethodGet, url, "", nil)
 		if err != nil {
@@ -534,13 +538,16 @@ func (c *Client) AddRepoLabel(org, repo, label, color string) error {
 	return err
 }
 
-// Updates org/repo label to label/color
-func (c *Client) UpdateRepoLabel(org, repo, label, color string) error {
-	c.log("UpdateRepoLabel", org, repo, label, color)
+// Updates org/repo label to label/color, and its description unless
+// description is empty.
+func (c *Client) UpdateRepoLabel(org, repo, label, color, description string) error {
+	c.log("UpdateRepoLabel", org, repo, label, color, description)
 	_, err := c.request(&request{
-		method:      http.MethodPatch,
-		path:        fmt.Sprintf("%s/repos/%s/%s/labels/%s", c.base, org, repo, label),
-		requestBody: Label{Name: label, Color: color},
+		method: http.MethodPatch,
+		path:   fmt.Sprintf("%s/repos/%s/%s/labels/%s", c.base, org, repo, label),
+		// Label descriptions are only available in the symmetra preview.
+		accept:      "application/vnd.github.symmetra-preview+json",
+		requestBody: Label{Name: label, Color: color, Description: description},
 		exitCodes:   []int{200},
 	}, nil)
 	return err
@@ -610,6 +617,18 @@ func (c *Client) RemoveLabel(org, repo string, number int, label string) error {
 	return err
 }
 
+// ReplaceLabels replaces all labels on the issue with labels.
+func (c *Client) ReplaceLabels(org, repo string, number int, labels []string) error {
+	c.log("ReplaceLabels", org, repo, number, labels)
+	_, err := c.request(&request{
+		method:      http.MethodPut,
+		path:        fmt.Sprintf("%s/repos/%s/%s/issues/%d/labels", c.base, org, repo, number),
+		requestBody: labels,
+		exitCodes:   []int{200},
+	}, nil)
+	return err
+}
+
 type MissingUsers struct {
 	Users  []string
 	action string
@@ -697,6 +716,64 @@ func (c *Client) CreateReview(org, repo string, number int, r DraftReview) error
 	return err
 }
 
+// ListReviews lists all reviews on a PR. This may use more than one API token.
+func (c *Client) ListReviews(org, repo string, number int) ([]Review, error) {
+	c.log("ListReviews", org, repo, number)
+	if c.fake {
+		return nil, nil
+	}
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", org, repo, number)
+	var reviews []Review
+	err := c.readPaginatedResults(path,
+		func() interface{} {
+			return &[]Review{}
+		},
+		func(obj interface{}) {
+			reviews = append(reviews, *(obj.(*[]Review))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// ListIssueEvents returns the events on the timeline of an issue or PR, eg.
+// label changes, oldest first.
+func (c *Client) ListIssueEvents(org, repo string, number int) ([]ListedIssueEvent, error) {
+	c.log("ListIssueEvents", org, repo, number)
+	if c.fake {
+		return nil, nil
+	}
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/events", org, repo, number)
+	var events []ListedIssueEvent
+	err := c.readPaginatedResults(path,
+		func() interface{} {
+			return &[]ListedIssueEvent{}
+		},
+		func(obj interface{}) {
+			events = append(events, *(obj.(*[]ListedIssueEvent))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DismissReview dismisses a review on a PR with the given message.
+func (c *Client) DismissReview(org, repo string, number, ID int, message string) error {
+	c.log("DismissReview", org, repo, number, ID, message)
+	_, err := c.request(&request{
+		method:      http.MethodPut,
+		path:        fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews/%d/dismissals", c.base, org, repo, number, ID),
+		accept:      "application/vnd.github.black-cat-preview+json",
+		requestBody: map[string]string{"message": message},
+		exitCodes:   []int{200},
+	}, nil)
+	return err
+}
+
 func (c *Client) tryRequestReview(org, repo string, number int, logins []string) (int, error) {
 	c.log("RequestReview", org, repo, number, logins)
 	var pr PullRequest
@@ -861,6 +938,35 @@ func (c *Client) FindIssues(query, sort string, asc bool) ([]Issue, error) {
 	return issSearchResult.Issues, err
 }
 
+// FindAllIssues is like FindIssues, but returns every page of results rather
+// than only the first one. This may use more than one API token.
+func (c *Client) FindAllIssues(query, sort string, asc bool) ([]Issue, error) {
+	c.log("FindAllIssues", query)
+	if c.fake {
+		return nil, nil
+	}
+	path := fmt.Sprintf("/search/issues?q=%s", url.QueryEscape(query))
+	if sort != "" {
+		path += "&sort=" + url.QueryEscape(sort)
+		if asc {
+			path += "&order=asc"
+		}
+	}
+	var issues []Issue
+	err := c.readPaginatedResults(path,
+		func() interface{} {
+			return &IssuesSearchResult{}
+		},
+		func(obj interface{}) {
+			issues = append(issues, obj.(*IssuesSearchResult).Issues...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
 type FileNotFound struct {
 	org, repo, path, commit string
 }
@@ -378,6 +378,34 @@ func TestRemoveLabel(t *testing.T) {
 	}
 }
 
+func TestReplaceLabels(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/issues/5/labels" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var ls []string
+		if err := json.Unmarshal(b, &ls); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if len(ls) != 2 {
+			t.Errorf("Wrong length labels: %v", ls)
+		} else if ls[0] != "yay" || ls[1] != "nay" {
+			t.Errorf("Wrong labels: %v", ls)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.ReplaceLabels("k8s", "kuber", 5, []string{"yay", "nay"}); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
 func TestAssignIssue(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -558,6 +586,113 @@ func TestListPullRequestComments(t *testing.T) {
 	}
 }
 
+func TestListReviews(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/pulls/15/reviews" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := json.Marshal([]Review{{ID: 1, State: ReviewStateApproved}, {ID: 2, State: ReviewStateChangesRequested}})
+		if err != nil {
+			t.Fatalf("Didn't expect error: %v", err)
+		}
+		fmt.Fprint(w, string(b))
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	reviews, err := c.ListReviews("k8s", "kuber", 15)
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if len(reviews) != 2 {
+		t.Errorf("Expected two reviews, found %d: %v", len(reviews), reviews)
+	} else if reviews[0].ID != 1 || reviews[1].State != ReviewStateChangesRequested {
+		t.Errorf("Wrong reviews: %v", reviews)
+	}
+}
+
+func TestUpdateRepoLabel(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/labels/release-note" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var label Label
+		if err := json.Unmarshal(b, &label); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if label.Color != "c2e0c6" || label.Description != "Has a release note." {
+			t.Errorf("Wrong label: %+v", label)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.UpdateRepoLabel("k8s", "kuber", "release-note", "c2e0c6", "Has a release note."); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
+func TestListIssueEvents(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/issues/15/events" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := json.Marshal([]ListedIssueEvent{
+			{Event: ListedIssueEventLabeled, Label: Label{Name: "lgtm"}},
+			{Event: ListedIssueEventUnlabeled, Label: Label{Name: "lgtm"}, Actor: User{Login: "bob"}},
+		})
+		if err != nil {
+			t.Fatalf("Didn't expect error: %v", err)
+		}
+		fmt.Fprint(w, string(b))
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	events, err := c.ListIssueEvents("k8s", "kuber", 15)
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if len(events) != 2 {
+		t.Errorf("Expected two events, found %d: %v", len(events), events)
+	} else if events[0].Event != ListedIssueEventLabeled || events[1].Actor.Login != "bob" {
+		t.Errorf("Wrong events: %v", events)
+	}
+}
+
+func TestDismissReview(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/pulls/5/reviews/7/dismissals" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if body["message"] != "done" {
+			t.Errorf("Wrong message: %v", body)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.DismissReview("k8s", "kuber", 5, 7, "done"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
 func TestRequestReview(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -854,6 +989,44 @@ func TestFindIssues(t *testing.T) {
 	}
 }
 
+func TestFindAllIssues(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		var result IssuesSearchResult
+		if r.URL.Path == "/search/issues" {
+			if q, pp := r.URL.Query().Get("q"), r.URL.Query().Get("per_page"); q != "is:pr" || pp != "100" {
+				t.Errorf("Bad query %q with per_page %q", q, pp)
+			}
+			if s := r.URL.Query().Get("sort"); s != "updated" {
+				t.Errorf("Bad sort: %q", s)
+			}
+			result = IssuesSearchResult{Total: 2, Issues: []Issue{{Number: 1}}}
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+		} else if r.URL.Path == "/someotherpath" {
+			result = IssuesSearchResult{Total: 2, Issues: []Issue{{Number: 2}}}
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := json.Marshal(&result)
+		if err != nil {
+			t.Fatalf("Didn't expect error: %v", err)
+		}
+		fmt.Fprint(w, string(b))
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	issues, err := c.FindAllIssues("is:pr", "updated", false)
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if len(issues) != 2 {
+		t.Errorf("Expected two issues, found %d: %v", len(issues), issues)
+	} else if issues[0].Number != 1 || issues[1].Number != 2 {
+		t.Errorf("Wrong issue numbers: %v", issues)
+	}
+}
+
 func TestGetFile(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -19,6 +19,8 @@ package fakegithub
 import (
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 
 	"k8s.io/test-infra/prow/github"
 )
@@ -31,17 +33,28 @@ type FakeClient struct {
 	PullRequests       map[int]*github.PullRequest
 	PullRequestChanges map[int][]github.PullRequestChange
 	CombinedStatuses   map[string]*github.CombinedStatus
+	// Statuses created by ref.
+	CreatedStatuses map[string][]github.Status
 
 	//All Labels That Exist In The Repo
 	ExistingLabels []string
+	// Labels with their colors and descriptions, which take precedence over
+	// ExistingLabels if set.
+	RepoLabels []github.Label
+	// org/repo:label
+	RepoLabelsUpdated []string
 	// org/repo#number:label
 	LabelsAdded   []string
 	LabelsRemoved []string
+	// org/repo#number:label1,label2
+	LabelsReplaced []string
 
 	// org/repo#number:body
 	IssueCommentsAdded []string
 	// org/repo#issuecommentid
 	IssueCommentsDeleted []string
+	// org/repo#issuecommentid:body
+	IssueCommentsEdited []string
 
 	// org/repo#issuecommentid:reaction
 	IssueReactionsAdded   []string
@@ -50,6 +63,18 @@ type FakeClient struct {
 	// org/repo#number:assignee
 	AssigneesAdded []string
 
+	// org/repo#number:reviewer
+	ReviewersRequested []string
+
+	// Reviews on PRs by PR number.
+	Reviews map[int][]github.Review
+	// org/repo#number:reviewID
+	ReviewsDismissed []string
+	ReviewID         int
+
+	// Timeline events on issues and PRs by number.
+	IssueEvents map[int][]github.ListedIssueEvent
+
 	// Fake remote git storage. File name are keys
 	// and values map SHA to content
 	RemoteFiles map[string]map[string]string
@@ -75,13 +100,28 @@ func (f *FakeClient) ListIssueComments(owner, repo string, number int) ([]github
 func (f *FakeClient) CreateComment(owner, repo string, number int, comment string) error {
 	f.IssueCommentsAdded = append(f.IssueCommentsAdded, fmt.Sprintf("%s/%s#%d:%s", owner, repo, number, comment))
 	f.IssueComments[number] = append(f.IssueComments[number], github.IssueComment{
-		ID:   f.IssueCommentID,
-		Body: comment,
+		ID:        f.IssueCommentID,
+		Body:      comment,
+		User:      github.User{Login: "k8s-ci-robot"},
+		CreatedAt: time.Now(),
 	})
 	f.IssueCommentID++
 	return nil
 }
 
+func (f *FakeClient) EditComment(owner, repo string, ID int, comment string) error {
+	f.IssueCommentsEdited = append(f.IssueCommentsEdited, fmt.Sprintf("%s/%s#%d:%s", owner, repo, ID, comment))
+	for num, ics := range f.IssueComments {
+		for i, ic := range ics {
+			if ic.ID == ID {
+				f.IssueComments[num][i].Body = comment
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("could not find issue comment %d", ID)
+}
+
 func (f *FakeClient) CreateCommentReaction(org, repo string, ID int, reaction string) error {
 	f.CommentReactionsAdded = append(f.CommentReactionsAdded, fmt.Sprintf("%s/%s#%d:%s", org, repo, ID, reaction))
 	return nil
@@ -132,6 +172,10 @@ func (f *FakeClient) GetRef(owner, repo, ref string) (string, error) {
 }
 
 func (f *FakeClient) CreateStatus(owner, repo, ref string, s github.Status) error {
+	if f.CreatedStatuses == nil {
+		f.CreatedStatuses = map[string][]github.Status{}
+	}
+	f.CreatedStatuses[ref] = append(f.CreatedStatuses[ref], s)
 	return nil
 }
 
@@ -140,6 +184,9 @@ func (f *FakeClient) GetCombinedStatus(owner, repo, ref string) (*github.Combine
 }
 
 func (f *FakeClient) GetRepoLabels(owner, repo string) ([]github.Label, error) {
+	if f.RepoLabels != nil {
+		return append([]github.Label{}, f.RepoLabels...), nil
+	}
 	la := []github.Label{}
 	for _, l := range f.ExistingLabels {
 		la = append(la, github.Label{Name: l})
@@ -179,11 +226,39 @@ func (f *FakeClient) RemoveLabel(owner, repo string, number int, label string) e
 	return nil
 }
 
+// ReplaceLabels records the replacement in f.LabelsReplaced.
+func (f *FakeClient) ReplaceLabels(owner, repo string, number int, labels []string) error {
+	for _, label := range labels {
+		if err := f.checkLabelExists(owner, repo, number, label); err != nil {
+			return err
+		}
+	}
+	f.LabelsReplaced = append(f.LabelsReplaced, fmt.Sprintf("%s/%s#%d:%s", owner, repo, number, strings.Join(labels, ",")))
+	return nil
+}
+
+func (f *FakeClient) checkLabelExists(owner, repo string, number int, label string) error {
+	if f.ExistingLabels == nil {
+		return nil
+	}
+	for _, l := range f.ExistingLabels {
+		if label == l {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot add %v to %s/%s/#%d", label, owner, repo, number)
+}
+
 // FindIssues returns f.Issues
 func (f *FakeClient) FindIssues(query, sort string, asc bool) ([]github.Issue, error) {
 	return f.Issues, nil
 }
 
+// FindAllIssues returns f.Issues
+func (f *FakeClient) FindAllIssues(query, sort string, asc bool) ([]github.Issue, error) {
+	return f.Issues, nil
+}
+
 func (f *FakeClient) AssignIssue(owner, repo string, number int, assignees []string) error {
 	var m github.MissingUsers
 	for _, a := range assignees {
@@ -223,3 +298,71 @@ func (f *FakeClient) GetFile(org, repo, file, commit string) ([]byte, error) {
 func (f *FakeClient) ListTeamMembers(teamID int) ([]github.TeamMember, error) {
 	return []github.TeamMember{{Login: "sig-lead"}}, nil
 }
+
+// RequestReview records the requested reviewers in f.ReviewersRequested.
+func (f *FakeClient) RequestReview(org, repo string, number int, logins []string) error {
+	for _, login := range logins {
+		f.ReviewersRequested = append(f.ReviewersRequested, fmt.Sprintf("%s/%s#%d:%s", org, repo, number, login))
+	}
+	return nil
+}
+
+// CreateReview records a review by the bot in f.Reviews.
+func (f *FakeClient) CreateReview(org, repo string, number int, r github.DraftReview) error {
+	if f.Reviews == nil {
+		f.Reviews = map[int][]github.Review{}
+	}
+	state := github.ReviewStatePending
+	switch r.Action {
+	case github.Approve:
+		state = github.ReviewStateApproved
+	case github.RequestChanges:
+		state = github.ReviewStateChangesRequested
+	case github.Comment:
+		state = github.ReviewStateCommented
+	}
+	botName, _ := f.BotName()
+	f.Reviews[number] = append(f.Reviews[number], github.Review{
+		ID:    f.ReviewID,
+		User:  github.User{Login: botName},
+		Body:  r.Body,
+		State: state,
+	})
+	f.ReviewID++
+	return nil
+}
+
+func (f *FakeClient) ListReviews(org, repo string, number int) ([]github.Review, error) {
+	return append([]github.Review{}, f.Reviews[number]...), nil
+}
+
+func (f *FakeClient) DismissReview(org, repo string, number, ID int, message string) error {
+	for i, r := range f.Reviews[number] {
+		if r.ID == ID {
+			f.Reviews[number][i].State = github.ReviewStateDismissed
+			f.ReviewsDismissed = append(f.ReviewsDismissed, fmt.Sprintf("%s/%s#%d:%d", org, repo, number, ID))
+			return nil
+		}
+	}
+	return fmt.Errorf("could not find review %d on %s/%s#%d", ID, org, repo, number)
+}
+
+// ListIssueEvents returns f.IssueEvents for the issue.
+func (f *FakeClient) ListIssueEvents(org, repo string, number int) ([]github.ListedIssueEvent, error) {
+	return append([]github.ListedIssueEvent{}, f.IssueEvents[number]...), nil
+}
+
+// UpdateRepoLabel updates the label in f.RepoLabels.
+func (f *FakeClient) UpdateRepoLabel(org, repo, label, color, description string) error {
+	for i, l := range f.RepoLabels {
+		if l.Name == label {
+			f.RepoLabels[i].Color = color
+			if description != "" {
+				f.RepoLabels[i].Description = description
+			}
+			f.RepoLabelsUpdated = append(f.RepoLabelsUpdated, fmt.Sprintf("%s/%s:%s", org, repo, label))
+			return nil
+		}
+	}
+	return fmt.Errorf("could not find label %q", label)
+}
@@ -18,6 +18,7 @@ package github
 
 import (
 	"strings"
+	"time"
 )
 
 // These are possible State entries for a Status.
@@ -61,8 +62,12 @@ type User struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 	ID    int    `json:"id"`
+	Type  string `json:"type"`
 }
 
+// UserTypeBot is the type of GitHub App and other bot accounts.
+const UserTypeBot = "Bot"
+
 // NormLogin normalizes GitHub login strings
 var NormLogin = strings.ToLower
 
@@ -83,6 +88,8 @@ const (
 	PullRequestActionClosed                                      = "closed"
 	PullRequestActionReopened                                    = "reopened"
 	PullRequestActionSynchronize                                 = "synchronize"
+	PullRequestActionMilestoned                                  = "milestoned"
+	PullRequestActionDemilestoned                                = "demilestoned"
 )
 
 // PullRequestEvent is what GitHub sends us when a PR is changed.
@@ -92,6 +99,27 @@ type PullRequestEvent struct {
 	PullRequest PullRequest            `json:"pull_request"`
 	Repo        Repo                   `json:"repository"`
 	Label       Label                  `json:"label"`
+	// Changes is only set for edited events.
+	Changes *PullRequestEventChanges `json:"changes,omitempty"`
+}
+
+// PullRequestEventChanges holds the previous values of the fields changed
+// by an edited event. Fields that didn't change are nil.
+type PullRequestEventChanges struct {
+	Title *EditedFrom `json:"title,omitempty"`
+	Body  *EditedFrom `json:"body,omitempty"`
+	Base  *BaseChange `json:"base,omitempty"`
+}
+
+// BaseChange holds the previous base of a PR whose base branch was changed.
+type BaseChange struct {
+	Ref EditedFrom `json:"ref"`
+	SHA EditedFrom `json:"sha"`
+}
+
+// EditedFrom is the previous value of an edited field.
+type EditedFrom struct {
+	From string `json:"from"`
 }
 
 // PullRequest contains information about a PullRequest.
@@ -105,8 +133,11 @@ type PullRequest struct {
 	Body               string            `json:"body"`
 	RequestedReviewers []User            `json:"requested_reviewers"`
 	Assignees          []User            `json:"assignees"`
+	Labels             []Label           `json:"labels"`
 	State              string            `json:"state"`
 	Merged             bool              `json:"merged"`
+	CreatedAt          time.Time         `json:"created_at"`
+	Milestone          *Milestone        `json:"milestone,omitempty"`
 	// ref https://developer.github.com/v3/pulls/#get-a-single-pull-request
 	// If Merged is true, MergeSHA is the SHA of the merge commit, or squashed commit
 	// If Merged is false, MergeSHA is a commit SHA that github created to test if
@@ -114,6 +145,12 @@ type PullRequest struct {
 	MergeSHA *string `json:"merge_commit_sha"`
 }
 
+// Milestone is a milestone on an issue or PR.
+type Milestone struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
 // PullRequestBranch contains information about a particular branch in a PR.
 type PullRequestBranch struct {
 	Ref  string `json:"ref"`
@@ -122,9 +159,10 @@ type PullRequestBranch struct {
 }
 
 type Label struct {
-	URL   string `json:"url"`
-	Name  string `json:"name"`
-	Color string `json:"color"`
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description,omitempty"`
 }
 
 // PullRequestFileStatus enumerates the statuses for this webhook payload type.
@@ -180,6 +218,21 @@ type IssueEvent struct {
 	Repo   Repo             `json:"repository"`
 }
 
+// These are some of the possible Event entries for a ListedIssueEvent.
+const (
+	ListedIssueEventLabeled   = "labeled"
+	ListedIssueEventUnlabeled = "unlabeled"
+)
+
+// ListedIssueEvent is an event on the timeline of an issue or PR, as listed
+// by the issue events API. Label is only set for label events.
+type ListedIssueEvent struct {
+	Event     string    `json:"event"`
+	Actor     User      `json:"actor"`
+	Label     Label     `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // IssueCommentEventAction enumerates the triggers for this
 // webhook payload type. See also:
 // https://developer.github.com/v3/activity/events/types/#issuecommentevent
@@ -199,14 +252,15 @@ type IssueCommentEvent struct {
 }
 
 type Issue struct {
-	User      User    `json:"user"`
-	Number    int     `json:"number"`
-	Title     string  `json:"title"`
-	State     string  `json:"state"`
-	HTMLURL   string  `json:"html_url"`
-	Labels    []Label `json:"labels"`
-	Assignees []User  `json:"assignees"`
-	Body      string  `json:"body"`
+	User      User      `json:"user"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	HTMLURL   string    `json:"html_url"`
+	Labels    []Label   `json:"labels"`
+	Assignees []User    `json:"assignees"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
 
 	// This will be non-nil if it is a pull request.
 	PullRequest *struct{} `json:"pull_request,omitempty"`
@@ -239,10 +293,11 @@ func (i Issue) HasLabel(labelToFind string) bool {
 }
 
 type IssueComment struct {
-	ID      int    `json:"id,omitempty"`
-	Body    string `json:"body"`
-	User    User   `json:"user,omitempty"`
-	HTMLURL string `json:"html_url,omitempty"`
+	ID        int       `json:"id,omitempty"`
+	Body      string    `json:"body"`
+	User      User      `json:"user,omitempty"`
+	HTMLURL   string    `json:"html_url,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
 }
 
 type StatusEvent struct {
@@ -308,6 +363,15 @@ type ReviewEvent struct {
 	Review      Review            `json:"review"`
 }
 
+// These are possible State entries for a Review.
+const (
+	ReviewStateApproved         = "APPROVED"
+	ReviewStateChangesRequested = "CHANGES_REQUESTED"
+	ReviewStateCommented        = "COMMENTED"
+	ReviewStateDismissed        = "DISMISSED"
+	ReviewStatePending          = "PENDING"
+)
+
 // Review describes a Pull Request review.
 type Review struct {
 	ID      int    `json:"id"`
@@ -315,6 +379,8 @@ type Review struct {
 	Body    string `json:"body"`
 	State   string `json:"state"`
 	HTMLURL string `json:"html_url"`
+
+	SubmittedAt time.Time `json:"submitted_at"`
 }
 
 // ReviewCommentEventAction enumerates the triggers for this
@@ -0,0 +1,55 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pluginhelp defines structures that represent the help of plugins,
+// eg. for /help and the plugin catalog.
+package pluginhelp
+
+// Command is a serializable representation of the help for a single
+// command.
+type Command struct {
+	// Usage is a usage string for the command, eg. "/release-note-none".
+	Usage string
+	// Featured is true if the command should be highlighted.
+	Featured bool
+	// Description is a short description of what the command does.
+	Description string
+	// Examples are examples of using the command.
+	Examples []string
+	// WhoCanUse describes who may run the command.
+	WhoCanUse string
+}
+
+// PluginHelp is a serializable representation of the help for a single
+// plugin.
+type PluginHelp struct {
+	// Description describes what the plugin does.
+	Description string
+	// WhoCanUse describes who may use the plugin through its commands.
+	WhoCanUse string
+	// Config maps repos, eg. "org/repo", to a description of the plugin's
+	// configuration for them.
+	Config map[string]string
+	// Events are the GitHub events the plugin handles.
+	Events []string
+	// Commands are the commands the plugin provides.
+	Commands []Command
+}
+
+// AddCommand adds the help for a command to the plugin's help.
+func (pluginHelp *PluginHelp) AddCommand(command Command) {
+	pluginHelp.Commands = append(pluginHelp.Commands, command)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package plugins
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"strings"
@@ -30,6 +32,7 @@ import (
 	"k8s.io/test-infra/prow/git"
 	"k8s.io/test-infra/prow/github"
 	"k8s.io/test-infra/prow/kube"
+	"k8s.io/test-infra/prow/pluginhelp"
 	"k8s.io/test-infra/prow/slack"
 )
 
@@ -43,8 +46,24 @@ var (
 	reviewEventHandlers        = map[string]ReviewEventHandler{}
 	reviewCommentEventHandlers = map[string]ReviewCommentEventHandler{}
 	statusEventHandlers        = map[string]StatusEventHandler{}
+	helpProviders              = map[string]HelpProvider{}
 )
 
+// HelpProvider constructs the help of a plugin from its configuration and the
+// repos it is enabled in.
+type HelpProvider func(config *Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error)
+
+// RegisterHelpProvider registers the help provider of a plugin.
+func RegisterHelpProvider(name string, fn HelpProvider) {
+	allPlugins[name] = struct{}{}
+	helpProviders[name] = fn
+}
+
+// HelpProviders returns the registered help providers by plugin name.
+func HelpProviders() map[string]HelpProvider {
+	return helpProviders
+}
+
 type IssueHandler func(PluginClient, github.IssueEvent) error
 
 func RegisterIssueHandler(name string, fn IssueHandler) {
@@ -135,6 +154,8 @@ type Configuration struct {
 	Slack           Slack               `json:"slack,omitempty"`
 	// ConfigUpdater holds config for the config-updater plugin.
 	ConfigUpdater ConfigUpdater `json:"config_updater,omitempty"`
+	// ReleaseNotes holds per-org and per-repo config for the release-note plugin.
+	ReleaseNotes []ReleaseNote `json:"release_notes,omitempty"`
 }
 
 type Trigger struct {
@@ -180,6 +201,387 @@ type ConfigUpdater struct {
 	PluginFile string `json:"plugin_file,omitempty"`
 }
 
+// ReleaseNote contains the configuration options for the release-note plugin.
+type ReleaseNote struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// NoteFences are the code fence info strings that mark a release note
+	// block, eg. "release-note" matches ```release-note. Defaults to
+	// "release-note" and "release-notes"; projects writing ```changelog list
+	// "changelog" along with them.
+	NoteFences []string `json:"note_fences,omitempty"`
+	// NoteHeading is the PR template heading that may directly precede an
+	// untagged release note fence. Defaults to "Release note".
+	NoteHeading string `json:"note_heading,omitempty"`
+	// ActionRequiredPhrases are the phrases that, when found in a release
+	// note, mark it as requiring action. Matching is case insensitive.
+	// Defaults to "action required".
+	ActionRequiredPhrases []string `json:"action_required_phrases,omitempty"`
+	// WarnRelativeLinks enables a non-blocking comment suggesting absolute
+	// URLs when the release note contains relative links, which won't
+	// resolve once the note is published in a changelog.
+	WarnRelativeLinks bool `json:"warn_relative_links,omitempty"`
+	// RequireNoteOverChangedLines, when positive, lets PRs that change at
+	// most this many lines (additions plus deletions) with an empty release
+	// note block be labeled release-note-none automatically. Larger PRs must
+	// follow the full release note process.
+	RequireNoteOverChangedLines int `json:"require_note_over_changed_lines,omitempty"`
+	// InheritDependsOnNote lets a PR with an empty release note block
+	// inherit the release note of a PR in the same repo that it references
+	// with "Depends on #N" or "Part of #N", eg. the top PR of a stack.
+	InheritDependsOnNote bool `json:"inherit_depends_on_note,omitempty"`
+	// MigrateDeprecatedLabels makes reconciles replace the deprecated
+	// release-note-label-needed label with its current equivalent, without
+	// commenting again on PRs that were already told to add a release note.
+	MigrateDeprecatedLabels bool `json:"migrate_deprecated_labels,omitempty"`
+	// ReadOnlyRepos are repos, of the form org/repo or just org, in which the
+	// plugin still comments but only logs the label changes it would make.
+	// This is useful when migrating a repo to the plugin.
+	ReadOnlyRepos []string `json:"read_only_repos,omitempty"`
+	// UseReviewForGuidance posts the guidance for following the release note
+	// process as a review requesting changes instead of a comment, and
+	// dismisses that review once the process has been followed.
+	UseReviewForGuidance bool `json:"use_review_for_guidance,omitempty"`
+	// ActionRequiredLabels are labels, eg. kind/action-required, that
+	// upgrade a PR with a release note to release-note-action-required even
+	// if the note itself doesn't say that action is required.
+	ActionRequiredLabels []string `json:"action_required_labels,omitempty"`
+	// MentionOnNeeded are GitHub users or teams, eg. "org/release-team",
+	// to mention once a PR has been edited MentionAfterEdits times since it
+	// was told that it needs a release note, without adding one.
+	MentionOnNeeded   []string `json:"mention_on_needed,omitempty"`
+	MentionAfterEdits int      `json:"mention_after_edits,omitempty"`
+	// NoTemplateBehavior controls PRs whose body has neither a release note
+	// fence nor the NoteHeading, eg. in forks that never adopted the PR
+	// template. "block" (the default) requires a release note as usual,
+	// "auto-none" labels them release-note-none and "ignore" leaves them
+	// unlabeled.
+	NoTemplateBehavior string `json:"no_template_behavior,omitempty"`
+	// ReportStatus sets a "release-note" commit status on the head of each
+	// PR describing why the PR does or doesn't need a release note.
+	ReportStatus bool `json:"report_status,omitempty"`
+	// RstNoteDirective also recognizes release notes written as a
+	// reStructuredText ".. release-note::" directive, whose indented content
+	// is the note. Markdown release note blocks take precedence.
+	RstNoteDirective bool `json:"rst_note_directive,omitempty"`
+	// NonePreconditions must all be met before /release-note-none is
+	// honored, in addition to the user being the author or an org member.
+	NonePreconditions []NonePrecondition `json:"none_preconditions,omitempty"`
+	// KeepStaleComments keeps the bot's release note comments once they no
+	// longer apply instead of deleting them, eg. for auditing.
+	KeepStaleComments bool `json:"keep_stale_comments,omitempty"`
+	// WelcomeFirstTimers greets authors opening their first PR in a repo
+	// with detailed instructions for writing a release note. With
+	// LabelDelay, they are greeted on the first event after the delay.
+	WelcomeFirstTimers bool `json:"welcome_first_timers,omitempty"`
+	// ManualRemovalCooldown, eg. "24h", stops the plugin from adding
+	// release-note-none back for this long after a human removed it, eg. to
+	// require a real release note. Disabled by default.
+	ManualRemovalCooldown string `json:"manual_removal_cooldown,omitempty"`
+	// PrimaryBranches are glob patterns, eg. "release-next", of the branches
+	// that PRs are developed against rather than cherry-picked into. PRs
+	// into them always require a release note. Defaults to "master".
+	PrimaryBranches []string `json:"primary_branches,omitempty"`
+	// ActionRequiredDelimiter, eg. "**Action required:**", must appear in
+	// release notes that require action, separating the action from the
+	// rest of the note. Notes without it need a release note.
+	ActionRequiredDelimiter string `json:"action_required_delimiter,omitempty"`
+	// DuplicateCommentWindow, eg. "1m", stops the plugin from posting a
+	// comment identical to one it posted within this long, eg. when a
+	// webhook is delivered twice. Disabled by default.
+	DuplicateCommentWindow string `json:"duplicate_comment_window,omitempty"`
+	// StrictNoneOnly only treats release notes that are exactly "none" as
+	// not needing a release note. If false, notes starting with the word
+	// "none", eg. "none; internal metric renamed", are too. Defaults to true.
+	StrictNoneOnly *bool `json:"strict_none_only,omitempty"`
+	// RecordActionRequiredNotes lets org members run
+	// "/release-note-action-required <note>" to label a PR
+	// release-note-action-required and record the note in a comment, eg.
+	// when the PR body is locked.
+	RecordActionRequiredNotes bool `json:"record_action_required_notes,omitempty"`
+	// CherryPickBot is the login of the bot that opens cherry-pick PRs.
+	CherryPickBot string `json:"cherry_pick_bot,omitempty"`
+	// TrustCherryPickBotNote lets cherry-picks opened by CherryPickBot with a
+	// release note of their own skip checking that their parents have one.
+	TrustCherryPickBotNote bool `json:"trust_cherry_pick_bot_note,omitempty"`
+	// LabelAppearances are the expected colors and descriptions of the
+	// release note labels, by label name.
+	LabelAppearances map[string]LabelAppearance `json:"label_appearances,omitempty"`
+	// AreaNotes lets PRs have a release note block per area, tagged like
+	// ```release-note area/network, and labels them with each area in
+	// AllowedAreas that has a note. The notes are classified together.
+	AreaNotes    bool     `json:"area_notes,omitempty"`
+	AllowedAreas []string `json:"allowed_areas,omitempty"`
+	// ReplyOnNonPR replies to release note commands on issues, explaining
+	// that they only apply to PRs, instead of ignoring them.
+	ReplyOnNonPR bool `json:"reply_on_non_pr,omitempty"`
+	// ForceNeededLabels, eg. needs-release-note, keep a PR that has any of
+	// them blocked until it has a real release note, even if it would
+	// otherwise be labeled release-note-none.
+	ForceNeededLabels []string `json:"force_needed_labels,omitempty"`
+	// BatchLabelChanges replaces the PR's labels in a single call when more
+	// than one release note label needs to be added or removed.
+	BatchLabelChanges bool `json:"batch_label_changes,omitempty"`
+	// HonorNoNoteCheckbox treats a checked "- [x] No release note needed"
+	// task list item as release-note-none when the release note is empty.
+	HonorNoNoteCheckbox bool `json:"honor_no_note_checkbox,omitempty"`
+	// InheritFromUpstreamRef lets a PR in a downstream mirror with an empty
+	// release note inherit the release note of the upstream PR referenced
+	// with "Upstream: owner/repo#N".
+	InheritFromUpstreamRef bool `json:"inherit_from_upstream_ref,omitempty"`
+	// LabelDelay, eg. "5m", is how long after a PR is created to wait before
+	// labeling it or commenting on it, so label-reactive automation doesn't
+	// fire prematurely. The PR is evaluated again on the next event, and
+	// until then its status, if reported, is pending.
+	LabelDelay string `json:"label_delay,omitempty"`
+	// StrictMilestones are the titles of milestones, eg. "v1.9", whose PRs
+	// need a real release note. release-note-none isn't accepted for them.
+	StrictMilestones []string `json:"strict_milestones,omitempty"`
+	// ParentCommentTemplate is a Go template for the comment asking for a
+	// release note on a cherry-pick whose parents don't have one. It is
+	// passed .Author and .Parents, whose items have .Number and .HasNote.
+	// The default comment is used if it is empty.
+	ParentCommentTemplate string `json:"parent_comment_template,omitempty"`
+	// NoteFromFile is a glob, eg. "releasenotes/*.md", matching release note
+	// files. If a PR with an empty release note block adds or changes a
+	// matching file, the release note is taken from that file instead.
+	NoteFromFile string `json:"note_from_file,omitempty"`
+	// ForbiddenFlagPatterns are regexps, eg. `--feature-gates=\S*Alpha\S*`,
+	// matching feature flags that release notes must not mention. A matching
+	// note gets a warning comment.
+	ForbiddenFlagPatterns []string `json:"forbidden_flag_patterns,omitempty"`
+	// HardEnforceFlags blocks PRs whose release note matches any of
+	// ForbiddenFlagPatterns instead of only warning.
+	HardEnforceFlags bool `json:"hard_enforce_flags,omitempty"`
+	// MaxParents is the most cherry-pick parents that are checked for a
+	// release note. The author is asked to clean up PR bodies that reference
+	// more. Defaults to 20.
+	MaxParents int `json:"max_parents,omitempty"`
+	// LabelInheritedNotes adds the informational release-note-inherited
+	// label to cherry-picks that don't need their own release note because
+	// their parents have one.
+	LabelInheritedNotes bool `json:"label_inherited_notes,omitempty"`
+	// EmptyActionRequiredBehavior controls release notes that say that action
+	// is required without describing the action. "accept" (the default)
+	// labels them release-note-action-required as usual, "block" requires a
+	// real release note and "label" also adds the needs-rereview label.
+	EmptyActionRequiredBehavior string `json:"empty_action_required_behavior,omitempty"`
+	// ExpectedNoteLanguage, eg. "en", is the primary language of the repo.
+	// If set, PRs whose release note appears to be in a language with a
+	// different script get a suggestion comment. It never blocks a PR.
+	ExpectedNoteLanguage string `json:"expected_note_language,omitempty"`
+	// PermissionRejectionTemplate, PreconditionRejectionTemplate and
+	// PrecedenceRejectionTemplate are Go templates for the replies to a
+	// /release-note-none command from a user who may not use it, who hasn't
+	// met a NonePreconditions entry, or on a PR whose body already has a
+	// release note. They are passed .Label and .User, and the precondition
+	// template also .Precondition. The default replies are used if empty.
+	PermissionRejectionTemplate   string `json:"permission_rejection_template,omitempty"`
+	PreconditionRejectionTemplate string `json:"precondition_rejection_template,omitempty"`
+	PrecedenceRejectionTemplate   string `json:"precedence_rejection_template,omitempty"`
+	// FlagNoteChangesAfterApproval labels and comments on PRs whose release
+	// note is edited after they were approved.
+	FlagNoteChangesAfterApproval bool `json:"flag_note_changes_after_approval,omitempty"`
+	// AutoPopulateCherryPickNote gives cherry-picks without a release note
+	// of their own the combined release notes of their parents. The combined
+	// note is recorded in a comment and the PR is labeled accordingly.
+	AutoPopulateCherryPickNote bool `json:"auto_populate_cherry_pick_note,omitempty"`
+	// FrontMatterNotes reads the release note from a "release-note" key in
+	// YAML front matter at the top of the PR body if the release note block
+	// is empty. A "skip" or "none" value means no release note is needed.
+	FrontMatterNotes bool `json:"front_matter_notes,omitempty"`
+	// FrontMatterOverridesFence makes the front matter take precedence over
+	// the release note block.
+	FrontMatterOverridesFence bool `json:"front_matter_overrides_fence,omitempty"`
+	// NoneCommandAllowedBots, if set, are the only bot accounts whose
+	// /release-note-none commands are honored. Commands from other bots are
+	// ignored.
+	NoneCommandAllowedBots []string `json:"none_command_allowed_bots,omitempty"`
+	// ResolveInsteadOfRemove adds a release-note-was-needed label when the
+	// needed label is removed from a PR, so that it remains visible that
+	// the PR was once blocked on its release note.
+	ResolveInsteadOfRemove bool `json:"resolve_instead_of_remove,omitempty"`
+	// NoneSentinel is an additional word, eg. "keine", that marks a release
+	// note as none like "none" does. Its alias command, eg. /keine, applies
+	// release-note-none like /release-note-none does.
+	NoneSentinel string `json:"none_sentinel,omitempty"`
+	// AutoNoneMinApprovals is the number of approving reviews a PR needs
+	// before it is labeled release-note-none for being exempt, eg. for
+	// lacking the template or being small. Until then it keeps the needed
+	// label.
+	AutoNoneMinApprovals int `json:"auto_none_min_approvals,omitempty"`
+	// MaxBotComments, if set, is the number of comments the plugin posts on
+	// a PR before it stops commenting and only manages labels.
+	MaxBotComments int `json:"max_bot_comments,omitempty"`
+	// ChangelogLineTemplate is a Go template for the changelog line of each
+	// PR, eg. in release notes populated from cherry-pick parents. It is
+	// passed the flattened .Note, the .PR number and the PR's .Author, and
+	// defaults to "{{.Note}} (#{{.PR}}, @{{.Author}})".
+	ChangelogLineTemplate string `json:"changelog_line_template,omitempty"`
+	// ActionRequiredChangelogPrefix, eg. "⚠️", is prepended to the notes of
+	// action required changelog lines.
+	ActionRequiredChangelogPrefix string `json:"action_required_changelog_prefix,omitempty"`
+	// WarnVerboseNotes enables a non-blocking comment suggesting a concise
+	// note when the release note repeats the PR description.
+	WarnVerboseNotes bool `json:"warn_verbose_notes,omitempty"`
+	// SkipBranches are glob patterns, eg. "docs-*", of the branches whose
+	// PRs are exempt from the release note process entirely: the plugin
+	// neither labels nor comments on them.
+	SkipBranches []string `json:"skip_branches,omitempty"`
+	// SecurityNotePatterns are regexes, eg. `CVE-\d` or `(?i)security fix`,
+	// of release notes to label release-note-security for review by the
+	// security team. The label doesn't block merging.
+	SecurityNotePatterns []string `json:"security_note_patterns,omitempty"`
+	// BodyNoteWinsSilently ignores /release-note-none commands on PRs whose
+	// body has a release note instead of replying to explain why.
+	BodyNoteWinsSilently bool `json:"body_note_wins_silently,omitempty"`
+	// CacheClassifications caches the label determined from each PR's body
+	// in memory, so it isn't determined again when an event leaves the
+	// body unchanged. Labels are still reconciled on every event.
+	CacheClassifications bool `json:"cache_classifications,omitempty"`
+	// FormFieldNotes also recognizes release notes written in a "Release
+	// note" field of a GitHub form, which is rendered as a "### Release
+	// note" heading followed by the answer. An unanswered field means no
+	// release note is needed. Markdown release note blocks take precedence.
+	FormFieldNotes bool `json:"form_field_notes,omitempty"`
+	// StickyCommentRecreate keeps exactly one guidance comment on PRs that
+	// need a release note: it is posted again if it was deleted, and
+	// duplicates are deleted.
+	StickyCommentRecreate bool `json:"sticky_comment_recreate,omitempty"`
+	// RequireNoteForPaths are glob patterns, eg. "pkg/apis" or "*/api", of
+	// files and directories whose PRs always need a release note, even if
+	// they would otherwise be labeled release-note-none automatically, eg.
+	// for being small or lacking the template.
+	RequireNoteForPaths []string `json:"require_note_for_paths,omitempty"`
+	// WarnLinkPlaceholders enables a non-blocking comment when the release
+	// note contains links whose targets were left as placeholders, eg.
+	// [docs](URL), [docs](link) or [docs]().
+	WarnLinkPlaceholders bool `json:"warn_link_placeholders,omitempty"`
+	// ActionRequiredReviewTeamID is the ID of a GitHub team whose members are
+	// requested to review a PR when its release note becomes action required.
+	ActionRequiredReviewTeamID int `json:"action_required_review_team_id,omitempty"`
+	// FallbackToEventLabels makes the plugin use the labels from the PR event
+	// if listing the PR's labels fails, instead of giving up until the next
+	// event. The event's labels may be stale.
+	FallbackToEventLabels bool `json:"fallback_to_event_labels,omitempty"`
+	// DebounceWindow, eg. "10s", makes the plugin wait that long after a PR
+	// event and only process it if no other event arrived for the PR in the
+	// meantime, so that a burst of edits is processed once.
+	DebounceWindow string `json:"debounce_window,omitempty"`
+	// BlockReferenceOnlyNotes makes the plugin treat release notes that only
+	// reference issues, PRs or URLs, eg. "#1234", as missing.
+	BlockReferenceOnlyNotes bool `json:"block_reference_only_notes,omitempty"`
+	// RevertNoteBehavior controls revert PRs, ie. PRs titled `Revert "..."`,
+	// without a release note. "require" (the default) requires one as usual
+	// and "auto-none" labels them release-note-none. Revert PRs with a
+	// release note are always labeled by it.
+	RevertNoteBehavior string `json:"revert_note_behavior,omitempty"`
+	// ConsolidateSuggestions makes the plugin post its non-blocking
+	// suggestions about the release note, eg. from WarnRelativeLinks, in a
+	// single comment that is edited as they change, instead of one comment
+	// each.
+	ConsolidateSuggestions bool `json:"consolidate_suggestions,omitempty"`
+	// ConventionalCommitTitles derives the release note of PRs without one
+	// from their Conventional Commits title: "feat: ..." and "fix: ..." titles
+	// are release notes, and a "!" after the type, eg. "fix!: ...", or a
+	// "BREAKING CHANGE:" footer in the body makes any title action required.
+	ConventionalCommitTitles bool `json:"conventional_commit_titles,omitempty"`
+	// BumpNotes synthesize the release notes of automated bump PRs without
+	// one. The first entry matching a PR is used.
+	BumpNotes []BumpNote `json:"bump_notes,omitempty"`
+	// WarnMalformedMarkdown enables a non-blocking comment when the release
+	// note has unbalanced markdown, eg. unclosed emphasis, code spans or
+	// brackets, which renders poorly in the changelog.
+	WarnMalformedMarkdown bool `json:"warn_malformed_markdown,omitempty"`
+	// Labels overrides the names of the release note labels, eg. for forks
+	// with their own labeling scheme.
+	Labels ReleaseNoteLabels `json:"labels,omitempty"`
+	// CommandRateLimit limits how often each user can use the
+	// /release-note-none command in a repo. Only applied commands count, and
+	// commands beyond the limit get a cool-down notice instead of changing
+	// the labels.
+	CommandRateLimit CommandRateLimit `json:"command_rate_limit,omitempty"`
+	// NoneSynonyms are release notes that, like "none", mean the PR doesn't
+	// need one. They must match the whole note, ignoring case and surrounding
+	// whitespace. Defaults to "n/a", "na" and "not applicable"; "none" is
+	// always honored.
+	NoneSynonyms []string `json:"none_synonyms,omitempty"`
+	// RequireOnlyWhenLabeled, eg. "needs-release-note", inverts the default
+	// for low-ceremony repos: only PRs with this label must follow the
+	// release note process, and the plugin leaves other PRs alone.
+	RequireOnlyWhenLabeled string `json:"require_only_when_labeled,omitempty"`
+
+	// hash identifies the values above. It is computed when the config is
+	// loaded, see Hash.
+	hash string
+}
+
+// Hash identifies the values of the config, eg. to tell whether results
+// computed with another config still apply. It is computed once when the
+// config is loaded, so copies of a loaded config share its hash.
+func (r *ReleaseNote) Hash() string {
+	if r.hash != "" {
+		return r.hash
+	}
+	return hashReleaseNote(r)
+}
+
+func hashReleaseNote(r *ReleaseNote) string {
+	// Every field can be marshalled.
+	b, _ := json.Marshal(r)
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// LabelAppearance is how a label looks on GitHub.
+type LabelAppearance struct {
+	// Color is the hex color of the label, eg. "c2e0c6".
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// BumpNote synthesizes the release note of automated bump PRs, eg. image or
+// dependency bumps.
+type BumpNote struct {
+	// Authors are the logins of the bots opening the PRs.
+	Authors []string `json:"authors,omitempty"`
+	// TitlePattern is a regexp the PRs' titles must match, eg.
+	// `^Bump (?P<name>\S+) to (?P<version>\S+)$`.
+	TitlePattern string `json:"title_pattern,omitempty"`
+	// NoteTemplate is a Go template for the release note. It is passed the
+	// PR's .Title and the named groups of TitlePattern as .Groups, eg.
+	// "Updated {{.Groups.name}} to version {{.Groups.version}}."
+	NoteTemplate string `json:"note_template,omitempty"`
+}
+
+// ReleaseNoteLabels are the names of the release note labels. Empty names
+// default to the upstream ones, eg. "release-note-none".
+type ReleaseNoteLabels struct {
+	ReleaseNote    string `json:"release_note,omitempty"`
+	None           string `json:"none,omitempty"`
+	ActionRequired string `json:"action_required,omitempty"`
+	Needed         string `json:"needed,omitempty"`
+}
+
+// CommandRateLimit limits how many commands a user can use within a window.
+type CommandRateLimit struct {
+	// Commands is the most commands a user can use within Window. Zero means
+	// no limit.
+	Commands int `json:"commands,omitempty"`
+	// Window is a duration, eg. "1h".
+	Window string `json:"window,omitempty"`
+}
+
+// NonePrecondition is a precondition for the /release-note-none command. It
+// is met if any of its conditions hold.
+type NonePrecondition struct {
+	// TeamIDs are the IDs of GitHub teams whose members meet the precondition.
+	TeamIDs []int `json:"team_ids,omitempty"`
+	// Approved is met if the PR has at least one approving review.
+	Approved bool `json:"approved,omitempty"`
+	// Labels are met if the PR has any of them, eg. "approved".
+	Labels []string `json:"labels,omitempty"`
+}
+
 // MergeWarning is a config for the slackevents plugin's manual merge warings.
 // If a PR is pushed to any of the repos listed in the config
 // then send messages to the all the  slack channels listed if pusher is NOT in the whitelist.
@@ -206,6 +608,28 @@ func (c *Configuration) TriggerFor(org, repo string) *Trigger {
 	return nil
 }
 
+// ReleaseNoteFor finds the ReleaseNote config for a repo. Config listed for
+// the repo itself takes precedence over config listed for the owning
+// organization. If neither exists an empty config is returned.
+func (c *Configuration) ReleaseNoteFor(org, repo string) *ReleaseNote {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	var orgConfig *ReleaseNote
+	for i, rn := range c.ReleaseNotes {
+		for _, r := range rn.Repos {
+			if r == fullName {
+				return &c.ReleaseNotes[i]
+			}
+			if r == org && orgConfig == nil {
+				orgConfig = &c.ReleaseNotes[i]
+			}
+		}
+	}
+	if orgConfig != nil {
+		return orgConfig
+	}
+	return &ReleaseNote{}
+}
+
 func (c *Configuration) setDefaults() {
 	if c.ConfigUpdater.ConfigFile == "" {
 		c.ConfigUpdater.ConfigFile = "prow/config.yaml"
@@ -213,6 +637,9 @@ func (c *Configuration) setDefaults() {
 	if c.ConfigUpdater.PluginFile == "" {
 		c.ConfigUpdater.PluginFile = "prow/plugins.yaml"
 	}
+	for i := range c.ReleaseNotes {
+		c.ReleaseNotes[i].hash = hashReleaseNote(&c.ReleaseNotes[i])
+	}
 }
 
 // Load attempts to load config from the path. It returns an error if either
@@ -83,3 +83,42 @@ func TestGetPlugins(t *testing.T) {
 		}
 	}
 }
+
+func TestReleaseNoteFor(t *testing.T) {
+	c := &Configuration{
+		ReleaseNotes: []ReleaseNote{
+			{Repos: []string{"org"}, NoteHeading: "org"},
+			{Repos: []string{"org/repo"}, NoteHeading: "repo"},
+		},
+	}
+	var testcases = []struct {
+		name     string
+		org      string
+		repo     string
+		expected string
+	}{
+		{
+			name:     "repo config takes precedence over org config",
+			org:      "org",
+			repo:     "repo",
+			expected: "repo",
+		},
+		{
+			name:     "org config applies to other repos in the org",
+			org:      "org",
+			repo:     "other",
+			expected: "org",
+		},
+		{
+			name:     "empty config for an unconfigured org",
+			org:      "other",
+			repo:     "repo",
+			expected: "",
+		},
+	}
+	for _, tc := range testcases {
+		if got := c.ReleaseNoteFor(tc.org, tc.repo).NoteHeading; got != tc.expected {
+			t.Errorf("For case %s, expected config %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// classificationCacheSize is the number of PRs whose classification is
+// cached.
+const classificationCacheSize = 1000
+
+// bodyLabels caches the labels determined from PR bodies if
+// cfg.CacheClassifications is set.
+var bodyLabels = newClassificationCache(classificationCacheSize)
+
+// classificationCache is an LRU cache of the label determined from the body
+// of each PR, keyed by org/repo#number.
+type classificationCache struct {
+	sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type classification struct {
+	key string
+	// cfgHash identifies the config the label was determined with.
+	cfgHash string
+	hash    [sha256.Size]byte
+	// label is the label determined from the body with the hash.
+	label string
+}
+
+func newClassificationCache(size int) *classificationCache {
+	return &classificationCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// label returns the label classify determines from body, reusing the last
+// result for the PR if neither its body nor the values in cfg changed since.
+func (c *classificationCache) label(cfg *plugins.ReleaseNote, org, repo string, number int, body string, classify func() string) string {
+	// Compare configs by hash, since copies of the same config are passed
+	// around, eg. by withoutDebounce.
+	cfgHash := cfg.Hash()
+	key := fmt.Sprintf("%s/%s#%d", org, repo, number)
+	hash := sha256.Sum256([]byte(body))
+	c.Lock()
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*classification)
+		if entry.cfgHash == cfgHash && entry.hash == hash {
+			c.order.MoveToFront(e)
+			c.Unlock()
+			return entry.label
+		}
+	}
+	c.Unlock()
+
+	label := classify()
+
+	c.Lock()
+	defer c.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+	}
+	c.entries[key] = c.order.PushFront(&classification{key: key, cfgHash: cfgHash, hash: hash, label: label})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*classification).key)
+	}
+	return label
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestClassificationCache(t *testing.T) {
+	cache := newClassificationCache(2)
+	cfg := &plugins.ReleaseNote{}
+	computed := 0
+	label := func(number int, body string) string {
+		return cache.label(cfg, "org", "repo", number, body, func() string {
+			computed++
+			return determineReleaseNoteLabel(cfg, body)
+		})
+	}
+	steps := []struct {
+		name           string
+		number         int
+		body           string
+		expectLabel    string
+		expectComputed int
+	}{
+		{
+			name:           "first classification",
+			number:         1,
+			body:           "```release-note\nNONE\n```",
+			expectLabel:    releaseNoteNone,
+			expectComputed: 1,
+		},
+		{
+			name:           "unchanged body isn't classified again",
+			number:         1,
+			body:           "```release-note\nNONE\n```",
+			expectLabel:    releaseNoteNone,
+			expectComputed: 1,
+		},
+		{
+			name:           "changed body is classified again",
+			number:         1,
+			body:           "```release-note\nA note.\n```",
+			expectLabel:    releaseNote,
+			expectComputed: 2,
+		},
+		{
+			name:           "other PRs are classified separately",
+			number:         2,
+			body:           "```release-note\nA note.\n```",
+			expectLabel:    releaseNote,
+			expectComputed: 3,
+		},
+		{
+			name:           "least recently used PR is evicted",
+			number:         3,
+			body:           "```release-note\nA note.\n```",
+			expectLabel:    releaseNote,
+			expectComputed: 4,
+		},
+		{
+			name:           "evicted PR is classified again",
+			number:         1,
+			body:           "```release-note\nA note.\n```",
+			expectLabel:    releaseNote,
+			expectComputed: 5,
+		},
+		{
+			name:           "recently used PR is still cached",
+			number:         3,
+			body:           "```release-note\nA note.\n```",
+			expectLabel:    releaseNote,
+			expectComputed: 5,
+		},
+	}
+	for _, step := range steps {
+		if actual := label(step.number, step.body); actual != step.expectLabel {
+			t.Errorf("(%s): Expected %q, but got %q.", step.name, step.expectLabel, actual)
+		}
+		if computed != step.expectComputed {
+			t.Errorf("(%s): Expected %d classifications, but got %d.", step.name, step.expectComputed, computed)
+		}
+	}
+
+	// A copy of the config, eg. after a reload that didn't change it, still
+	// hits the cache.
+	copied := *cfg
+	cfg = &copied
+	label(3, "```release-note\nA note.\n```")
+	if computed != 5 {
+		t.Errorf("Expected a copied config not to be classified again, but got %d classifications.", computed)
+	}
+
+	// A changed config invalidates the cache.
+	cfg = &plugins.ReleaseNote{NoneSynonyms: []string{"nope"}}
+	label(3, "```release-note\nA note.\n```")
+	if computed != 6 {
+		t.Errorf("Expected a changed config to be classified again, but got %d classifications.", computed)
+	}
+}
+
+func TestCacheClassifications(t *testing.T) {
+	bodyLabels = newClassificationCache(classificationCacheSize)
+	defer func() { bodyLabels = newClassificationCache(classificationCacheSize) }()
+	cfg := &plugins.ReleaseNote{CacheClassifications: true}
+	fc, pr := newFakeClient("```release-note\nNONE\n```", "master", nil, nil, nil)
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	pr.PullRequest.Body = "```release-note\nA note.\n```"
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if expected := formatLabels(1, releaseNoteNone, releaseNote); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+		t.Errorf("Expected labels %q to be added, but got %q.", expected, fc.LabelsAdded)
+	}
+}
+
+// loadedConfig returns the release note config of a loaded plugin config, so
+// that its hash is computed once as it is in hook.
+func loadedConfig(b *testing.B) *plugins.ReleaseNote {
+	f, err := ioutil.TempFile("", "plugins")
+	if err != nil {
+		b.Fatalf("Failed to create config file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("plugins:\n  org/repo:\n  - " + pluginName + "\nrelease_notes:\n- repos:\n  - org/repo\n"); err != nil {
+		b.Fatalf("Failed to write config file: %v", err)
+	}
+	f.Close()
+	pa := &plugins.PluginAgent{}
+	if err := pa.Load(f.Name()); err != nil {
+		b.Fatalf("Failed to load config: %v", err)
+	}
+	return pa.Config().ReleaseNoteFor("org", "repo")
+}
+
+func BenchmarkClassificationCache(b *testing.B) {
+	cfg := loadedConfig(b)
+	cache := newClassificationCache(classificationCacheSize)
+	classify := func() string { return determineReleaseNoteLabel(cfg, benchmarkBody) }
+	for i := 0; i < b.N; i++ {
+		cache.label(cfg, "org", "repo", 1, benchmarkBody, classify)
+	}
+}
+
+// BenchmarkClassificationCacheUncached classifies the body on every call,
+// which is what handlePR does without cfg.CacheClassifications.
+func BenchmarkClassificationCacheUncached(b *testing.B) {
+	cfg := loadedConfig(b)
+	for i := 0; i < b.N; i++ {
+		determineReleaseNoteLabel(cfg, benchmarkBody)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// corpusDir holds PR bodies recorded from real PRs, one per file, along with
+// expected.json, which maps each file name to the label it should get.
+const corpusDir = "testdata/corpus"
+
+// runCorpus runs determineReleaseNoteLabel over every body and fails with a
+// diff of all the bodies whose label doesn't match the expected one.
+func runCorpus(t *testing.T, cfg *plugins.ReleaseNote, bodies, expected map[string]string) {
+	var names []string
+	for name := range bodies {
+		names = append(names, name)
+	}
+	for name := range expected {
+		if _, ok := bodies[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diff []string
+	for _, name := range names {
+		body, ok := bodies[name]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("%s: expected %q, but there is no such body", name, expected[name]))
+			continue
+		}
+		want, ok := expected[name]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("%s: no expected label", name))
+			continue
+		}
+		if got := determineReleaseNoteLabel(cfg, body); got != want {
+			diff = append(diff, fmt.Sprintf("%s: -%q +%q", name, want, got))
+		}
+	}
+	if len(diff) > 0 {
+		t.Errorf("Corpus labels differ from the expected labels:\n%s", strings.Join(diff, "\n"))
+	}
+}
+
+func loadCorpus(t *testing.T, dir string) (bodies, expected map[string]string) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "expected.json"))
+	if err != nil {
+		t.Fatalf("Failed to read expected labels: %v", err)
+	}
+	if err := json.Unmarshal(raw, &expected); err != nil {
+		t.Fatalf("Failed to parse expected labels: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		t.Fatalf("Failed to list corpus bodies: %v", err)
+	}
+	bodies = map[string]string{}
+	for _, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		bodies[filepath.Base(file)] = string(body)
+	}
+	return bodies, expected
+}
+
+func TestCorpus(t *testing.T) {
+	bodies, expected := loadCorpus(t, corpusDir)
+	runCorpus(t, &plugins.ReleaseNote{}, bodies, expected)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// prEvents debounces PR events if cfg.DebounceWindow is set.
+var prEvents = newDebouncer()
+
+// debouncer lets only the last of a burst of events for a PR through. Events
+// are processed from a timer, so no goroutine is held for the window.
+type debouncer struct {
+	sync.Mutex
+	seq int
+	// pending are the latest scheduled events by org/repo#number.
+	pending map[string]pendingEvent
+	// afterFunc is time.AfterFunc, returning the timer's Stop, except in
+	// tests.
+	afterFunc func(time.Duration, func()) func() bool
+}
+
+// pendingEvent is an event waiting for its window to pass.
+type pendingEvent struct {
+	seq  int
+	stop func() bool
+}
+
+func newDebouncer() *debouncer {
+	return &debouncer{
+		pending: map[string]pendingEvent{},
+		afterFunc: func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		},
+	}
+}
+
+// schedule calls process once window has passed, unless another event for
+// the PR is scheduled in the meantime. It returns whether this event
+// superseded a pending one.
+func (d *debouncer) schedule(org, repo string, number int, window time.Duration, process func()) bool {
+	key := fmt.Sprintf("%s/%s#%d", org, repo, number)
+	d.Lock()
+	defer d.Unlock()
+	d.seq++
+	seq := d.seq
+	previous, superseded := d.pending[key]
+	if superseded {
+		previous.stop()
+	}
+	stop := d.afterFunc(window, func() {
+		d.Lock()
+		// The timer may have fired just as a later event stopped it.
+		latest := d.pending[key].seq == seq
+		if latest {
+			delete(d.pending, key)
+		}
+		d.Unlock()
+		if latest {
+			process()
+		}
+	})
+	d.pending[key] = pendingEvent{seq: seq, stop: stop}
+	return superseded
+}
+
+// withoutDebounce returns cfg without a DebounceWindow, for callers that need
+// the event processed before handlePR returns.
+func withoutDebounce(cfg *plugins.ReleaseNote) *plugins.ReleaseNote {
+	if cfg.DebounceWindow == "" {
+		return cfg
+	}
+	copied := *cfg
+	copied.DebounceWindow = ""
+	return &copied
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// fakeTimers is a debouncer afterFunc whose timers fire only when fire is
+// called.
+type fakeTimers struct {
+	sync.Mutex
+	pending []func()
+}
+
+func (f *fakeTimers) afterFunc(_ time.Duration, fn func()) func() bool {
+	f.Lock()
+	defer f.Unlock()
+	f.pending = append(f.pending, fn)
+	return func() bool { return true }
+}
+
+// fire runs all the timers, including stopped ones, which must do nothing.
+func (f *fakeTimers) fire() {
+	f.Lock()
+	pending := f.pending
+	f.pending = nil
+	f.Unlock()
+	for _, fn := range pending {
+		fn()
+	}
+}
+
+func TestDebounceWindow(t *testing.T) {
+	timers := &fakeTimers{}
+	prEvents = newDebouncer()
+	prEvents.afterFunc = timers.afterFunc
+	defer func() { prEvents = newDebouncer() }()
+
+	fc, _ := newFakeClient("", "master", nil, nil, nil)
+	cfg := &plugins.ReleaseNote{DebounceWindow: "1s"}
+	bodies := []string{
+		"```release-note\nNONE\n```",
+		"```release-note\naction required: run the migration\n```",
+		"```release-note\nThe foo command now supports the --bar flag.\n```",
+	}
+	for i, body := range bodies {
+		_, pr := newFakeClient(body, "master", nil, nil, nil)
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("Unexpected error from handlePR for event %d: %v", i, err)
+		}
+	}
+	if len(fc.LabelsAdded) != 0 {
+		t.Fatalf("Expected no labels to be added within the window, but got %q.", fc.LabelsAdded)
+	}
+	timers.fire()
+
+	if expected := formatLabels(1, releaseNote); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+		t.Errorf("Expected only the last event to add labels %q, but got %q.", expected, fc.LabelsAdded)
+	}
+}
+
+func TestDebouncerSeparatePRs(t *testing.T) {
+	d := newDebouncer()
+	processed := make(chan int, 2)
+	for _, number := range []int{1, 2} {
+		number := number
+		if d.schedule("org", "repo", number, time.Millisecond, func() { processed <- number }) {
+			t.Errorf("Expected events for different PRs not to supersede each other.")
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-processed:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected both events to be processed, but got %d.", i)
+		}
+	}
+}
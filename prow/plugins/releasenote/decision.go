@@ -0,0 +1,65 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// Decision is the release note decision for a PR.
+type Decision struct {
+	// Satisfied is whether the PR has followed the release note process.
+	Satisfied bool
+	// Label is the release note label the PR should have, by its name in
+	// the repo's config, or empty if the PR doesn't need one.
+	Label string
+}
+
+// DecisionSink receives the release note decision for every PR event the
+// plugin handles, eg. to feed an external status service.
+type DecisionSink interface {
+	RecordDecision(org, repo string, number int, d Decision) error
+}
+
+type noopSink struct{}
+
+func (noopSink) RecordDecision(org, repo string, number int, d Decision) error {
+	return nil
+}
+
+var decisionSink DecisionSink = noopSink{}
+
+// SetDecisionSink makes the plugin send its decisions to sink. It must be
+// called before the plugin handles any events. A nil sink restores the
+// default, which discards decisions.
+func SetDecisionSink(sink DecisionSink) {
+	if sink == nil {
+		sink = noopSink{}
+	}
+	decisionSink = sink
+}
+
+// recordDecision sends the decision to label the PR with label to the
+// decision sink.
+func recordDecision(log *logrus.Entry, cfg *plugins.ReleaseNote, org, repo string, number int, label string) {
+	d := Decision{Satisfied: label != releaseNoteLabelNeeded, Label: newLabelNames(cfg).name(label)}
+	if err := decisionSink.RecordDecision(org, repo, number, d); err != nil {
+		log.WithError(err).Errorf("Failed to record the release note decision for %s/%s#%d.", org, repo, number)
+	}
+}
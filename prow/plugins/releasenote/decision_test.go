@@ -0,0 +1,86 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+type capturingSink struct {
+	decisions map[string]Decision
+}
+
+func (s *capturingSink) RecordDecision(org, repo string, number int, d Decision) error {
+	s.decisions[fmt.Sprintf("%s/%s#%d", org, repo, number)] = d
+	return nil
+}
+
+func TestDecisionSink(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		branch   string
+		labels   plugins.ReleaseNoteLabels
+		expected map[string]Decision
+	}{
+		{
+			name:     "satisfied",
+			body:     "```release-note\nA note.\n```",
+			branch:   "master",
+			expected: map[string]Decision{"org/repo#1": {Satisfied: true, Label: releaseNote}},
+		},
+		{
+			name:     "unsatisfied",
+			body:     "```release-note\n```",
+			branch:   "master",
+			expected: map[string]Decision{"org/repo#1": {Satisfied: false, Label: releaseNoteLabelNeeded}},
+		},
+		{
+			name:     "renamed label",
+			body:     "```release-note\nA note.\n```",
+			branch:   "master",
+			labels:   plugins.ReleaseNoteLabels{ReleaseNote: "changelog"},
+			expected: map[string]Decision{"org/repo#1": {Satisfied: true, Label: "changelog"}},
+		},
+		{
+			name:     "not required",
+			body:     "Cherry pick of #2 on release-1.8.\n```release-note\n```",
+			branch:   "release-1.8",
+			expected: map[string]Decision{"org/repo#1": {Satisfied: true}},
+		},
+	}
+	sink := &capturingSink{}
+	SetDecisionSink(sink)
+	defer SetDecisionSink(nil)
+	for _, test := range tests {
+		sink.decisions = map[string]Decision{}
+		fc, pr := newFakeClient(test.body, test.branch, nil, nil, map[int]string{2: releaseNote})
+		fc.ExistingLabels = append(fc.ExistingLabels, test.labels.ReleaseNote)
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{Labels: test.labels}, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(sink.decisions, test.expected) {
+			t.Errorf("(%s): Expected decisions %v, but got %v.", test.name, test.expected, sink.decisions)
+		}
+	}
+}
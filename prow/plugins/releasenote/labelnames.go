@@ -0,0 +1,170 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"strings"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// labelNames maps the release note labels to the names configured in
+// cfg.Labels. The plugin works with the upstream names, which are translated
+// to and from the configured ones where labels reach GitHub. Commands and
+// comments are built with the configured names instead.
+type labelNames struct {
+	// custom maps upstream names to configured ones.
+	custom map[string]string
+	// upstream maps lowercased configured names to upstream ones.
+	upstream map[string]string
+}
+
+// newLabelNames returns the label names configured in cfg, or nil if none of
+// the labels is renamed.
+func newLabelNames(cfg *plugins.ReleaseNote) *labelNames {
+	overrides := map[string]string{
+		releaseNote:               cfg.Labels.ReleaseNote,
+		releaseNoteNone:           cfg.Labels.None,
+		releaseNoteActionRequired: cfg.Labels.ActionRequired,
+		releaseNoteLabelNeeded:    cfg.Labels.Needed,
+	}
+	n := &labelNames{custom: map[string]string{}, upstream: map[string]string{}}
+	for label, name := range overrides {
+		if name != "" && name != label {
+			n.custom[label] = name
+			n.upstream[strings.ToLower(name)] = label
+		}
+	}
+	if len(n.custom) == 0 {
+		return nil
+	}
+	return n
+}
+
+// name returns the configured name of the upstream label.
+func (n *labelNames) name(label string) string {
+	if n == nil {
+		return label
+	}
+	if name, ok := n.custom[label]; ok {
+		return name
+	}
+	return label
+}
+
+// label returns the upstream label for the configured name, which matches
+// case-insensitively.
+func (n *labelNames) label(name string) string {
+	if n == nil {
+		return name
+	}
+	if label, ok := n.upstream[strings.ToLower(name)]; ok {
+		return label
+	}
+	return name
+}
+
+// labels returns a copy of labels with their upstream names.
+func (n *labelNames) labels(labels []github.Label) []github.Label {
+	if n == nil || labels == nil {
+		return labels
+	}
+	translated := make([]github.Label, len(labels))
+	for i, l := range labels {
+		l.Name = n.label(l.Name)
+		translated[i] = l
+	}
+	return translated
+}
+
+// withLabelNames wraps gc to translate the label names configured in cfg, if
+// any.
+func withLabelNames(gc githubClient, cfg *plugins.ReleaseNote) githubClient {
+	if names := newLabelNames(cfg); names != nil {
+		return &labelNameClient{githubClient: gc, names: names}
+	}
+	return gc
+}
+
+// labelNameClient translates the release note labels between their upstream
+// and configured names.
+type labelNameClient struct {
+	githubClient
+	names *labelNames
+}
+
+func (c *labelNameClient) AddLabel(org, repo string, number int, label string) error {
+	return c.githubClient.AddLabel(org, repo, number, c.names.name(label))
+}
+
+func (c *labelNameClient) RemoveLabel(org, repo string, number int, label string) error {
+	return c.githubClient.RemoveLabel(org, repo, number, c.names.name(label))
+}
+
+func (c *labelNameClient) ReplaceLabels(org, repo string, number int, labels []string) error {
+	names := make([]string, len(labels))
+	for i, label := range labels {
+		names[i] = c.names.name(label)
+	}
+	return c.githubClient.ReplaceLabels(org, repo, number, names)
+}
+
+func (c *labelNameClient) GetIssueLabels(org, repo string, number int) ([]github.Label, error) {
+	labels, err := c.githubClient.GetIssueLabels(org, repo, number)
+	return c.names.labels(labels), err
+}
+
+func (c *labelNameClient) GetPullRequest(org, repo string, number int) (*github.PullRequest, error) {
+	pr, err := c.githubClient.GetPullRequest(org, repo, number)
+	if pr != nil {
+		translated := *pr
+		translated.Labels = c.names.labels(pr.Labels)
+		pr = &translated
+	}
+	return pr, err
+}
+
+func (c *labelNameClient) FindIssues(query, sort string, asc bool) ([]github.Issue, error) {
+	issues, err := c.githubClient.FindIssues(query, sort, asc)
+	translated := make([]github.Issue, len(issues))
+	for i, issue := range issues {
+		issue.Labels = c.names.labels(issue.Labels)
+		translated[i] = issue
+	}
+	return translated, err
+}
+
+func (c *labelNameClient) FindAllIssues(query, sort string, asc bool) ([]github.Issue, error) {
+	issues, err := c.githubClient.FindAllIssues(query, sort, asc)
+	translated := make([]github.Issue, len(issues))
+	for i, issue := range issues {
+		issue.Labels = c.names.labels(issue.Labels)
+		translated[i] = issue
+	}
+	return translated, err
+}
+
+func (c *labelNameClient) ListIssueEvents(org, repo string, number int) ([]github.ListedIssueEvent, error) {
+	events, err := c.githubClient.ListIssueEvents(org, repo, number)
+	translated := make([]github.ListedIssueEvent, len(events))
+	for i, e := range events {
+		e.Label.Name = c.names.label(e.Label.Name)
+		translated[i] = e
+	}
+	return translated, err
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// customLabels renames every release note label, like a fork would.
+var customLabels = plugins.ReleaseNoteLabels{
+	ReleaseNote:    "docs/release-note",
+	None:           "docs/release-note-none",
+	ActionRequired: "docs/release-note-action-required",
+	Needed:         "docs/needs-release-note",
+}
+
+func TestCustomLabelNames(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		initialLabels   []string
+		expectedAdded   []string
+		expectedRemoved []string
+		expectComment   bool
+	}{
+		{
+			name:            "note replaces the needed label",
+			body:            "```release-note\nThe foo command now supports the --bar flag.\n```",
+			initialLabels:   []string{"Docs/Needs-Release-Note"},
+			expectedAdded:   formatLabels(1, customLabels.ReleaseNote),
+			expectedRemoved: formatLabels(1, customLabels.Needed),
+		},
+		{
+			name:            "action required note",
+			body:            "```release-note\nAction required: the --bar flag was removed.\n```",
+			initialLabels:   []string{customLabels.ReleaseNote},
+			expectedAdded:   formatLabels(1, customLabels.ActionRequired),
+			expectedRemoved: formatLabels(1, customLabels.ReleaseNote),
+		},
+		{
+			name:            "none",
+			body:            "```release-note\nNONE\n```",
+			initialLabels:   []string{customLabels.ActionRequired},
+			expectedAdded:   formatLabels(1, customLabels.None),
+			expectedRemoved: formatLabels(1, customLabels.ActionRequired),
+		},
+		{
+			name:          "missing note",
+			body:          "Some text.",
+			expectedAdded: formatLabels(1, customLabels.Needed),
+			expectComment: true,
+		},
+		{
+			name:          "correct label already present",
+			body:          "```release-note\nThe foo command now supports the --bar flag.\n```",
+			initialLabels: []string{customLabels.ReleaseNote},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		fc.ExistingLabels = []string{customLabels.ReleaseNote, customLabels.None, customLabels.ActionRequired, customLabels.Needed}
+		cfg := &plugins.ReleaseNote{Labels: customLabels}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		added := fc.LabelsAdded[len(test.initialLabels):]
+		if len(added) != len(test.expectedAdded) || len(sliceDifference(added, test.expectedAdded)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, test.expectedAdded, added)
+		}
+		// The needed label may be removed more than once.
+		if len(sliceDifference(fc.LabelsRemoved, test.expectedRemoved)) > 0 || len(sliceDifference(test.expectedRemoved, fc.LabelsRemoved)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, test.expectedRemoved, fc.LabelsRemoved)
+		}
+		if len(fc.IssueCommentsAdded) > 0 != test.expectComment {
+			t.Errorf("(%s): Expected a comment: %t, but got comments %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+		for _, comment := range fc.IssueCommentsAdded {
+			if !strings.Contains(comment, `"docs/release-note"`) || !strings.Contains(comment, customLabels.Needed) {
+				t.Errorf("(%s): Expected the comment to mention the custom labels, but got %q.", test.name, comment)
+			}
+			if strings.Contains(comment, releaseNoteLabelNeeded) || strings.Contains(comment, `"`+releaseNote+`"`) {
+				t.Errorf("(%s): Expected the comment not to mention the upstream labels, but got %q.", test.name, comment)
+			}
+		}
+	}
+}
+
+func TestCustomLabelNamesCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		command       string
+		expectedAdded string
+	}{
+		{
+			name:          "custom none command",
+			command:       "/docs/release-note-none",
+			expectedAdded: customLabels.None,
+		},
+		{
+			name:    "unrelated command",
+			command: "/lgtm",
+		},
+	}
+	for _, test := range tests {
+		fc := &fakegithub.FakeClient{IssueComments: map[int][]github.IssueComment{}}
+		ic := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: test.command, User: github.User{Login: "a"}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      5,
+				State:       "open",
+				PullRequest: &struct{}{},
+				Labels:      []github.Label{{Name: customLabels.Needed}},
+			},
+		}
+		cfg := &plugins.ReleaseNote{Labels: customLabels}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ic); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		var expectedAdded, expectedRemoved []string
+		if test.expectedAdded != "" {
+			expectedAdded = []string{"/#5:" + test.expectedAdded}
+			expectedRemoved = []string{"/#5:" + customLabels.Needed}
+		}
+		if len(fc.LabelsAdded) != len(expectedAdded) || len(sliceDifference(fc.LabelsAdded, expectedAdded)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expectedAdded, fc.LabelsAdded)
+		}
+		if len(sliceDifference(fc.LabelsRemoved, expectedRemoved)) > 0 || len(sliceDifference(expectedRemoved, fc.LabelsRemoved)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, expectedRemoved, fc.LabelsRemoved)
+		}
+	}
+}
+
+func TestCustomLabelNamesGuidanceReview(t *testing.T) {
+	fc, pr := newFakeClient("Some text.", "master", nil, nil, nil)
+	fc.ExistingLabels = []string{customLabels.ReleaseNote, customLabels.None, customLabels.ActionRequired, customLabels.Needed}
+	cfg := &plugins.ReleaseNote{Labels: customLabels, UseReviewForGuidance: true}
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if len(fc.Reviews[1]) != 1 || strings.Contains(fc.Reviews[1][0].Body, releaseNoteLabelNeeded) {
+		t.Fatalf("Expected a guidance review mentioning the custom labels, but got %+v.", fc.Reviews[1])
+	}
+
+	pr.PullRequest.Body = "```release-note\nThe foo command now supports the --bar flag.\n```"
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if len(fc.ReviewsDismissed) != 1 {
+		t.Errorf("Expected the guidance review to be dismissed, but got %q dismissed.", fc.ReviewsDismissed)
+	}
+}
+
+func TestCustomLabelNamesStaleComments(t *testing.T) {
+	cfg := &plugins.ReleaseNote{Labels: customLabels, RequireOnlyWhenLabeled: "needs-release-note"}
+	fc, pr := newFakeClient("Some text.", "master", []string{customLabels.ReleaseNote}, nil, nil)
+	fc.IssueComments[1] = []github.IssueComment{{ID: 1, Body: releaseNoteBody(cfg), User: github.User{Login: "k8s-ci-robot"}}}
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if len(fc.IssueCommentsDeleted) != 1 {
+		t.Errorf("Expected the stale guidance to be deleted, but got %q deleted.", fc.IssueCommentsDeleted)
+	}
+}
+
+func TestCustomLabelNamesStatus(t *testing.T) {
+	cfg := &plugins.Configuration{ReleaseNotes: []plugins.ReleaseNote{{Repos: []string{"org/repo"}, Labels: customLabels}}}
+	fc := &fakegithub.FakeClient{
+		Issues: []github.Issue{
+			{Number: 10, Body: "Cherry pick of #1 on release-1.8.", PullRequest: &struct{}{}, Labels: []github.Label{{Name: customLabels.ReleaseNote}}},
+			{Number: 11, Body: "Cherry pick of #2 on release-1.8.", PullRequest: &struct{}{}, Labels: []github.Label{{Name: customLabels.ActionRequired}}},
+			{Number: 12, Body: "Cherry pick of #3 on release-1.8.", PullRequest: &struct{}{}, Labels: []github.Label{{Name: customLabels.Needed}}},
+		},
+		LabelsAdded: append(append(
+			formatLabels(1, customLabels.ReleaseNote),
+			formatLabels(2, customLabels.ActionRequired)...),
+			formatLabels(3, customLabels.Needed)...),
+	}
+
+	issues, err := ValidateCherryPicks(fc, cfg, "org", "repo", "release-1.8")
+	if err != nil {
+		t.Fatalf("Unexpected error from ValidateCherryPicks: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Number != 12 {
+		t.Errorf("Expected only #12 to have a noteless parent, but got %+v.", issues)
+	}
+
+	stats, err := MilestoneNoteStats(fc, cfg, "org", "repo", "v1.8")
+	if err != nil {
+		t.Fatalf("Unexpected error from MilestoneNoteStats: %v", err)
+	}
+	expected := map[string]int{
+		customLabels.ReleaseNote:    1,
+		customLabels.ActionRequired: 1,
+		customLabels.None:           0,
+		NeededStat:                  1,
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Expected stats %v, but got %v.", expected, stats)
+	}
+}
+
+func TestCustomLabelNamesCommandRegexes(t *testing.T) {
+	res := regexesFor(&plugins.ReleaseNote{Labels: plugins.ReleaseNoteLabels{ReleaseNote: "changelog", None: "changelog-none"}})
+	tests := []struct {
+		name           string
+		comment        string
+		expectNote     bool
+		expectNone     bool
+		expectRecorded string
+	}{
+		{
+			name:       "configured none command",
+			comment:    "/changelog-none",
+			expectNone: true,
+		},
+		{
+			name:       "command after text",
+			comment:    "Thanks!\n/CHANGELOG-NONE\n",
+			expectNone: true,
+		},
+		{
+			name:       "configured note command",
+			comment:    "/changelog",
+			expectNote: true,
+		},
+		{
+			name:    "upstream command",
+			comment: "/release-note-none",
+		},
+		{
+			name:    "prose",
+			comment: "See the changelog-none docs.",
+		},
+		{
+			name:           "unrenamed action required command",
+			comment:        "/release-note-action-required Remove the --bar flag.",
+			expectRecorded: "Remove the --bar flag.",
+		},
+	}
+	for _, test := range tests {
+		if actual := res.noteCommand.MatchString(test.comment); actual != test.expectNote {
+			t.Errorf("(%s): Expected the note command to match: %t, but got %t.", test.name, test.expectNote, actual)
+		}
+		if actual := res.noneCommand.MatchString(test.comment); actual != test.expectNone {
+			t.Errorf("(%s): Expected the none command to match: %t, but got %t.", test.name, test.expectNone, actual)
+		}
+		var noted string
+		if m := res.recordedActionRequired.FindStringSubmatch(test.comment); m != nil {
+			noted = m[1]
+		}
+		if noted != test.expectRecorded {
+			t.Errorf("(%s): Expected the recorded note %q, but got %q.", test.name, test.expectRecorded, noted)
+		}
+	}
+}
+
+// eventsClient lists events without copying them, like a caching client
+// might.
+type eventsClient struct {
+	*fakegithub.FakeClient
+	events []github.ListedIssueEvent
+}
+
+func (c *eventsClient) ListIssueEvents(org, repo string, number int) ([]github.ListedIssueEvent, error) {
+	return c.events, nil
+}
+
+func TestLabelNameClientListIssueEvents(t *testing.T) {
+	events := []github.ListedIssueEvent{{Event: github.IssueActionLabeled, Label: github.Label{Name: "changelog-none"}}}
+	fc := &eventsClient{FakeClient: &fakegithub.FakeClient{}, events: events}
+	gc := withLabelNames(fc, &plugins.ReleaseNote{Labels: plugins.ReleaseNoteLabels{None: "changelog-none"}})
+	translated, err := gc.ListIssueEvents("org", "repo", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error from ListIssueEvents: %v", err)
+	}
+	if len(translated) != 1 || translated[0].Label.Name != releaseNoteNone {
+		t.Errorf("Expected the event to be for %q, but got %+v.", releaseNoteNone, translated)
+	}
+	if events[0].Label.Name != "changelog-none" {
+		t.Errorf("Expected the listed events to be left alone, but got %+v.", events)
+	}
+}
+
+func TestCustomLabelNamesInProse(t *testing.T) {
+	cfg := &plugins.ReleaseNote{Labels: plugins.ReleaseNoteLabels{ReleaseNote: "changelog"}, WarnRelativeLinks: true}
+	fc, pr := newFakeClient("```release-note\nSee the [docs](docs/foo.md).\n```", "master", nil, nil, nil)
+	fc.ExistingLabels = append(fc.ExistingLabels, "changelog")
+	for i := 0; i < 3; i++ {
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("Unexpected error from handlePR: %v", err)
+		}
+	}
+	if len(fc.IssueCommentsAdded) != 1 {
+		t.Errorf("Expected the relative link warning to be posted once, but got %q.", fc.IssueCommentsAdded)
+	}
+}
+
+func TestHelpProviderCustomLabelNames(t *testing.T) {
+	config := &plugins.Configuration{
+		ReleaseNotes: []plugins.ReleaseNote{
+			{Repos: []string{"org/repo"}, Labels: plugins.ReleaseNoteLabels{None: "changelog-none"}},
+		},
+	}
+	help, err := helpProvider(config, []string{"org/repo"})
+	if err != nil {
+		t.Fatalf("Unexpected error from helpProvider: %v", err)
+	}
+	c := help.Config["org/repo"]
+	if !strings.Contains(c, "labels changelog-none") && !strings.Contains(c, ", changelog-none") {
+		t.Errorf("Expected the org/repo config to name the renamed label, but got %q.", c)
+	}
+	if !strings.Contains(c, "/changelog-none") {
+		t.Errorf("Expected the org/repo config to name the renamed command, but got %q.", c)
+	}
+}
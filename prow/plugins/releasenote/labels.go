@@ -0,0 +1,127 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"strings"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// LabelSpec describes a label managed by the plugin, eg. for contributor docs
+// and label sync manifests.
+type LabelSpec struct {
+	Name string
+	// Purpose is when the plugin applies the label.
+	Purpose string
+	// Color and Description are how the label should look on GitHub.
+	Color       string
+	Description string
+}
+
+// defaultLabelSpecs returns the labels the plugin always manages, with their
+// names from cfg.Labels.
+func defaultLabelSpecs(cfg *plugins.ReleaseNote) []LabelSpec {
+	names := newLabelNames(cfg)
+	needed := names.name(releaseNoteLabelNeeded)
+	return []LabelSpec{
+		{
+			Name:        names.name(releaseNote),
+			Purpose:     "The PR has a release note.",
+			Color:       "c2e0c6",
+			Description: "Denotes a PR that will be considered when it comes time to generate release notes.",
+		},
+		{
+			Name:        names.name(releaseNoteActionRequired),
+			Purpose:     "The PR has a release note that requires users to take action.",
+			Color:       "c2e0c6",
+			Description: "Denotes a PR that introduces potentially breaking changes that require user action.",
+		},
+		{
+			Name:        names.name(releaseNoteNone),
+			Purpose:     "The PR doesn't need a release note.",
+			Color:       "c2e0c6",
+			Description: "Denotes a PR that doesn't merit a release note.",
+		},
+		{
+			Name:        needed,
+			Purpose:     "The PR needs a release note, and can't merge until it has one or is marked as not needing one.",
+			Color:       "e11d21",
+			Description: "Indicates that a PR should not merge because it's missing one of the release note labels.",
+		},
+		{
+			Name:        deprecatedReleaseNoteLabelNeeded,
+			Purpose:     "Deprecated version of " + needed + ", which the plugin still honors and removes.",
+			Color:       "e11d21",
+			Description: "Deprecated, see " + needed + ".",
+		},
+	}
+}
+
+// ManagedLabels returns the labels the plugin manages with cfg, with their
+// names from cfg.Labels and their appearances from cfg.LabelAppearances or
+// the defaults.
+func ManagedLabels(cfg *plugins.ReleaseNote) []LabelSpec {
+	specs := defaultLabelSpecs(cfg)
+	if cfg.LabelInheritedNotes {
+		specs = append(specs, LabelSpec{
+			Name:        releaseNoteInherited,
+			Purpose:     "The PR is a cherry-pick whose parents' release notes satisfy the process.",
+			Color:       "c2e0c6",
+			Description: "Denotes a cherry-pick that inherits the release notes of the PRs it cherry-picks.",
+		})
+	}
+	if cfg.EmptyActionRequiredBehavior == emptyActionLabel {
+		specs = append(specs, LabelSpec{
+			Name:        needsRereviewLabel,
+			Purpose:     "The PR has an action required release note that doesn't describe the action.",
+			Color:       "fbca04",
+			Description: "Indicates that the release note of a PR should be reviewed again.",
+		})
+	}
+	if cfg.FlagNoteChangesAfterApproval {
+		specs = append(specs, LabelSpec{
+			Name:        noteChangedLabel,
+			Purpose:     "The PR's release note was edited after it was approved.",
+			Color:       "fbca04",
+			Description: "Indicates that the release note of a PR changed after it was approved.",
+		})
+	}
+	if cfg.ResolveInsteadOfRemove {
+		specs = append(specs, LabelSpec{
+			Name:        releaseNoteWasNeeded,
+			Purpose:     "The PR was once blocked on its release note.",
+			Color:       "ededed",
+			Description: "Records that a PR was once missing a release note.",
+		})
+	}
+	if len(cfg.SecurityNotePatterns) > 0 {
+		specs = append(specs, LabelSpec{
+			Name:        releaseNoteSecurity,
+			Purpose:     "The PR's release note mentions security.",
+			Color:       "b60205",
+			Description: "Denotes a PR whose release note should be reviewed by the security team.",
+		})
+	}
+	for i := range specs {
+		if appearance, ok := cfg.LabelAppearances[specs[i].Name]; ok {
+			specs[i].Color = strings.ToLower(strings.TrimPrefix(appearance.Color, "#"))
+			specs[i].Description = appearance.Description
+		}
+	}
+	return specs
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestManagedLabels(t *testing.T) {
+	names := func(specs []LabelSpec) []string {
+		var out []string
+		for _, spec := range specs {
+			out = append(out, spec.Name)
+		}
+		return out
+	}
+
+	defaults := ManagedLabels(&plugins.ReleaseNote{})
+	if expected := []string{releaseNote, releaseNoteActionRequired, releaseNoteNone, releaseNoteLabelNeeded, deprecatedReleaseNoteLabelNeeded}; !reflect.DeepEqual(names(defaults), expected) {
+		t.Errorf("Expected the default labels %q, but got %q.", expected, names(defaults))
+	}
+	for _, spec := range defaults {
+		if spec.Purpose == "" || spec.Color == "" || spec.Description == "" {
+			t.Errorf("Expected %q to have a purpose, color and description, but got %+v.", spec.Name, spec)
+		}
+	}
+
+	cfg := &plugins.ReleaseNote{
+		LabelInheritedNotes:          true,
+		FlagNoteChangesAfterApproval: true,
+		SecurityNotePatterns:         []string{`CVE-\d`},
+		LabelAppearances: map[string]plugins.LabelAppearance{
+			releaseNote:         {Color: "#00FF00", Description: "Has a release note."},
+			releaseNoteSecurity: {Color: "ff0000", Description: "Mentions security."},
+		},
+	}
+	configured := ManagedLabels(cfg)
+	if expected := append(names(defaults), releaseNoteInherited, noteChangedLabel, releaseNoteSecurity); !reflect.DeepEqual(names(configured), expected) {
+		t.Errorf("Expected the configured labels %q, but got %q.", expected, names(configured))
+	}
+	for _, spec := range configured {
+		switch spec.Name {
+		case releaseNote:
+			if spec.Color != "00ff00" || spec.Description != "Has a release note." {
+				t.Errorf("Expected the configured appearance of %q, but got %+v.", spec.Name, spec)
+			}
+		case releaseNoteSecurity:
+			if spec.Color != "ff0000" || spec.Description != "Mentions security." {
+				t.Errorf("Expected the configured appearance of %q, but got %+v.", spec.Name, spec)
+			}
+		case releaseNoteNone:
+			if !reflect.DeepEqual(spec, defaults[2]) {
+				t.Errorf("Expected the default appearance of %q, but got %+v.", spec.Name, spec)
+			}
+		}
+	}
+	if !reflect.DeepEqual(ManagedLabels(&plugins.ReleaseNote{}), defaults) {
+		t.Error("Expected the default labels not to be modified by configured appearances.")
+	}
+}
+
+func TestCustomManagedLabels(t *testing.T) {
+	cfg := &plugins.ReleaseNote{
+		Labels:           plugins.ReleaseNoteLabels{None: customLabels.None},
+		LabelAppearances: map[string]plugins.LabelAppearance{customLabels.None: {Color: "#FFFFFF", Description: "No note."}},
+	}
+	names := map[string]LabelSpec{}
+	for _, spec := range ManagedLabels(cfg) {
+		names[spec.Name] = spec
+	}
+	for _, label := range []string{releaseNote, releaseNoteActionRequired, releaseNoteLabelNeeded, customLabels.None} {
+		if _, ok := names[label]; !ok {
+			t.Errorf("Expected %q to be managed, but got %v.", label, names)
+		}
+	}
+	if _, ok := names[releaseNoteNone]; ok {
+		t.Errorf("Expected %q to be renamed, but it is still managed.", releaseNoteNone)
+	}
+	if spec := names[customLabels.None]; spec.Color != "ffffff" || spec.Description != "No note." {
+		t.Errorf("Expected the configured appearance for %q, but got %+v.", customLabels.None, spec)
+	}
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// deprecatedCommandCounter counts uses of the deprecated /release-note
+	// and /release-note-action-required commands, to tell when they can be
+	// removed.
+	deprecatedCommandCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "prow_release_note_deprecated_commands",
+		Help: "A counter of the deprecated release note commands used.",
+	}, []string{"command", "repo"})
+)
+
+func init() {
+	prometheus.MustRegister(deprecatedCommandCounter)
+}
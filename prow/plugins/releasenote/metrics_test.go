@@ -0,0 +1,68 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func deprecatedCommandCount(t *testing.T, command, repo string) float64 {
+	var m dto.Metric
+	if err := deprecatedCommandCounter.WithLabelValues(command, repo).Write(&m); err != nil {
+		t.Fatalf("Failed to read the counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestDeprecatedCommandCounter(t *testing.T) {
+	tests := []struct {
+		comment string
+		command string
+		counted bool
+	}{
+		{comment: "/release-note", command: "/release-note", counted: true},
+		{comment: "/release-note-action-required", command: "/release-note-action-required", counted: true},
+		{comment: "/release-note-none", command: "/release-note-none"},
+	}
+	for _, test := range tests {
+		before := deprecatedCommandCount(t, test.command, "org/repo")
+		fc, _ := newFakeClient("", "master", nil, nil, nil)
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: test.comment, User: github.User{Login: "a"}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      1,
+				PullRequest: &struct{}{},
+			},
+			Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+		}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.comment, err)
+		}
+		after := deprecatedCommandCount(t, test.command, "org/repo")
+		if counted := after == before+1; counted != test.counted {
+			t.Errorf("(%s): Expected the command to be counted: %t, but the counter went from %v to %v.", test.comment, test.counted, before, after)
+		}
+	}
+}
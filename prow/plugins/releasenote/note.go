@@ -0,0 +1,149 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// changelogLineFormat is the canonical changelog line for a PR, eg. "Foo now
+// supports bar. (#123, @alice)".
+const changelogLineFormat = "%s (#%d, @%s)"
+
+var (
+	inlineCodeRe    = regexp.MustCompile("```(.+?)```|``(.+?)``|`([^`]+)`")
+	markdownImageRe = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownTextRe  = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\[[^\]]*\])`)
+	strongRe        = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	emphasisStarRe  = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	// Underscores inside words, eg. snake_case, aren't emphasis.
+	emphasisUnderRe = regexp.MustCompile(`(^|[^\w])_([^_\s](?:[^_]*[^_\s])?)_([^\w]|$)`)
+	strikethroughRe = regexp.MustCompile(`~~(.+?)~~`)
+	listMarkerRe    = regexp.MustCompile(`^[ \t]*(?:[*+-]|[[:digit:]]+[.)])[ \t]+`)
+)
+
+// ExtractReleaseNote returns the release note in a PR body written with the
+// default release note fences, or "" if there is none.
+func ExtractReleaseNote(body string) string {
+	return getReleaseNote(&plugins.ReleaseNote{}, body)
+}
+
+// PlainTextNote returns the release note in a PR body with common markdown
+// stripped, eg. for plain text changelogs. Links and images are replaced by
+// their text, and emphasis and inline code markers are removed.
+func PlainTextNote(body string) string {
+	note := ExtractReleaseNote(body)
+	// Leave the contents of inline code alone.
+	var out []string
+	last := 0
+	for _, m := range inlineCodeRe.FindAllStringSubmatchIndex(note, -1) {
+		out = append(out, stripMarkdown(note[last:m[0]]))
+		for i := 2; i < len(m); i += 2 {
+			if m[i] >= 0 {
+				out = append(out, note[m[i]:m[i+1]])
+				break
+			}
+		}
+		last = m[1]
+	}
+	out = append(out, stripMarkdown(note[last:]))
+	return strings.Join(out, "")
+}
+
+func stripMarkdown(s string) string {
+	s = markdownImageRe.ReplaceAllString(s, "$1")
+	s = markdownTextRe.ReplaceAllString(s, "$1")
+	s = strongRe.ReplaceAllString(s, "$1$2")
+	s = emphasisStarRe.ReplaceAllString(s, "$1")
+	s = emphasisUnderRe.ReplaceAllString(s, "$1$2$3")
+	return strikethroughRe.ReplaceAllString(s, "$1")
+}
+
+// markdownProblems returns descriptions of the unbalanced markdown in note,
+// eg. "unclosed `**`". Fenced code blocks and code spans are skipped.
+func markdownProblems(note string) []string {
+	text := codeFenceRe.ReplaceAllString(note, "")
+	if strings.Count(text, "`")%2 != 0 {
+		// The rest can't be told apart from code.
+		return []string{"unclosed code span"}
+	}
+	text = inlineCodeRe.ReplaceAllString(text, "")
+	var problems []string
+	for _, marker := range []string{"**", "~~"} {
+		if strings.Count(text, marker)%2 != 0 {
+			problems = append(problems, fmt.Sprintf("unclosed `%s`", marker))
+		}
+		text = strings.Replace(text, marker, "", -1)
+	}
+	// List markers, eg. "* " and "1) ", and asterisks surrounded by spaces
+	// aren't markup.
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, listMarkerRe.ReplaceAllString(line, ""))
+	}
+	text = strings.Join(lines, "\n")
+	if (strings.Count(text, "*")-strings.Count(text, " * "))%2 != 0 {
+		problems = append(problems, "unclosed `*`")
+	}
+	for _, pair := range [][2]string{{"[", "]"}, {"(", ")"}} {
+		if strings.Count(text, pair[0]) != strings.Count(text, pair[1]) {
+			problems = append(problems, fmt.Sprintf("unbalanced `%s%s`", pair[0], pair[1]))
+		}
+	}
+	return problems
+}
+
+// ChangelogLine returns the canonical changelog line for the release note of
+// PR pr by author, eg. "Foo now supports bar. (#123, @alice)". Notes spanning
+// several lines are flattened to one.
+func ChangelogLine(note string, pr int, author string) string {
+	return fmt.Sprintf(changelogLineFormat, flattenNote(note), pr, author)
+}
+
+// changelogData is passed to the changelog line template.
+type changelogData struct {
+	Note   string
+	PR     int
+	Author string
+}
+
+// ChangelogLineFor returns the changelog line for the release note of PR pr
+// by author as the plugin renders it for cfg: with cfg.ChangelogLineTemplate,
+// or the canonical line if it isn't set or can't be rendered, which is
+// logged to log. Action required notes are prefixed with
+// cfg.ActionRequiredChangelogPrefix.
+func ChangelogLineFor(log *logrus.Entry, cfg *plugins.ReleaseNote, note string, pr int, author string) string {
+	flat := flattenNote(note)
+	if cfg.ActionRequiredChangelogPrefix != "" && noteLabel(cfg, note) == releaseNoteActionRequired {
+		flat = cfg.ActionRequiredChangelogPrefix + " " + flat
+	}
+	data := changelogData{Note: flat, PR: pr, Author: author}
+	if line, ok := renderTemplate(log, "changelog_line_template", cfg.ChangelogLineTemplate, data); ok {
+		return line
+	}
+	return fmt.Sprintf(changelogLineFormat, flat, pr, author)
+}
+
+func flattenNote(note string) string {
+	return strings.Join(strings.Fields(note), " ")
+}
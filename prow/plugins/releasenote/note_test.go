@@ -0,0 +1,179 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestPlainTextNote(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "no release note",
+			body:     "Some text.",
+			expected: "",
+		},
+		{
+			name:     "plain note",
+			body:     "```release-note\nThe foo flag was added.\n```",
+			expected: "The foo flag was added.",
+		},
+		{
+			name:     "links",
+			body:     "```release-note\nSee [the docs](https://example.com/docs) and [KEP-1][kep].\n```",
+			expected: "See the docs and KEP-1.",
+		},
+		{
+			name:     "images",
+			body:     "```release-note\nNew logo: ![the logo](https://example.com/logo.png)\n```",
+			expected: "New logo: the logo",
+		},
+		{
+			name:     "emphasis",
+			body:     "```release-note\n**Action required:** the *old* flag is __gone__, _really_ ~~deprecated~~ removed.\n```",
+			expected: "Action required: the old flag is gone, really deprecated removed.",
+		},
+		{
+			name:     "underscores inside words",
+			body:     "```release-note\nThe max_pods_per_node setting is honored.\n```",
+			expected: "The max_pods_per_node setting is honored.",
+		},
+		{
+			name:     "inline code",
+			body:     "```release-note\nUse `--snake_case` or ``*ptr`` instead of `**bold**`.\n```",
+			expected: "Use --snake_case or *ptr instead of **bold**.",
+		},
+	}
+	for _, test := range tests {
+		if actual := PlainTextNote(test.body); actual != test.expected {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestChangelogLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		note     string
+		cfg      *plugins.ReleaseNote
+		expected string
+	}{
+		{
+			name:     "normal note",
+			note:     "Foo now supports bar.",
+			cfg:      &plugins.ReleaseNote{},
+			expected: "Foo now supports bar. (#123, @alice)",
+		},
+		{
+			name:     "action required note without a prefix",
+			note:     "Action required: the baz flag was removed.",
+			cfg:      &plugins.ReleaseNote{},
+			expected: "Action required: the baz flag was removed. (#123, @alice)",
+		},
+		{
+			name:     "action required note with a prefix",
+			note:     "Action required: the baz flag was removed.",
+			cfg:      &plugins.ReleaseNote{ActionRequiredChangelogPrefix: "⚠️"},
+			expected: "⚠️ Action required: the baz flag was removed. (#123, @alice)",
+		},
+		{
+			name:     "prefix only applies to action required notes",
+			note:     "Foo now supports bar.",
+			cfg:      &plugins.ReleaseNote{ActionRequiredChangelogPrefix: "⚠️"},
+			expected: "Foo now supports bar. (#123, @alice)",
+		},
+		{
+			name:     "multi-line note is flattened",
+			note:     "Foo now supports bar.\n  It can be disabled\r\nwith --no-bar.\n",
+			cfg:      &plugins.ReleaseNote{},
+			expected: "Foo now supports bar. It can be disabled with --no-bar. (#123, @alice)",
+		},
+		{
+			name:     "custom template",
+			note:     "Foo now supports bar.",
+			cfg:      &plugins.ReleaseNote{ChangelogLineTemplate: "* {{.Note}} ([#{{.PR}}](https://github.com/org/repo/pull/{{.PR}}))"},
+			expected: "* Foo now supports bar. ([#123](https://github.com/org/repo/pull/123))",
+		},
+		{
+			name:     "invalid template falls back to the canonical line",
+			note:     "Foo now supports bar.",
+			cfg:      &plugins.ReleaseNote{ChangelogLineTemplate: "{{.Note"},
+			expected: "Foo now supports bar. (#123, @alice)",
+		},
+	}
+	for _, test := range tests {
+		if actual := ChangelogLineFor(logrus.WithField("plugin", pluginName), test.cfg, test.note, 123, "alice"); actual != test.expected {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expected, actual)
+		}
+	}
+	if actual, expected := ChangelogLine("Foo now supports bar.\n  It can be disabled.", 123, "alice"), "Foo now supports bar. It can be disabled. (#123, @alice)"; actual != expected {
+		t.Errorf("Expected %q, but got %q.", expected, actual)
+	}
+}
+
+func TestMarkdownProblems(t *testing.T) {
+	tests := []struct {
+		note     string
+		expected []string
+	}{
+		{
+			note: "The **foo** command now supports the `--bar` flag, see [the docs](https://example.com).",
+		},
+		{
+			note: "* Added the *foo* flag.\n* Removed the 2 * 3 example.",
+		},
+		{
+			note: "1) Added the foo flag.\n2) Removed the bar flag.",
+		},
+		{
+			note: "The `**` and `[` characters are now escaped.",
+		},
+		{
+			note: "The foo command now takes a list:\n```shell\nfoo --bar=a,b *\n```",
+		},
+		{
+			note:     "The **foo command now supports the --bar flag.",
+			expected: []string{"unclosed `**`"},
+		},
+		{
+			note:     "The *foo command now supports the --bar flag.",
+			expected: []string{"unclosed `*`"},
+		},
+		{
+			note:     "The foo command now supports the `--bar flag.",
+			expected: []string{"unclosed code span"},
+		},
+		{
+			note:     "The foo command now supports the --bar flag (see [the docs](https://example.com).",
+			expected: []string{"unbalanced `()`"},
+		},
+	}
+	for _, test := range tests {
+		if problems := markdownProblems(test.note); !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("Expected problems %q for %q, but got %q.", test.expected, test.note, problems)
+		}
+	}
+}
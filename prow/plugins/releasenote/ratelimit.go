@@ -0,0 +1,96 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// noneCommands rate limits the /release-note-none command if
+// cfg.CommandRateLimit is set.
+var noneCommands = newCommandLimiter()
+
+// commandLimiter limits how many commands each user can use within a window.
+type commandLimiter struct {
+	sync.Mutex
+	// uses are the times at which the recent commands stop counting towards
+	// the limit, by org/repo and user.
+	uses map[string][]time.Time
+	// notified is whether the user was told about the cool-down since their
+	// last allowed command, by org/repo and user.
+	notified map[string]bool
+	// now is time.Now, except in tests.
+	now func() time.Time
+}
+
+func newCommandLimiter() *commandLimiter {
+	return &commandLimiter{uses: map[string][]time.Time{}, notified: map[string]bool{}, now: time.Now}
+}
+
+func commandKey(org, repo, user string) string {
+	return fmt.Sprintf("%s/%s:%s", org, repo, user)
+}
+
+// allow returns whether user may use another command in org/repo, given at
+// most limit commands within a window. Otherwise it returns how long until
+// the user may use a command again, and whether the user should be notified
+// about it, which is only the case for the first throttled command. Commands
+// only count once they are recorded with record.
+func (l *commandLimiter) allow(org, repo, user string, limit int) (allowed bool, wait time.Duration, notify bool) {
+	key := commandKey(org, repo, user)
+	l.Lock()
+	defer l.Unlock()
+	now := l.now()
+	l.prune(now)
+	recent := l.uses[key]
+	if len(recent) < limit {
+		return true, 0, false
+	}
+	notify = !l.notified[key]
+	l.notified[key] = true
+	return false, recent[len(recent)-limit].Sub(now), notify
+}
+
+// record counts a command that user applied in org/repo for the next window.
+func (l *commandLimiter) record(org, repo, user string, window time.Duration) {
+	key := commandKey(org, repo, user)
+	l.Lock()
+	defer l.Unlock()
+	l.uses[key] = append(l.uses[key], l.now().Add(window))
+	delete(l.notified, key)
+}
+
+// prune forgets the commands that no longer count at now, and the users
+// without any such commands.
+func (l *commandLimiter) prune(now time.Time) {
+	for key, expiries := range l.uses {
+		var recent []time.Time
+		for _, t := range expiries {
+			if t.After(now) {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) == 0 {
+			delete(l.uses, key)
+			delete(l.notified, key)
+		} else {
+			l.uses[key] = recent
+		}
+	}
+}
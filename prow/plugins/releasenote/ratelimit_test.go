@@ -0,0 +1,194 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestCommandRateLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit plugins.CommandRateLimit
+		// commands are the minutes after the first command at which the
+		// commands are used.
+		commands        []int
+		expectedAdded   int
+		expectedNotices int
+	}{
+		{
+			name:          "no limit",
+			commands:      []int{0, 0, 0, 0},
+			expectedAdded: 4,
+		},
+		{
+			name:          "within the limit",
+			limit:         plugins.CommandRateLimit{Commands: 2, Window: "1h"},
+			commands:      []int{0, 10},
+			expectedAdded: 2,
+		},
+		{
+			name:            "exceeding the limit",
+			limit:           plugins.CommandRateLimit{Commands: 2, Window: "1h"},
+			commands:        []int{0, 1, 2, 3},
+			expectedAdded:   2,
+			expectedNotices: 1,
+		},
+		{
+			name:          "limit applies within the window",
+			limit:         plugins.CommandRateLimit{Commands: 1, Window: "1h"},
+			commands:      []int{0, 60, 120},
+			expectedAdded: 3,
+		},
+		{
+			name:            "notified again after a later cool-down",
+			limit:           plugins.CommandRateLimit{Commands: 1, Window: "1h"},
+			commands:        []int{0, 1, 2, 61, 62},
+			expectedAdded:   2,
+			expectedNotices: 2,
+		},
+		{
+			name:          "invalid window",
+			limit:         plugins.CommandRateLimit{Commands: 1, Window: "soon"},
+			commands:      []int{0, 0},
+			expectedAdded: 2,
+		},
+	}
+	for _, test := range tests {
+		start := time.Now()
+		var now time.Time
+		noneCommands = newCommandLimiter()
+		noneCommands.now = func() time.Time { return now }
+
+		fc := &fakegithub.FakeClient{IssueComments: map[int][]github.IssueComment{}}
+		cfg := &plugins.ReleaseNote{CommandRateLimit: test.limit}
+		for _, minute := range test.commands {
+			now = start.Add(time.Duration(minute) * time.Minute)
+			ic := github.IssueCommentEvent{
+				Action:  github.IssueCommentActionCreated,
+				Comment: github.IssueComment{Body: "/release-note-none", User: github.User{Login: "a"}},
+				Issue: github.Issue{
+					User:        github.User{Login: "a"},
+					Number:      5,
+					State:       "open",
+					PullRequest: &struct{}{},
+				},
+			}
+			if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ic); err != nil {
+				t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+			}
+		}
+		if len(fc.LabelsAdded) != test.expectedAdded {
+			t.Errorf("(%s): Expected %d commands to be honored, but got labels %q.", test.name, test.expectedAdded, fc.LabelsAdded)
+		}
+		if len(fc.IssueCommentsAdded) != test.expectedNotices {
+			t.Errorf("(%s): Expected %d cool-down notices, but got %q.", test.name, test.expectedNotices, fc.IssueCommentsAdded)
+		}
+		for _, comment := range fc.IssueCommentsAdded {
+			if !strings.Contains(comment, "please wait") {
+				t.Errorf("(%s): Expected a cool-down notice, but got %q.", test.name, comment)
+			}
+		}
+	}
+	noneCommands = newCommandLimiter()
+}
+
+func TestCommandLimiterSeparateUsers(t *testing.T) {
+	now := time.Now()
+	l := newCommandLimiter()
+	l.now = func() time.Time { return now }
+	if allowed, _, _ := l.allow("org", "repo", "alice", 1); !allowed {
+		t.Error("Expected the first command to be allowed.")
+	}
+	l.record("org", "repo", "alice", time.Hour)
+	if allowed, _, _ := l.allow("org", "repo", "bob", 1); !allowed {
+		t.Error("Expected the commands of different users not to limit each other.")
+	}
+	if allowed, _, _ := l.allow("org", "other", "alice", 1); !allowed {
+		t.Error("Expected the commands in different repos not to limit each other.")
+	}
+	now = now.Add(20 * time.Minute)
+	allowed, wait, notify := l.allow("org", "repo", "alice", 1)
+	if allowed || !notify {
+		t.Errorf("Expected the second command to be throttled with a notice, but got allowed: %t, notify: %t.", allowed, notify)
+	}
+	if wait != 40*time.Minute {
+		t.Errorf("Expected a cool-down of %v, but got %v.", 40*time.Minute, wait)
+	}
+}
+
+func TestCommandLimiterPrunes(t *testing.T) {
+	now := time.Now()
+	l := newCommandLimiter()
+	l.now = func() time.Time { return now }
+	l.record("org", "repo", "alice", time.Hour)
+	l.record("org", "repo", "bob", 2*time.Hour)
+	l.allow("org", "repo", "alice", 1)
+	now = now.Add(90 * time.Minute)
+	if allowed, _, _ := l.allow("org", "repo", "carol", 1); !allowed {
+		t.Error("Expected the first command to be allowed.")
+	}
+	if _, ok := l.uses["org/repo:alice"]; ok {
+		t.Errorf("Expected the expired commands to be forgotten, but got %v.", l.uses)
+	}
+	if _, ok := l.notified["org/repo:alice"]; ok {
+		t.Errorf("Expected the expired notices to be forgotten, but got %v.", l.notified)
+	}
+	if len(l.uses["org/repo:bob"]) != 1 {
+		t.Errorf("Expected the recent commands to be kept, but got %v.", l.uses)
+	}
+}
+
+func TestCommandRateLimitRejectedCommands(t *testing.T) {
+	noneCommands = newCommandLimiter()
+	defer func() { noneCommands = newCommandLimiter() }()
+	fc := &fakegithub.FakeClient{IssueComments: map[int][]github.IssueComment{}}
+	cfg := &plugins.ReleaseNote{CommandRateLimit: plugins.CommandRateLimit{Commands: 1, Window: "1h"}}
+	for _, body := range []string{"```release-note\nA note.\n```", "```release-note\nA note.\n```", "```release-note\n```"} {
+		ic := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: "/release-note-none", User: github.User{Login: "a"}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      5,
+				Body:        body,
+				State:       "open",
+				PullRequest: &struct{}{},
+			},
+		}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ic); err != nil {
+			t.Fatalf("Unexpected error from handleComment: %v", err)
+		}
+	}
+	// Commands rejected because of the note don't use up the limit.
+	if len(fc.LabelsAdded) != 1 {
+		t.Errorf("Expected the last command to be honored, but got labels %q.", fc.LabelsAdded)
+	}
+	for _, comment := range fc.IssueCommentsAdded {
+		if strings.Contains(comment, "please wait") {
+			t.Errorf("Expected no cool-down notice, but got %q.", comment)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// ReconcileRepoSince applies the release note process to every open PR in
+// org/repo that has been updated since the given time, as if its body had
+// just been edited. It is intended for periodic jobs that catch up on events
+// the plugin missed without processing every open PR each time.
+func ReconcileRepoSince(gc githubClient, cfg *plugins.Configuration, org, repo string, since time.Time) error {
+	log := logrus.WithField("plugin", pluginName)
+	query := fmt.Sprintf("repo:%s/%s is:pr is:open updated:>=%s", org, repo, since.UTC().Format(time.RFC3339))
+	issues, err := gc.FindAllIssues(query, "updated", false)
+	if err != nil {
+		return fmt.Errorf("failed to search for PRs in %s/%s updated since %v: %v", org, repo, since, err)
+	}
+
+	var errs []error
+	for _, issue := range issues {
+		// The search API only has second granularity, so check again.
+		if !issue.IsPullRequest() || issue.UpdatedAt.Before(since) {
+			continue
+		}
+		if err := reconcilePR(gc, log, cfg.ReleaseNoteFor(org, repo), org, repo, issue.Number); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("encountered %d errors reconciling PRs in %s/%s: %v", len(errs), org, repo, errs)
+	}
+	return nil
+}
+
+// reconcilePR runs handlePR against the current state of a PR.
+func reconcilePR(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, org, repo string, number int) error {
+	pr, err := gc.GetPullRequest(org, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to get %s/%s#%d: %v", org, repo, number, err)
+	}
+	if pr == nil {
+		return fmt.Errorf("failed to get %s/%s#%d: not found", org, repo, number)
+	}
+	if cfg.MigrateDeprecatedLabels {
+		if err := migrateDeprecatedLabel(wrapClient(gc, log, cfg, org, repo), org, repo, number); err != nil {
+			return err
+		}
+	}
+	return handlePR(gc, log.WithField("pr", number), withoutDebounce(cfg), editedEvent(org, repo, pr))
+}
+
+// editedEvent returns an event for pr as if its body had just been edited.
+func editedEvent(org, repo string, pr *github.PullRequest) *github.PullRequestEvent {
+	return &github.PullRequestEvent{
+		Action:      github.PullRequestActionEdited,
+		Number:      pr.Number,
+		PullRequest: *pr,
+		Repo: github.Repo{
+			Owner: github.User{Login: org},
+			Name:  repo,
+		},
+	}
+}
+
+// migrateDeprecatedLabel replaces deprecatedReleaseNoteLabelNeeded with
+// releaseNoteLabelNeeded. Since the PR already carries a needed label,
+// handlePR will then treat the author as already notified rather than
+// commenting again.
+func migrateDeprecatedLabel(gc githubClient, org, repo string, number int) error {
+	labels, err := gc.GetIssueLabels(org, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to list labels on %s/%s#%d: %v", org, repo, number, err)
+	}
+	if !hasLabel(deprecatedReleaseNoteLabelNeeded, labels) {
+		return nil
+	}
+	if !hasLabel(releaseNoteLabelNeeded, labels) {
+		if err := gc.AddLabel(org, repo, number, releaseNoteLabelNeeded); err != nil {
+			return fmt.Errorf("failed to add %q to %s/%s#%d: %v", releaseNoteLabelNeeded, org, repo, number, err)
+		}
+	}
+	if err := gc.RemoveLabel(org, repo, number, deprecatedReleaseNoteLabelNeeded); err != nil {
+		return fmt.Errorf("failed to remove %q from %s/%s#%d: %v", deprecatedReleaseNoteLabelNeeded, org, repo, number, err)
+	}
+	return nil
+}
+
+// EnsureLabelAppearance updates the release note labels in org/repo whose
+// color or description differs from their configured appearance. Labels
+// without a configured appearance, or that don't exist, are left alone.
+func EnsureLabelAppearance(gc githubClient, org, repo string, cfg *plugins.Configuration) error {
+	rn := cfg.ReleaseNoteFor(org, repo)
+	if len(rn.LabelAppearances) == 0 {
+		return nil
+	}
+	specs := map[string]LabelSpec{}
+	for _, spec := range ManagedLabels(rn) {
+		if _, ok := rn.LabelAppearances[spec.Name]; ok {
+			specs[spec.Name] = spec
+		}
+	}
+	labels, err := gc.GetRepoLabels(org, repo)
+	if err != nil {
+		return fmt.Errorf("failed to list labels in %s/%s: %v", org, repo, err)
+	}
+
+	var errs []error
+	for _, label := range labels {
+		want, ok := specs[label.Name]
+		if !ok {
+			continue
+		}
+		// An empty description leaves the label's description alone.
+		if strings.ToLower(label.Color) == want.Color && (want.Description == "" || label.Description == want.Description) {
+			continue
+		}
+		if err := gc.UpdateRepoLabel(org, repo, label.Name, want.Color, want.Description); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update %q: %v", label.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("encountered %d errors updating labels in %s/%s: %v", len(errs), org, repo, errs)
+	}
+	return nil
+}
+
+func isManagedLabel(label string) bool {
+	for _, l := range allRNLabels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
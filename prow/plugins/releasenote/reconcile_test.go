@@ -0,0 +1,179 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestReconcileRepoSince(t *testing.T) {
+	since := time.Date(2017, time.October, 1, 12, 0, 0, 0, time.UTC)
+	fc := &fakegithub.FakeClient{
+		IssueComments:  map[int][]github.IssueComment{},
+		ExistingLabels: []string{releaseNote, releaseNoteNone, releaseNoteLabelNeeded},
+		PullRequests:   map[int]*github.PullRequest{},
+	}
+	updated := map[int]time.Time{
+		1: since.Add(time.Hour),
+		2: since.Add(-time.Hour),
+		3: since.Add(time.Second),
+		4: since,
+	}
+	for number, updatedAt := range updated {
+		fc.Issues = append(fc.Issues, github.Issue{
+			Number:      number,
+			UpdatedAt:   updatedAt,
+			PullRequest: &struct{}{},
+		})
+		fc.PullRequests[number] = &github.PullRequest{
+			Number: number,
+			Base:   github.PullRequestBranch{Ref: "master"},
+			Body:   "```release-note\nnone\n```",
+		}
+	}
+
+	if err := ReconcileRepoSince(fc, &plugins.Configuration{}, "org", "repo", since); err != nil {
+		t.Fatalf("Unexpected error from ReconcileRepoSince: %v", err)
+	}
+	// PR 4 was updated exactly at since, which the search includes too.
+	expected := append(formatLabels(1, releaseNoteNone), formatLabels(3, releaseNoteNone)...)
+	expected = append(expected, formatLabels(4, releaseNoteNone)...)
+	sort.Strings(fc.LabelsAdded)
+	if !reflect.DeepEqual(fc.LabelsAdded, expected) {
+		t.Errorf("Expected only PRs updated since %v to be reconciled with labels %q, but got %q.", since, expected, fc.LabelsAdded)
+	}
+}
+
+func TestReconcileMigratesDeprecatedLabel(t *testing.T) {
+	tests := []struct {
+		name          string
+		migrate       bool
+		readOnly      bool
+		initialLabels []string
+		expectLabels  []string
+	}{
+		{
+			name:          "deprecated label is migrated without commenting again",
+			migrate:       true,
+			initialLabels: []string{deprecatedReleaseNoteLabelNeeded},
+		},
+		{
+			name:          "deprecated and current labels are deduplicated",
+			migrate:       true,
+			initialLabels: []string{deprecatedReleaseNoteLabelNeeded, releaseNoteLabelNeeded},
+		},
+		{
+			name:          "without migration the deprecated label is still replaced",
+			initialLabels: []string{deprecatedReleaseNoteLabelNeeded},
+		},
+		{
+			name:          "read-only repos are not migrated",
+			migrate:       true,
+			readOnly:      true,
+			initialLabels: []string{deprecatedReleaseNoteLabelNeeded},
+			expectLabels:  []string{deprecatedReleaseNoteLabelNeeded},
+		},
+	}
+	for _, test := range tests {
+		fc := &fakegithub.FakeClient{
+			IssueComments:  map[int][]github.IssueComment{},
+			ExistingLabels: []string{releaseNoteLabelNeeded},
+			LabelsAdded:    formatLabels(1, test.initialLabels...),
+			PullRequests: map[int]*github.PullRequest{
+				1: {Number: 1, Base: github.PullRequestBranch{Ref: "master"}},
+			},
+			Issues: []github.Issue{
+				{Number: 1, UpdatedAt: time.Now(), PullRequest: &struct{}{}},
+			},
+		}
+		rn := plugins.ReleaseNote{Repos: []string{"org"}, MigrateDeprecatedLabels: test.migrate}
+		if test.readOnly {
+			rn.ReadOnlyRepos = []string{"org/repo"}
+		}
+		cfg := &plugins.Configuration{ReleaseNotes: []plugins.ReleaseNote{rn}}
+		if err := ReconcileRepoSince(fc, cfg, "org", "repo", time.Time{}); err != nil {
+			t.Fatalf("(%s): Unexpected error from ReconcileRepoSince: %v", test.name, err)
+		}
+		if test.expectLabels == nil {
+			test.expectLabels = []string{releaseNoteLabelNeeded}
+		}
+		actualLabels := sliceDifference(fc.LabelsAdded, fc.LabelsRemoved)
+		if expected := formatLabels(1, test.expectLabels...); !reflect.DeepEqual(actualLabels, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, actualLabels)
+		}
+		if len(fc.IssueCommentsAdded) > 0 {
+			t.Errorf("(%s): Expected no comments, but got %q.", test.name, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestEnsureLabelAppearance(t *testing.T) {
+	fc := &fakegithub.FakeClient{
+		RepoLabels: []github.Label{
+			{Name: releaseNote, Color: "ff0000", Description: "Has a release note."},
+			{Name: releaseNoteNone, Color: "C2E0C6", Description: "Doesn't need a release note."},
+			{Name: releaseNoteActionRequired, Color: "c2e0c6"},
+			{Name: lgtmLabel, Color: "ff0000"},
+		},
+	}
+	cfg := &plugins.Configuration{
+		ReleaseNotes: []plugins.ReleaseNote{{
+			Repos: []string{"org"},
+			LabelAppearances: map[string]plugins.LabelAppearance{
+				releaseNote:               {Color: "#c2e0c6", Description: "Has a release note."},
+				releaseNoteNone:           {Color: "c2e0c6", Description: "Doesn't need a release note."},
+				releaseNoteActionRequired: {Color: "c2e0c6", Description: "Has an action required release note."},
+				releaseNoteLabelNeeded:    {Color: "e11d21"},
+				lgtmLabel:                 {Color: "15dd18"},
+			},
+		}},
+	}
+	if err := EnsureLabelAppearance(fc, "org", "repo", cfg); err != nil {
+		t.Fatalf("Unexpected error from EnsureLabelAppearance: %v", err)
+	}
+	if expected := []string{"org/repo:" + releaseNote, "org/repo:" + releaseNoteActionRequired}; !reflect.DeepEqual(fc.RepoLabelsUpdated, expected) {
+		t.Errorf("Expected labels %q to be updated, but got %q.", expected, fc.RepoLabelsUpdated)
+	}
+	expected := []github.Label{
+		{Name: releaseNote, Color: "c2e0c6", Description: "Has a release note."},
+		{Name: releaseNoteNone, Color: "C2E0C6", Description: "Doesn't need a release note."},
+		{Name: releaseNoteActionRequired, Color: "c2e0c6", Description: "Has an action required release note."},
+		{Name: lgtmLabel, Color: "ff0000"},
+	}
+	if !reflect.DeepEqual(fc.RepoLabels, expected) {
+		t.Errorf("Expected labels %+v, but got %+v.", expected, fc.RepoLabels)
+	}
+}
+
+func TestDeprecatedNeededLabelNotRecommented(t *testing.T) {
+	fc, pr := newFakeClient("", "master", []string{deprecatedReleaseNoteLabelNeeded}, nil, nil)
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if len(fc.IssueCommentsAdded) > 0 {
+		t.Errorf("Expected no new comments on a PR that was already told it needs a note, but got %q.", fc.IssueCommentsAdded)
+	}
+}
@@ -17,18 +17,54 @@ limitations under the License.
 package releasenote
 
 import (
+	"bytes"
 	"fmt"
+	"net/url"
+	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
 
+	"github.com/ghodss/yaml"
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/pluginhelp"
 	"k8s.io/test-infra/prow/plugins"
 )
 
-const pluginName = "release-note"
+const (
+	pluginName    = "release-note"
+	statusContext = "release-note"
+)
+
+// Values of the NoTemplateBehavior config option.
+const (
+	noTemplateBlock    = "block"
+	noTemplateAutoNone = "auto-none"
+	noTemplateIgnore   = "ignore"
+)
+
+// Values of the EmptyActionRequiredBehavior config option.
+const (
+	emptyActionAccept = "accept"
+	emptyActionBlock  = "block"
+	emptyActionLabel  = "label"
+)
+
+// Values of the RevertNoteBehavior config option.
+const (
+	revertRequire  = "require"
+	revertAutoNone = "auto-none"
+)
+
+// revertTitlePrefix starts the titles of revert PRs created by GitHub.
+const revertTitlePrefix = `Revert "`
 
 const (
 	// deprecatedReleaseNoteLabelNeeded is the previous version of the
@@ -40,10 +76,55 @@ const (
 	releaseNote               = "release-note"
 	releaseNoteNone           = "release-note-none"
 	releaseNoteActionRequired = "release-note-action-required"
+	// releaseNoteInherited marks cherry-picks whose parents' release notes
+	// satisfy the process.
+	releaseNoteInherited = "release-note-inherited"
+	// needsRereviewLabel marks PRs with an action required release note
+	// that doesn't describe the action.
+	needsRereviewLabel = "needs-rereview"
+	// noteChangedLabel marks PRs whose release note was edited after they
+	// were approved.
+	noteChangedLabel = "release-note-changed-after-approval"
+	approvedLabel    = "approved"
+	// releaseNoteSecurity marks PRs whose release note mentions security.
+	releaseNoteSecurity = "release-note-security"
+	// releaseNoteWasNeeded records that a PR was once blocked on its release
+	// note.
+	releaseNoteWasNeeded = "release-note-was-needed"
 
 	releaseNoteFormat       = `Adding %s because the release note process has not been followed.`
 	releaseNoteSuffixFormat = `One of the following labels is required %q, %q, or %q.
 Please see: https://github.com/kubernetes/community/blob/master/contributors/devel/pull-requests.md#write-release-notes-if-needed.`
+	suggestionFenceBody     = "It looks like the release note was written in a `suggestion` block. Please move it into a `release-note` block instead, for example:\n````\n```release-note\nSome release note.\n```\n````"
+	misfencedNoteBody       = "It looks like the release note was written in a code block. Please move it into a `release-note` block instead, for example:\n````\n```release-note\nSome release note.\n```\n````"
+	unresolvedEditsFormat   = "<!-- release-note-unresolved-edits: %d -->"
+	escalationBody          = "This PR still needs a release note."
+	rstNoteDirective        = ".. release-note::"
+	formNoResponse          = "_No response_"
+	actionDelimiterPrefix   = "This release note requires action, but doesn't separate the action from the rest of the note"
+	recordedNoteMarker      = "<!-- release-note: recorded -->"
+	acceptedNoneMarker      = "<!-- release-note: none accepted -->"
+	acceptedNoneFormat      = "Marked this PR as not needing a release note on behalf of @%s."
+	parentNoteMarker        = "<!-- release-note: parents -->"
+	populatedNoteMarker     = "<!-- release-note: populated -->"
+	bumpNoteMarker          = "<!-- release-note: synthesized -->"
+	bumpNoteBody            = "Synthesized the release note of this automated PR:"
+	botCommentMarker        = "<!-- release-note: bot comment -->"
+	populatedNoteBody       = "Populated the release note of this cherry-pick from its parents:"
+	recordedNoteFormat      = "Recorded the following action required release note from @%s:"
+	welcomeBody             = "Welcome, and thanks for your first PR here! Every PR needs a release note describing its user-visible change for the changelog. Please write it in the `release-note` block of the PR body, for example:\n````\n```release-note\nThe foo command now supports the --bar flag.\n```\n````\nIf the change requires users to take action when upgrading, include the phrase `action required` in the note. If the change isn't user-visible, eg. a test or docs fix, write `NONE` in the block instead."
+	missingParentBody       = "This PR targets a release branch, but doesn't reference the PR it cherry-picks, so it must have its own release note. If it is a cherry-pick, please add a line like `Cherry pick of #123 on release-1.8.` to the PR body."
+	strictMilestoneBody     = "This PR is in a milestone that requires a real release note, so `NONE` isn't accepted. Please describe the change in the `release-note` block."
+	forbiddenFlagBody       = "The release note mentions feature flags that aren't user-visible yet. Please remove them from the note."
+	tooManyParentsFormat    = "This PR references more than %d cherry-pick parents, so only the first %d were checked. Please clean up the PR body so it only references the PRs it cherry-picks."
+	emptyActionRequiredBody = "The release note says that action is required, but doesn't describe the action. Please describe what users need to do in the `release-note` block."
+	noteLanguageBody        = "The release note doesn't appear to be in this repo's primary language. Please consider writing it in that language so it can be published in the changelog as is."
+	noteChangedBody         = "The release note was changed after this PR was approved. Please make sure that reviewers are happy with the new note."
+	referenceOnlyBody       = "The release note only references an issue, PR or URL, which isn't a usable changelog entry. Please describe the user-visible change in the `release-note` block."
+	malformedMarkdownBody   = "The release note has unbalanced markdown, which will render poorly in the changelog. Please check its formatting."
+	linkPlaceholderBody     = "The release note contains links whose targets haven't been filled in, which will be broken in the changelog. Please replace them with real URLs."
+	verboseNoteBody         = "The release note repeats the PR description. Please consider writing a concise note describing the user-facing change for the changelog instead."
+	relativeLinkBody        = `The release note contains relative links, which won't resolve once the note is published in the changelog. Please consider using absolute URLs instead.`
 	parentReleaseNoteFormat = `All 'parent' PRs of a cherry-pick PR must have one of the %q or %q labels, or this PR must follow the standard/parent release note labeling requirement.`
 
 	noReleaseNoteComment = "none"
@@ -51,13 +132,28 @@ Please see: https://github.com/kubernetes/community/blob/master/contributors/dev
 )
 
 var (
-	releaseNoteSuffix         = fmt.Sprintf(releaseNoteSuffixFormat, releaseNote, releaseNoteActionRequired, releaseNoteNone)
-	releaseNoteBody           = fmt.Sprintf(releaseNoteFormat, releaseNoteLabelNeeded)
 	deprecatedReleaseNoteBody = fmt.Sprintf(releaseNoteFormat, deprecatedReleaseNoteLabelNeeded)
-	parentReleaseNoteBody     = fmt.Sprintf(parentReleaseNoteFormat, releaseNote, releaseNoteActionRequired)
 
-	noteMatcherRE = regexp.MustCompile(`(?s)(?:Release note\*\*:\s*(?:<!--[^<>]*-->\s*)?` + "```(?:release-note)?|```release-note)(.+?)```")
-	cpRe          = regexp.MustCompile(`Cherry pick of #([[:digit:]]+) on release-([[:digit:]]+\.[[:digit:]]+).`)
+	markdownLinkRe    = regexp.MustCompile(`\[[^\]]*\]\(\s*([^)\s]+)[^)]*\)`)
+	cpRe              = regexp.MustCompile(`Cherry pick of #([[:digit:]]+) on release-([[:digit:]]+\.[[:digit:]]+).`)
+	suggestionRe      = regexp.MustCompile("(?s)```suggestion[ \t]*\r?\n(.*?)```")
+	formFieldRe       = regexp.MustCompile(`(?i)^###[ \t]+release[ -]notes?[ \t]*$`)
+	formHeadingRe     = regexp.MustCompile(`^#{1,3}[ \t]`)
+	formFenceRe       = regexp.MustCompile("(?s)\\A```[[:alnum:]_+-]*[ \t]*\n(.*)\n```\\z")
+	codeFenceRe       = regexp.MustCompile("(?s)```([[:alnum:]_+-]*)[ \t]*\r?\n(.*?)```")
+	unresolvedEditsRe = regexp.MustCompile(`<!-- release-note-unresolved-edits: ([[:digit:]]+) -->`)
+	leadingNoneRe     = regexp.MustCompile(`^none\b`)
+	noNoteCheckboxRe  = regexp.MustCompile(`(?mi)^\s*[-*]\s+\[x\]\s+no release note needed\.?\s*$`)
+	dependsOnRe       = regexp.MustCompile(`(?mi)^\s*(?:depends on|part of):?\s+#([[:digit:]]+)\b`)
+	footerRe          = regexp.MustCompile(`(?i)^\s*(?:signed-off-by|co-authored-by|reviewed-by|acked-by|tested-by|reported-by|change-id):\s`)
+	noteReferenceRe   = regexp.MustCompile(`https?://\S+|(?:[[:alnum:]_.-]+/[[:alnum:]_.-]+)?#[[:digit:]]+`)
+	conventionalRe    = regexp.MustCompile(`^([[:alpha:]]+)(?:\([^)]*\))?(!)?:[ \t]+(\S.*)$`)
+	breakingChangeRe  = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:[ \t]`)
+	linkPlaceholderRe = regexp.MustCompile(`(?i)\[[^\]]*\]\(\s*(?:url|link)?\s*\)`)
+	htmlCommentRe     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	notActionMarkerRe = regexp.MustCompile(`(?i)<!--\s*release-note:\s*not-action-required\s*-->`)
+	frontMatterRe     = regexp.MustCompile(`(?s)\A\s*---[ \t]*\r?\n(.*?)\r?\n---[ \t]*(?:\r?\n|\z)`)
+	upstreamRefRe     = regexp.MustCompile(`(?mi)^\s*upstream:\s+([[:alnum:]_.-]+)/([[:alnum:]_.-]+)#([[:digit:]]+)\b`)
 
 	allRNLabels = []string{
 		releaseNoteNone,
@@ -67,49 +163,210 @@ var (
 		releaseNote,
 	}
 
-	releaseNoteRe               = regexp.MustCompile(`(?mi)^/release-note\s*$`)
-	releaseNoteNoneRe           = regexp.MustCompile(`(?mi)^/release-note-none\s*$`)
-	releaseNoteActionRequiredRe = regexp.MustCompile(`(?mi)^/release-note-action-required\s*$`)
+	releaseNoteRefreshRe = regexp.MustCompile(`(?mi)^/release-note-refresh\s*$`)
+
+	defaultNoteFences            = []string{"release-note", "release-notes"}
+	defaultPrimaryBranches       = []string{"master"}
+	defaultMaxParents            = 20
+	defaultNoteHeading           = "Release note"
+	defaultActionRequiredPhrases = []string{actionRequiredNote}
+	defaultNoneSynonyms          = []string{"n/a", "na", "not applicable"}
+
+	// languageScripts are the scripts notes in each supported
+	// ExpectedNoteLanguage are written in.
+	languageScripts = map[string][]*unicode.RangeTable{
+		"de": {unicode.Latin},
+		"en": {unicode.Latin},
+		"es": {unicode.Latin},
+		"fr": {unicode.Latin},
+		"ja": {unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Latin},
+		"ru": {unicode.Cyrillic, unicode.Latin},
+		"zh": {unicode.Han, unicode.Latin},
+	}
 )
 
+// releaseNoteBody returns the comment explaining why the needed label, as
+// named in cfg, was added.
+func releaseNoteBody(cfg *plugins.ReleaseNote) string {
+	return fmt.Sprintf(releaseNoteFormat, newLabelNames(cfg).name(releaseNoteLabelNeeded))
+}
+
+// releaseNoteSuffix returns the suffix of the guidance comments, which lists
+// the release note labels as named in cfg.
+func releaseNoteSuffix(cfg *plugins.ReleaseNote) string {
+	names := newLabelNames(cfg)
+	return fmt.Sprintf(releaseNoteSuffixFormat, names.name(releaseNote), names.name(releaseNoteActionRequired), names.name(releaseNoteNone))
+}
+
+// parentReleaseNoteBody returns the comment asking for a release note on a
+// cherry-pick with noteless parents, naming the labels as in cfg.
+func parentReleaseNoteBody(cfg *plugins.ReleaseNote) string {
+	names := newLabelNames(cfg)
+	return fmt.Sprintf(parentReleaseNoteFormat, names.name(releaseNote), names.name(releaseNoteActionRequired))
+}
+
+// noteRegexes holds the regexes used to extract and classify release notes,
+// compiled for a particular release-note config.
+type noteRegexes struct {
+	// noteMatcher captures the contents of the release note block.
+	noteMatcher *regexp.Regexp
+	// actionRequired matches release notes that require additional action.
+	actionRequired *regexp.Regexp
+	// areaBlock captures the area and contents of a release note block
+	// tagged with an area, eg. ```release-note area/network.
+	areaBlock *regexp.Regexp
+	// noteCommand, noneCommand and actionRequiredCommand match the commands
+	// named after the release note labels in cfg, eg. /release-note-none.
+	// noneCommand also matches the alias command for cfg.NoneSentinel.
+	noteCommand           *regexp.Regexp
+	noneCommand           *regexp.Regexp
+	actionRequiredCommand *regexp.Regexp
+	// recordedActionRequired captures the note of an action required
+	// command, eg. /release-note-action-required <note>.
+	recordedActionRequired *regexp.Regexp
+	// forbiddenFlags are the valid cfg.ForbiddenFlagPatterns.
+	forbiddenFlags []*regexp.Regexp
+	// securityNotes are the valid cfg.SecurityNotePatterns.
+	securityNotes []*regexp.Regexp
+	// bumpTitles are the title patterns of cfg.BumpNotes, in the same order.
+	bumpTitles []*regexp.Regexp
+}
+
+// regexCache holds the noteRegexes compiled for each distinct config so that
+// handlers don't need to recompile them on every event.
+var regexCache = struct {
+	sync.Mutex
+	entries map[string]*noteRegexes
+}{entries: map[string]*noteRegexes{}}
+
 func init() {
 	plugins.RegisterIssueCommentHandler(pluginName, handleIssueComment)
 	plugins.RegisterPullRequestHandler(pluginName, handlePullRequest)
+	plugins.RegisterHelpProvider(pluginName, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	purposes := map[string]string{}
+	for _, spec := range defaultLabelSpecs(&plugins.ReleaseNote{}) {
+		purposes[spec.Name] = spec.Purpose
+	}
+	var labels []string
+	for _, label := range allRNLabels {
+		labels = append(labels, fmt.Sprintf("%s: %s", label, purposes[label]))
+	}
+	repoConfig := map[string]string{}
+	for _, enabled := range enabledRepos {
+		parts := strings.SplitN(enabled, "/", 2)
+		org, repo := parts[0], ""
+		if len(parts) == 2 {
+			repo = parts[1]
+		}
+		cfg := config.ReleaseNoteFor(org, repo)
+		names := newLabelNames(cfg)
+		var managed []string
+		for _, spec := range ManagedLabels(cfg) {
+			managed = append(managed, names.name(spec.Name))
+		}
+		repoConfig[enabled] = fmt.Sprintf("PRs into %s must have a release note. The plugin manages the labels %s.", strings.Join(primaryBranches(cfg), ", "), strings.Join(managed, ", "))
+		if none := names.name(releaseNoteNone); none != releaseNoteNone {
+			repoConfig[enabled] += fmt.Sprintf(" The /%s command is /%s here.", releaseNoteNone, none)
+		}
+	}
+	pluginHelp := &pluginhelp.PluginHelp{
+		Description: "The release-note plugin enforces the release note process by labeling each PR by its release note, which is written in a block of the PR body like:\n```release-note\nSome release note.\n```\n" +
+			"A note including the phrase \"action required\" requires users to take action when upgrading, and a note of \"NONE\" means the PR doesn't need one. PRs without a release note can't merge. The labels are:\n" +
+			strings.Join(labels, "\n"),
+		WhoCanUse: "PR authors and org members can use the /release-note-none command.",
+		Config:    repoConfig,
+		Events:    []string{"pull_request", "issue_comment"},
+	}
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/release-note-none",
+		Featured:    true,
+		Description: "Marks a PR as not needing a release note, if the release note block of its body is empty or \"NONE\". The /release-note and /release-note-action-required commands are deprecated in favor of the block.",
+		Examples:    []string{"/release-note-none"},
+		WhoCanUse:   "The PR author and org members.",
+	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/release-note-refresh",
+		Description: "Re-evaluates the release note labels of a PR from its body, eg. after the plugin missed an edit.",
+		Examples:    []string{"/release-note-refresh"},
+		WhoCanUse:   "The PR author and org members.",
+	})
+	return pluginHelp, nil
 }
 
 type githubClient interface {
 	IsMember(org, user string) (bool, error)
 	CreateComment(owner, repo string, number int, comment string) error
+	EditComment(org, repo string, ID int, comment string) error
 	AddLabel(owner, repo string, number int, label string) error
 	RemoveLabel(owner, repo string, number int, label string) error
+	ReplaceLabels(org, repo string, number int, labels []string) error
 	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
+	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
+	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
+	GetFile(org, repo, filepath, commit string) ([]byte, error)
+	FindIssues(query, sort string, asc bool) ([]github.Issue, error)
+	FindAllIssues(query, sort string, asc bool) ([]github.Issue, error)
 	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
 	DeleteStaleComments(org, repo string, number int, comments []github.IssueComment, isStale func(github.IssueComment) bool) error
+	CreateReview(org, repo string, number int, r github.DraftReview) error
+	ListReviews(org, repo string, number int) ([]github.Review, error)
+	DismissReview(org, repo string, number, ID int, message string) error
+	CreateStatus(org, repo, ref string, s github.Status) error
+	ListTeamMembers(id int) ([]github.TeamMember, error)
+	RequestReview(org, repo string, number int, logins []string) error
+	ListIssueEvents(org, repo string, number int) ([]github.ListedIssueEvent, error)
+	GetRepoLabels(org, repo string) ([]github.Label, error)
+	UpdateRepoLabel(org, repo, label, color, description string) error
 	BotName() (string, error)
 }
 
 func handleIssueComment(pc plugins.PluginClient, ic github.IssueCommentEvent) error {
-	return handleComment(pc.GitHubClient, pc.Logger, ic)
+	cfg := pc.PluginConfig.ReleaseNoteFor(ic.Repo.Owner.Login, ic.Repo.Name)
+	return handleComment(pc.GitHubClient, pc.Logger, cfg, ic)
 }
 
-func handleComment(gc githubClient, log *logrus.Entry, ic github.IssueCommentEvent) error {
+func handleComment(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, ic github.IssueCommentEvent) error {
 	// Only consider PRs and new comments.
-	if !ic.Issue.IsPullRequest() || ic.Action != github.IssueCommentActionCreated {
+	if ic.Action != github.IssueCommentActionCreated {
+		return nil
+	}
+	if !ic.Issue.IsPullRequest() {
+		if cfg.ReplyOnNonPR && isReleaseNoteCommand(cfg, ic.Comment.Body) {
+			resp := "the release note commands only apply to pull requests."
+			return gc.CreateComment(ic.Repo.Owner.Login, ic.Repo.Name, ic.Issue.Number, plugins.FormatICResponse(ic.Comment, resp))
+		}
 		return nil
 	}
 
 	org := ic.Repo.Owner.Login
 	repo := ic.Repo.Name
 	number := ic.Issue.Number
+	if releaseNoteRefreshRe.MatchString(ic.Comment.Body) {
+		// handlePR wraps the client itself.
+		return refreshPR(gc, log, cfg, ic)
+	}
+	gc = wrapClient(gc, log, cfg, org, repo)
+	names := newLabelNames(cfg)
+	ic.Issue.Labels = names.labels(ic.Issue.Labels)
+	res := regexesFor(cfg)
+
+	if cfg.RecordActionRequiredNotes {
+		if m := res.recordedActionRequired.FindStringSubmatch(ic.Comment.Body); m != nil {
+			return recordActionRequiredNote(gc, cfg, ic, m[1])
+		}
+	}
 
 	// Which label does the comment want us to add?
 	var nl string
 	switch {
-	case releaseNoteRe.MatchString(ic.Comment.Body):
+	case res.noteCommand.MatchString(ic.Comment.Body):
 		nl = releaseNote
-	case releaseNoteNoneRe.MatchString(ic.Comment.Body):
+	case res.noneCommand.MatchString(ic.Comment.Body):
 		nl = releaseNoteNone
-	case releaseNoteActionRequiredRe.MatchString(ic.Comment.Body):
+	case res.actionRequiredCommand.MatchString(ic.Comment.Body):
 		nl = releaseNoteActionRequired
 	default:
 		return nil
@@ -117,11 +374,17 @@ func handleComment(gc githubClient, log *logrus.Entry, ic github.IssueCommentEve
 
 	// Emit deprecation warning for /release-note and /release-note-action-required.
 	if nl == releaseNote || nl == releaseNoteActionRequired {
+		deprecatedCommandCounter.WithLabelValues("/"+names.name(nl), org+"/"+repo).Inc()
 		format := "the `/%s` and `/%s` commands have been deprecated.\nPlease edit the `release-note` block in the PR body text to include the release note. If the release note requires additional action include the string `action required` in the release note. For example:\n````\n```release-note\nSome release note with action required.\n```\n````"
-		resp := fmt.Sprintf(format, releaseNote, releaseNoteActionRequired)
+		resp := fmt.Sprintf(format, names.name(releaseNote), names.name(releaseNoteActionRequired))
 		return gc.CreateComment(org, repo, number, plugins.FormatICResponse(ic.Comment, resp))
 	}
 
+	if ignoredBot(cfg, ic.Comment.User) {
+		log.Infof("Ignoring /%s from bot %s, which is not in none_command_allowed_bots.", names.name(releaseNoteNone), ic.Comment.User.Login)
+		return nil
+	}
+
 	// Only allow authors and org members to add labels.
 	isMember, err := gc.IsMember(ic.Repo.Owner.Login, ic.Comment.User.Login)
 	if err != nil {
@@ -130,24 +393,83 @@ func handleComment(gc githubClient, log *logrus.Entry, ic github.IssueCommentEve
 
 	isAuthor := ic.Issue.IsAuthor(ic.Comment.User.Login)
 
+	rejection := rejectionData{Label: names.name(releaseNoteNone), User: ic.Comment.User.Login}
 	if !isMember && !isAuthor {
 		format := "you can only set the release note label to %s if you are the PR author or an org member."
-		resp := fmt.Sprintf(format, releaseNoteNone)
-		return gc.CreateComment(org, repo, number, plugins.FormatICResponse(ic.Comment, resp))
+		resp := fmt.Sprintf(format, names.name(releaseNoteNone))
+		return rejectComment(gc, log, ic, "permission_rejection_template", cfg.PermissionRejectionTemplate, resp, rejection)
+	}
+
+	// rateWindow is how long the command counts towards the rate limit once
+	// applied, if any.
+	var rateWindow time.Duration
+	if limit := cfg.CommandRateLimit; limit.Commands > 0 {
+		window, err := time.ParseDuration(limit.Window)
+		if err != nil {
+			log.WithError(err).Errorf("Invalid command_rate_limit window %q.", limit.Window)
+		} else if allowed, wait, notify := noneCommands.allow(org, repo, ic.Comment.User.Login, limit.Commands); !allowed {
+			log.Infof("Ignoring /%s from %s on %s/%s#%d, who exceeded the command rate limit.", names.name(releaseNoteNone), ic.Comment.User.Login, org, repo, number)
+			if !notify {
+				return nil
+			}
+			format := "you have used the /%s command too often, please wait %s before using it again."
+			resp := fmt.Sprintf(format, names.name(releaseNoteNone), wait.Round(time.Second))
+			return gc.CreateComment(org, repo, number, plugins.FormatICResponse(ic.Comment, resp))
+		} else {
+			rateWindow = window
+		}
+	}
+
+	for _, precondition := range cfg.NonePreconditions {
+		met, err := nonePreconditionMet(gc, org, repo, number, ic.Comment.User.Login, ic.Issue.Labels, precondition)
+		if err != nil {
+			return err
+		}
+		if !met {
+			rejection.Precondition = describeNonePrecondition(precondition)
+			format := "you can only set the release note label to %s if you %s."
+			resp := fmt.Sprintf(format, names.name(releaseNoteNone), rejection.Precondition)
+			return rejectComment(gc, log, ic, "precondition_rejection_template", cfg.PreconditionRejectionTemplate, resp, rejection)
+		}
 	}
 
 	// Don't allow the /release-note-none command if the release-note block contains a valid release note.
-	blockNL := determineReleaseNoteLabel(ic.Issue.Body)
+	blockNL := determineReleaseNoteLabel(cfg, ic.Issue.Body)
 	if blockNL == releaseNote || blockNL == releaseNoteActionRequired {
+		if cfg.BodyNoteWinsSilently {
+			log.Infof("Ignoring /%s on %s/%s#%d, whose body has a release note.", names.name(releaseNoteNone), org, repo, number)
+			return nil
+		}
 		format := "you can only set the release note label to %s if the release-note block in the PR body text is empty or \"none\"."
-		resp := fmt.Sprintf(format, releaseNoteNone)
-		return gc.CreateComment(org, repo, number, plugins.FormatICResponse(ic.Comment, resp))
+		resp := fmt.Sprintf(format, names.name(releaseNoteNone))
+		return rejectComment(gc, log, ic, "precedence_rejection_template", cfg.PrecedenceRejectionTemplate, resp, rejection)
+	}
+	if recordsNoneCommands(cfg) {
+		// Record the accepted command, so later events honor only commands
+		// that passed the checks above.
+		record := fmt.Sprintf("%s\n%s", acceptedNoneMarker, fmt.Sprintf(acceptedNoneFormat, ic.Comment.User.Login))
+		if err := gc.CreateComment(org, repo, number, record); err != nil {
+			return err
+		}
 	}
 	if !ic.Issue.HasLabel(releaseNoteNone) {
 		if err := gc.AddLabel(org, repo, number, releaseNoteNone); err != nil {
 			return err
 		}
 	}
+	if rateWindow > 0 {
+		noneCommands.record(org, repo, ic.Comment.User.Login, rateWindow)
+	}
+	if cfg.UseReviewForGuidance && hasNeededLabel(ic.Issue.Labels) {
+		if err := dismissGuidanceReviews(gc, cfg, org, repo, number); err != nil {
+			log.WithError(err).Errorf("Failed to dismiss release note reviews on %s/%s#%d.", org, repo, number)
+		}
+	}
+	if resolvesNeededLabel(cfg, releaseNoteNone, ic.Issue.Labels) {
+		if err := gc.AddLabel(org, repo, number, releaseNoteWasNeeded); err != nil {
+			log.WithError(err).Errorf("Failed to add %q to %s/%s#%d.", releaseNoteWasNeeded, org, repo, number)
+		}
+	}
 	// Remove all other release-note-* labels if necessary.
 	return removeOtherLabels(
 		func(l string) error {
@@ -159,221 +481,2464 @@ func handleComment(gc githubClient, log *logrus.Entry, ic github.IssueCommentEve
 	)
 }
 
-func removeOtherLabels(remover func(string) error, label string, labelSet []string, currentLabels []github.Label) error {
-	var errs []error
-	for _, elem := range labelSet {
-		if elem != label && hasLabel(elem, currentLabels) {
-			if err := remover(elem); err != nil {
-				errs = append(errs, err)
+// nonePreconditionMet returns true if any of the conditions of precondition
+// hold for the user's /release-note-none command on the PR.
+func nonePreconditionMet(gc githubClient, org, repo string, number int, user string, prLabels []github.Label, precondition plugins.NonePrecondition) (bool, error) {
+	if hasAnyLabel(precondition.Labels, prLabels) {
+		return true, nil
+	}
+	for _, id := range precondition.TeamIDs {
+		members, err := gc.ListTeamMembers(id)
+		if err != nil {
+			return false, fmt.Errorf("failed to list members of team %d: %v", id, err)
+		}
+		for _, m := range members {
+			if github.NormLogin(m.Login) == github.NormLogin(user) {
+				return true, nil
 			}
 		}
 	}
-	if len(errs) > 0 {
-		return fmt.Errorf("encountered %d errors setting labels: %v", len(errs), errs)
+	if precondition.Approved {
+		reviews, err := gc.ListReviews(org, repo, number)
+		if err != nil {
+			return false, fmt.Errorf("failed to list reviews on %s/%s#%d: %v", org, repo, number, err)
+		}
+		for _, r := range reviews {
+			if r.State == github.ReviewStateApproved {
+				return true, nil
+			}
+		}
 	}
-	return nil
+	return false, nil
 }
 
-func handlePullRequest(pc plugins.PluginClient, pr github.PullRequestEvent) error {
-	return handlePR(pc.GitHubClient, pc.Logger, &pr)
+// describeNonePrecondition returns what a user must do to meet precondition,
+// eg. "are a member of team 42 or the PR has an approving review".
+func describeNonePrecondition(precondition plugins.NonePrecondition) string {
+	var conditions []string
+	for _, id := range precondition.TeamIDs {
+		conditions = append(conditions, fmt.Sprintf("are a member of team %d", id))
+	}
+	if precondition.Approved {
+		conditions = append(conditions, "the PR has an approving review")
+	}
+	if len(precondition.Labels) > 0 {
+		conditions = append(conditions, fmt.Sprintf("the PR has one of the labels %s", strings.Join(precondition.Labels, ", ")))
+	}
+	return strings.Join(conditions, " or ")
 }
 
-func handlePR(gc githubClient, log *logrus.Entry, pr *github.PullRequestEvent) error {
-	// Only consider events that edit the PR body.
-	if pr.Action != github.PullRequestActionOpened && pr.Action != github.PullRequestActionEdited {
-		return nil
+// refreshPR handles "/release-note-refresh" by re-evaluating the PR as if
+// its body had just been edited, eg. after events were missed while the
+// plugin was down. Only the author and org members may refresh a PR.
+func refreshPR(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, ic github.IssueCommentEvent) error {
+	org := ic.Repo.Owner.Login
+	repo := ic.Repo.Name
+	number := ic.Issue.Number
+	isMember, err := gc.IsMember(org, ic.Comment.User.Login)
+	if err != nil {
+		return err
 	}
-	org := pr.Repo.Owner.Login
-	repo := pr.Repo.Name
+	if !isMember && !ic.Issue.IsAuthor(ic.Comment.User.Login) {
+		resp := "you can only refresh the release note labels if you are the PR author or an org member."
+		return wrapClient(gc, log, cfg, org, repo).CreateComment(org, repo, number, plugins.FormatICResponse(ic.Comment, resp))
+	}
+	pr, err := gc.GetPullRequest(org, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to get %s/%s#%d. err: %v", org, repo, number, err)
+	}
+	if pr == nil {
+		return fmt.Errorf("failed to get %s/%s#%d", org, repo, number)
+	}
+	return handlePR(gc, log, cfg, &github.PullRequestEvent{
+		Action:      github.PullRequestActionEdited,
+		Number:      number,
+		PullRequest: *pr,
+		Repo:        ic.Repo,
+	})
+}
 
-	prLabels, err := gc.GetIssueLabels(org, repo, pr.Number)
+// recordActionRequiredNote handles "/release-note-action-required <note>"
+// from an org member by labeling the PR release-note-action-required and
+// recording the note in a comment, for PRs whose body can't be edited.
+func recordActionRequiredNote(gc githubClient, cfg *plugins.ReleaseNote, ic github.IssueCommentEvent, note string) error {
+	org := ic.Repo.Owner.Login
+	repo := ic.Repo.Name
+	number := ic.Issue.Number
+
+	isMember, err := gc.IsMember(org, ic.Comment.User.Login)
 	if err != nil {
-		return fmt.Errorf("failed to list labels on PR #%d. err: %v", pr.Number, err)
+		return err
+	}
+	if !isMember {
+		resp := fmt.Sprintf("you can only record a release note with `/%s` if you are an org member.", newLabelNames(cfg).name(releaseNoteActionRequired))
+		return gc.CreateComment(org, repo, number, plugins.FormatICResponse(ic.Comment, resp))
 	}
 
-	var comments []github.IssueComment
-	labelToAdd := determineReleaseNoteLabel(pr.PullRequest.Body)
-	if labelToAdd == releaseNoteLabelNeeded {
-		if !prMustFollowRelNoteProcess(gc, log, pr, prLabels, true) {
-			ensureNoRelNoteNeededLabel(gc, log, pr, prLabels)
-			return clearStaleComments(gc, log, pr, prLabels, nil)
-		}
-		// If /release-note-none has been left on PR then pretend the release-note body is "NONE" instead of empty.
-		comments, err = gc.ListIssueComments(org, repo, pr.Number)
-		if err != nil {
-			return fmt.Errorf("failed to list comments on %s/%s#%d. err: %v", org, repo, pr.Number, err)
-		}
-		if containsNoneCommand(comments) {
-			labelToAdd = releaseNoteNone
-		}
+	comment := fmt.Sprintf("%s\n%s\n```release-note\n%s\n```", recordedNoteMarker, fmt.Sprintf(recordedNoteFormat, ic.Comment.User.Login), note)
+	if err := gc.CreateComment(org, repo, number, comment); err != nil {
+		return err
 	}
-	if labelToAdd == releaseNoteLabelNeeded {
-		if !hasLabel(releaseNoteLabelNeeded, prLabels) {
-			comment := plugins.FormatResponse(pr.PullRequest.User.Login, releaseNoteBody, releaseNoteSuffix)
-			if err := gc.CreateComment(org, repo, pr.Number, comment); err != nil {
-				log.WithError(err).Errorf("Failed to comment on %s/%s#%d with comment %q.", org, repo, pr.Number, comment)
-			}
+	if !ic.Issue.HasLabel(releaseNoteActionRequired) {
+		if err := gc.AddLabel(org, repo, number, releaseNoteActionRequired); err != nil {
+			return err
 		}
-	} else {
-		//going to apply some other release-note-label
-		ensureNoRelNoteNeededLabel(gc, log, pr, prLabels)
 	}
-
-	// Add the label if needed
-	if !hasLabel(labelToAdd, prLabels) {
-		if err = gc.AddLabel(org, repo, pr.Number, labelToAdd); err != nil {
+	if cfg.UseReviewForGuidance && hasNeededLabel(ic.Issue.Labels) {
+		if err := dismissGuidanceReviews(gc, cfg, org, repo, number); err != nil {
 			return err
 		}
 	}
-
-	err = removeOtherLabels(
+	return removeOtherLabels(
 		func(l string) error {
-			return gc.RemoveLabel(org, repo, pr.Number, l)
+			return gc.RemoveLabel(org, repo, number, l)
 		},
-		labelToAdd,
+		releaseNoteActionRequired,
 		allRNLabels,
-		prLabels,
+		ic.Issue.Labels,
 	)
-	if err != nil {
-		log.Error(err)
+}
+
+// latestCommandLabel returns the label applied by the most recent release
+// note command in comments, or "" if there is none. A /release-note-none
+// command applies releaseNoteNone, and a release note the bot recorded from
+// a /release-note-action-required command applies releaseNoteActionRequired
+// if cfg.RecordActionRequiredNotes is set. If recordsNoneCommands(cfg), only
+// the /release-note-none commands the bot accepted and recorded count.
+func latestCommandLabel(gc githubClient, cfg *plugins.ReleaseNote, comments []github.IssueComment) (string, error) {
+	strict := recordsNoneCommands(cfg)
+	var botName string
+	if strict || cfg.RecordActionRequiredNotes {
+		var err error
+		if botName, err = gc.BotName(); err != nil {
+			return "", err
+		}
+	}
+	noneCommand := regexesFor(cfg).noneCommand
+	var label string
+	var latest time.Time
+	for _, c := range comments {
+		fromBot := botName != "" && github.NormLogin(c.User.Login) == github.NormLogin(botName)
+		var l string
+		switch {
+		case strict && fromBot && strings.HasPrefix(c.Body, acceptedNoneMarker):
+			l = releaseNoteNone
+		case !strict && noneCommand.MatchString(c.Body):
+			l = releaseNoteNone
+		case cfg.RecordActionRequiredNotes && fromBot && strings.HasPrefix(c.Body, recordedNoteMarker):
+			l = releaseNoteActionRequired
+		default:
+			continue
+		}
+		// Comments are listed in order, so later comments win ties.
+		if label == "" || !c.CreatedAt.Before(latest) {
+			label, latest = l, c.CreatedAt
+		}
 	}
+	return label, nil
+}
 
-	return clearStaleComments(gc, log, pr, prLabels, comments)
+func isReleaseNoteCommand(cfg *plugins.ReleaseNote, body string) bool {
+	res := regexesFor(cfg)
+	return res.noteCommand.MatchString(body) ||
+		res.noneCommand.MatchString(body) ||
+		res.actionRequiredCommand.MatchString(body) ||
+		res.recordedActionRequired.MatchString(body) ||
+		releaseNoteRefreshRe.MatchString(body)
 }
 
-func clearStaleComments(gc githubClient, log *logrus.Entry, pr *github.PullRequestEvent, prLabels []github.Label, comments []github.IssueComment) error {
-	// Clean up old comments.
-	// If the PR must follow the process and hasn't yet completed the process, don't remove comments.
-	if prMustFollowRelNoteProcess(gc, log, pr, prLabels, false) && !releaseNoteAlreadyAdded(prLabels) {
-		return nil
+func removeOtherLabels(remover func(string) error, label string, labelSet []string, currentLabels []github.Label) error {
+	var errs []error
+	for _, elem := range labelSet {
+		if elem != label && hasLabel(elem, currentLabels) {
+			if err := remover(elem); err != nil {
+				errs = append(errs, err)
+			}
+		}
 	}
-	botName, err := gc.BotName()
-	if err != nil {
-		return err
+	if len(errs) > 0 {
+		return fmt.Errorf("encountered %d errors setting labels: %v", len(errs), errs)
 	}
-	return gc.DeleteStaleComments(
-		pr.Repo.Owner.Login,
-		pr.Repo.Name,
-		pr.Number,
-		comments,
-		func(c github.IssueComment) bool { // isStale function
-			return c.User.Login == botName &&
-				(strings.Contains(c.Body, releaseNoteBody) ||
-					strings.Contains(c.Body, parentReleaseNoteBody) ||
-					strings.Contains(c.Body, deprecatedReleaseNoteBody))
-		},
-	)
+	return nil
 }
 
-func containsNoneCommand(comments []github.IssueComment) bool {
-	for _, c := range comments {
-		if releaseNoteNoneRe.MatchString(c.Body) {
-			return true
-		}
+// noteEdited returns whether pr is an edit of the PR body that changed its
+// release note.
+func noteEdited(cfg *plugins.ReleaseNote, pr *github.PullRequestEvent) bool {
+	if pr.Action != github.PullRequestActionEdited || pr.Changes == nil || pr.Changes.Body == nil {
+		return false
 	}
-	return false
+	return getReleaseNote(cfg, pr.Changes.Body.From) != getReleaseNote(cfg, pr.PullRequest.Body)
 }
 
-func ensureNoRelNoteNeededLabel(gc githubClient, log *logrus.Entry, pr *github.PullRequestEvent, prLabels []github.Label) {
+// flagNoteChange labels and comments on pr if it was approved before now,
+// either with an approving review or the approved label.
+func flagNoteChange(gc githubClient, pr *github.PullRequestEvent, prLabels []github.Label, now time.Time) error {
 	org := pr.Repo.Owner.Login
 	repo := pr.Repo.Name
-	format := "Failed to remove the label %q from %s/%s#%d."
-	if hasLabel(releaseNoteLabelNeeded, prLabels) {
-		if err := gc.RemoveLabel(org, repo, pr.Number, releaseNoteLabelNeeded); err != nil {
-			log.WithError(err).Errorf(format, releaseNoteLabelNeeded, org, repo, pr.Number)
-		}
+	approved, err := approvedBefore(gc, org, repo, pr.Number, now)
+	if err != nil || !approved {
+		return err
 	}
-	if hasLabel(deprecatedReleaseNoteLabelNeeded, prLabels) {
-		if err := gc.RemoveLabel(org, repo, pr.Number, deprecatedReleaseNoteLabelNeeded); err != nil {
-			log.WithError(err).Errorf(format, deprecatedReleaseNoteLabelNeeded, org, repo, pr.Number)
+	if !hasLabel(noteChangedLabel, prLabels) {
+		if err := gc.AddLabel(org, repo, pr.Number, noteChangedLabel); err != nil {
+			return err
 		}
 	}
+	comments, err := gc.ListIssueComments(org, repo, pr.Number)
+	if err != nil {
+		return fmt.Errorf("failed to list comments on %s/%s#%d: %v", org, repo, pr.Number, err)
+	}
+	if containsComment(comments, noteChangedBody) {
+		// Reviewers were already asked to look at the note again.
+		return nil
+	}
+	return gc.CreateComment(org, repo, pr.Number, plugins.FormatResponse(pr.PullRequest.User.Login, noteChangedBody, ""))
 }
 
-// determineReleaseNoteLabel returns the label to be added based on the contents of the 'release-note'
-// section of a PR's body text.
-func determineReleaseNoteLabel(body string) string {
-	composedReleaseNote := strings.ToLower(strings.TrimSpace(getReleaseNote(body)))
-
-	if composedReleaseNote == "" {
-		return releaseNoteLabelNeeded
+func approvedBefore(gc githubClient, org, repo string, number int, t time.Time) (bool, error) {
+	reviews, err := gc.ListReviews(org, repo, number)
+	if err != nil {
+		return false, fmt.Errorf("failed to list reviews on %s/%s#%d: %v", org, repo, number, err)
 	}
-	if composedReleaseNote == noReleaseNoteComment {
-		return releaseNoteNone
+	for _, r := range reviews {
+		if r.State == github.ReviewStateApproved && r.SubmittedAt.Before(t) {
+			return true, nil
+		}
 	}
-	if strings.Contains(composedReleaseNote, actionRequiredNote) {
-		return releaseNoteActionRequired
+	events, err := gc.ListIssueEvents(org, repo, number)
+	if err != nil {
+		return false, fmt.Errorf("failed to list events on %s/%s#%d: %v", org, repo, number, err)
 	}
-	return releaseNote
+	for _, e := range events {
+		if e.Event == github.ListedIssueEventLabeled && e.Label.Name == approvedLabel && e.CreatedAt.Before(t) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-// getReleaseNote returns the release note from a PR body
-// assumes that the PR body followed the PR template
-func getReleaseNote(body string) string {
-	potentialMatch := noteMatcherRE.FindStringSubmatch(body)
-	if potentialMatch == nil {
-		return ""
+// countApprovals returns the number of reviewers whose latest review of the
+// PR approves it. Comments don't change a reviewer's verdict.
+func countApprovals(gc githubClient, org, repo string, number int) (int, error) {
+	reviews, err := gc.ListReviews(org, repo, number)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list reviews on %s/%s#%d: %v", org, repo, number, err)
+	}
+	latest := map[string]string{}
+	for _, r := range reviews {
+		if r.State == github.ReviewStateCommented || r.State == github.ReviewStatePending {
+			continue
+		}
+		latest[github.NormLogin(r.User.Login)] = r.State
+	}
+	approvals := 0
+	for _, state := range latest {
+		if state == github.ReviewStateApproved {
+			approvals++
+		}
 	}
-	return strings.TrimSpace(potentialMatch[1])
+	return approvals, nil
 }
 
-func releaseNoteAlreadyAdded(prLabels []github.Label) bool {
-	return hasLabel(releaseNote, prLabels) ||
-		hasLabel(releaseNoteActionRequired, prLabels) ||
-		hasLabel(releaseNoteNone, prLabels)
+// inStrictMilestone returns whether pr is in one of cfg.StrictMilestones.
+func inStrictMilestone(cfg *plugins.ReleaseNote, pr *github.PullRequest) bool {
+	if pr.Milestone == nil {
+		return false
+	}
+	for _, m := range cfg.StrictMilestones {
+		if m == pr.Milestone.Title {
+			return true
+		}
+	}
+	return false
 }
 
-func prMustFollowRelNoteProcess(gc githubClient, log *logrus.Entry, pr *github.PullRequestEvent, prLabels []github.Label, comment bool) bool {
-	if pr.PullRequest.Base.Ref == "master" {
-		return true
+// inLabelDelay returns whether pr was created less than cfg.LabelDelay
+// before now.
+func inLabelDelay(log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequest, now time.Time) bool {
+	if cfg.LabelDelay == "" {
+		return false
 	}
-
-	parents := getCherrypickParentPRNums(pr.PullRequest.Body)
-	// if it has no parents it needs to follow the release note process
-	if len(parents) == 0 {
-		return true
+	delay, err := time.ParseDuration(cfg.LabelDelay)
+	if err != nil {
+		log.WithError(err).Errorf("Invalid label_delay %q.", cfg.LabelDelay)
+		return false
 	}
+	return now.Sub(pr.CreatedAt) < delay
+}
 
-	org := pr.Repo.Owner.Login
-	repo := pr.Repo.Name
+// reconcileLabels adds label to the PR and removes the other release note
+// labels. If cfg.BatchLabelChanges is set and more than one change is needed,
+// all changes are made with a single ReplaceLabels call, unless current are
+// the possibly stale labels from the event, which would be written back.
+// Otherwise the changes are rolled back if one of them fails.
+func reconcileLabels(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, org, repo string, number int, label string, current []github.Label, stale bool) error {
+	add := !hasLabel(label, current)
+	var remove []string
+	for _, l := range allRNLabels {
+		if l != label && hasLabel(l, current) {
+			remove = append(remove, l)
+		}
+	}
+	if cfg.BatchLabelChanges && !stale && (len(remove) > 1 || add && len(remove) > 0) {
+		var labels []string
+		for _, l := range current {
+			if strings.EqualFold(l.Name, label) || !isManagedLabel(l.Name) {
+				labels = append(labels, l.Name)
+			}
+		}
+		if add {
+			labels = append(labels, label)
+		}
+		if resolvesNeededLabel(cfg, label, current) {
+			labels = append(labels, releaseNoteWasNeeded)
+		}
+		return gc.ReplaceLabels(org, repo, number, labels)
+	}
 
-	var notelessParents []string
-	for _, parent := range parents {
-		// If the parent didn't set a release note, the CP must
-		parentLabels, err := gc.GetIssueLabels(org, repo, parent)
-		if err != nil {
-			log.WithError(err).Errorf("Failed to list labels on PR #%d (parent of #%d).", parent, pr.Number)
-			continue
+	// Add the label before removing the others, so the PR is never left
+	// without one.
+	var added, removed []string
+	if add {
+		if err := gc.AddLabel(org, repo, number, label); err != nil {
+			return err
 		}
-		if !hasLabel(releaseNote, parentLabels) &&
-			!hasLabel(releaseNoteActionRequired, parentLabels) {
-			notelessParents = append(notelessParents, "#"+strconv.Itoa(parent))
+		added = append(added, label)
+	}
+	if resolvesNeededLabel(cfg, label, current) {
+		if err := gc.AddLabel(org, repo, number, releaseNoteWasNeeded); err != nil {
+			log.WithError(err).Errorf("Failed to add %q to %s/%s#%d.", releaseNoteWasNeeded, org, repo, number)
+		} else {
+			added = append(added, releaseNoteWasNeeded)
 		}
 	}
-	if len(notelessParents) == 0 {
-		// All of the parents set the releaseNote or releaseNoteActionRequired label,
-		// so this cherrypick PR needs to do nothing.
-		return false
+	for _, l := range remove {
+		if err := gc.RemoveLabel(org, repo, number, l); err != nil {
+			cause := fmt.Errorf("failed to remove %q from %s/%s#%d: %v", l, org, repo, number, err)
+			return rollbackLabels(gc, org, repo, number, added, removed, cause)
+		}
+		removed = append(removed, l)
 	}
+	return nil
+}
 
-	if comment && !hasLabel(releaseNoteLabelNeeded, prLabels) {
-		comment := plugins.FormatResponse(
-			pr.PullRequest.User.Login,
-			parentReleaseNoteBody,
-			fmt.Sprintf("The following parent PRs have neither the %q nor the %q labels: %s.",
-				releaseNote,
-				releaseNoteActionRequired,
-				strings.Join(notelessParents, ", "),
-			),
-		)
-		if err := gc.CreateComment(org, repo, pr.Number, comment); err != nil {
-			log.WithError(err).Errorf("Error creating comment on %s/%s#%d with comment %q.", org, repo, pr.Number, comment)
+// rollbackLabels re-adds the removed labels and removes the added ones after
+// cause interrupted reconcileLabels, so the PR is left with its previous
+// labels rather than conflicting ones. It returns cause, along with any
+// errors rolling back.
+func rollbackLabels(gc githubClient, org, repo string, number int, added, removed []string, cause error) error {
+	var errs []error
+	for _, l := range removed {
+		if err := gc.AddLabel(org, repo, number, l); err != nil {
+			errs = append(errs, fmt.Errorf("failed to re-add %q: %v", l, err))
 		}
 	}
-	return true
+	for _, l := range added {
+		if err := gc.RemoveLabel(org, repo, number, l); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %q: %v", l, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v; encountered %d errors rolling back the label changes: %v", cause, len(errs), errs)
+	}
+	return fmt.Errorf("%v; rolled back the label changes", cause)
 }
 
-func getCherrypickParentPRNums(body string) []int {
+// isTriggerLabel returns whether label is cfg.RequireOnlyWhenLabeled.
+func isTriggerLabel(cfg *plugins.ReleaseNote, label string) bool {
+	return cfg.RequireOnlyWhenLabeled != "" && strings.EqualFold(label, cfg.RequireOnlyWhenLabeled)
+}
+
+// resolvesNeededLabel returns true if cfg.ResolveInsteadOfRemove is set and
+// switching to label removes a needed label from a PR that doesn't yet
+// carry releaseNoteWasNeeded.
+func resolvesNeededLabel(cfg *plugins.ReleaseNote, label string, current []github.Label) bool {
+	return cfg.ResolveInsteadOfRemove && label != releaseNoteLabelNeeded &&
+		hasNeededLabel(current) && !hasLabel(releaseNoteWasNeeded, current)
+}
+
+func handlePullRequest(pc plugins.PluginClient, pr github.PullRequestEvent) error {
+	cfg := pc.PluginConfig.ReleaseNoteFor(pr.Repo.Owner.Login, pr.Repo.Name)
+	return handlePR(pc.GitHubClient, pc.Logger, cfg, &pr)
+}
+
+func handlePR(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent) error {
+	if names := newLabelNames(cfg); names != nil {
+		translated := *pr
+		translated.Label.Name = names.label(pr.Label.Name)
+		translated.PullRequest.Labels = names.labels(pr.PullRequest.Labels)
+		pr = &translated
+	}
+	if isSkippedBranch(cfg, pr.PullRequest.Base.Ref) {
+		return nil
+	}
+	// Only consider events that (re)open the PR or edit its body or base, new
+	// commits if they need a status, removals of the needed label so it can
+	// be re-added, changes of the label that triggers the process if it must
+	// be labeled, and milestone changes if some milestones are strict.
+	switch pr.Action {
+	case github.PullRequestActionOpened:
+	case github.PullRequestActionReopened:
+		// The body may have been edited while the PR was closed.
+	case github.PullRequestActionEdited:
+		if c := pr.Changes; c != nil {
+			if c.Body == nil && c.Base == nil {
+				// eg. only the title changed, which can't change the decision.
+				return nil
+			}
+			if c.Base != nil {
+				// Whether the PR must follow the full process depends on the
+				// base branch, so it is re-evaluated below.
+				log.Infof("Base of %s/%s#%d changed from %q to %q.", pr.Repo.Owner.Login, pr.Repo.Name, pr.Number, c.Base.Ref.From, pr.PullRequest.Base.Ref)
+			}
+		}
+	case github.PullRequestActionUnlabeled:
+		if pr.Label.Name != releaseNoteLabelNeeded && !isTriggerLabel(cfg, pr.Label.Name) {
+			return nil
+		}
+	case github.PullRequestActionLabeled:
+		if !isTriggerLabel(cfg, pr.Label.Name) {
+			return nil
+		}
+	case github.PullRequestActionMilestoned, github.PullRequestActionDemilestoned:
+		if len(cfg.StrictMilestones) == 0 {
+			return nil
+		}
+	case github.PullRequestActionSynchronize:
+		if !cfg.ReportStatus {
+			return nil
+		}
+	default:
+		return nil
+	}
+	if cfg.DebounceWindow != "" {
+		window, err := time.ParseDuration(cfg.DebounceWindow)
+		if err != nil {
+			log.WithError(err).Errorf("Invalid debounce_window %q.", cfg.DebounceWindow)
+		} else {
+			org, repo := pr.Repo.Owner.Login, pr.Repo.Name
+			superseded := prEvents.schedule(org, repo, pr.Number, window, func() {
+				if err := processPR(gc, log, cfg, pr); err != nil {
+					log.WithError(err).Errorf("Failed to process a debounced %s event for %s/%s#%d.", pr.Action, org, repo, pr.Number)
+				}
+			})
+			if superseded {
+				log.Infof("Skipping an earlier event for %s/%s#%d superseded by a %s event.", org, repo, pr.Number, pr.Action)
+			}
+			return nil
+		}
+	}
+	return processPR(gc, log, cfg, pr)
+}
+
+// processPR applies the release note process to a PR event that handlePR
+// decided to consider.
+func processPR(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent) error {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	gc = wrapClient(gc, log, cfg, org, repo)
+
+	prLabels, err := gc.GetIssueLabels(org, repo, pr.Number)
+	staleLabels := false
+	if err != nil {
+		if !cfg.FallbackToEventLabels || pr.PullRequest.Labels == nil {
+			return fmt.Errorf("failed to list labels on PR #%d. err: %v", pr.Number, err)
+		}
+		log.WithError(err).Warnf("Failed to list labels on %s/%s#%d, using the possibly stale labels from the event.", org, repo, pr.Number)
+		prLabels = pr.PullRequest.Labels
+		staleLabels = true
+	}
+	recorder := &labelRecorder{githubClient: gc, number: pr.Number}
+	gc = recorder
+	defer func() {
+		log.WithFields(logrus.Fields{
+			"labels_before": managedLabels(prLabels),
+			"labels_after":  recorder.apply(managedLabels(prLabels)),
+		}).Info("Reconciled release note labels.")
+	}()
+
+	// Nothing is decided or posted during the label delay, so that the first
+	// event after it doesn't post the same comments again.
+	if inLabelDelay(log, cfg, &pr.PullRequest, time.Now()) {
+		log.Infof("Deferring release note labels on %s/%s#%d until the label delay has passed.", org, repo, pr.Number)
+		if cfg.ReportStatus {
+			status := github.Status{State: github.StatusPending, Context: statusContext, Description: "waiting for the label delay"}
+			if err := gc.CreateStatus(org, repo, pr.PullRequest.Head.SHA, status); err != nil {
+				log.WithError(err).Errorf("Failed to set the %q status on %s/%s#%d.", statusContext, org, repo, pr.Number)
+			}
+		}
+		return nil
+	}
+	if cfg.FlagNoteChangesAfterApproval && noteEdited(cfg, pr) {
+		if err := flagNoteChange(gc, pr, prLabels, time.Now()); err != nil {
+			log.WithError(err).Errorf("Failed to check for approvals of %s/%s#%d.", org, repo, pr.Number)
+		}
+	}
+
+	labelToAdd, comments, err := decideLabel(gc, log, cfg, pr, prLabels, true)
+	if err != nil {
+		return err
+	}
+	defer recordDecision(log, cfg, org, repo, pr.Number, labelToAdd)
+	if cfg.ReportStatus {
+		if err := gc.CreateStatus(org, repo, pr.PullRequest.Head.SHA, noteStatus(cfg, labelToAdd)); err != nil {
+			log.WithError(err).Errorf("Failed to set the %q status on %s/%s#%d.", statusContext, org, repo, pr.Number)
+		}
+	}
+	if labelToAdd == "" {
+		ensureNoRelNoteNeededLabel(gc, log, cfg, pr, prLabels)
+		if cfg.LabelInheritedNotes && !hasLabel(releaseNoteInherited, prLabels) && satisfiedByParents(gc, log, cfg, pr, prLabels) {
+			if err := gc.AddLabel(org, repo, pr.Number, releaseNoteInherited); err != nil {
+				log.WithError(err).Errorf("Failed to add the label %q to %s/%s#%d.", releaseNoteInherited, org, repo, pr.Number)
+			}
+		}
+		return clearStaleComments(gc, log, cfg, pr, prLabels, nil)
+	}
+	if hasLabel(releaseNoteInherited, prLabels) {
+		if err := gc.RemoveLabel(org, repo, pr.Number, releaseNoteInherited); err != nil {
+			log.WithError(err).Errorf("Failed to remove the label %q from %s/%s#%d.", releaseNoteInherited, org, repo, pr.Number)
+		}
+	}
+	welcome := shouldWelcome(gc, log, cfg, pr, comments)
+	if labelToAdd == releaseNoteLabelNeeded {
+		recreate := false
+		if cfg.StickyCommentRecreate && !cfg.UseReviewForGuidance && hasNeededLabel(prLabels) {
+			present, err := ensureSingleGuidance(gc, cfg, org, repo, pr.Number, comments)
+			if err != nil {
+				log.WithError(err).Errorf("Failed to check the release note guidance on %s/%s#%d.", org, repo, pr.Number)
+			}
+			recreate = err == nil && !present
+		}
+		// The author was already told when the needed label was first added,
+		// unless the comment telling them has been deleted since.
+		if (!hasNeededLabel(prLabels) || recreate) && pr.Action != github.PullRequestActionUnlabeled {
+			body := releaseNoteBody(cfg)
+			if welcome {
+				body = welcomeBody + "\n\n" + body
+			}
+			comment := plugins.FormatResponse(pr.PullRequest.User.Login, body, releaseNoteSuffix(cfg))
+			if len(cfg.MentionOnNeeded) > 0 {
+				comment += "\n" + fmt.Sprintf(unresolvedEditsFormat, 0)
+			}
+			if err := postGuidance(gc, cfg, org, repo, pr.Number, comment); err != nil {
+				log.WithError(err).Errorf("Failed to comment on %s/%s#%d with comment %q.", org, repo, pr.Number, comment)
+			}
+		} else if len(cfg.MentionOnNeeded) > 0 && pr.Action == github.PullRequestActionEdited {
+			if err := escalateUnresolvedNote(gc, cfg, pr, comments); err != nil {
+				log.WithError(err).Errorf("Failed to escalate the missing release note on %s/%s#%d.", org, repo, pr.Number)
+			}
+		}
+		if isReleaseBranch(cfg, pr.PullRequest.Base.Ref) && len(getCherrypickParentPRNums(pr.PullRequest.Body)) == 0 && !containsComment(comments, missingParentBody) {
+			// The cherry-pick reference may have been edited out.
+			comment(gc, log, pr, missingParentBody, "")
+		}
+		if actionRequiredWithoutDelimiter(cfg, pr.PullRequest.Body, prLabels) && !containsComment(comments, actionDelimiterBody(cfg)) {
+			comment(gc, log, pr, actionDelimiterBody(cfg), releaseNoteSuffix(cfg))
+		}
+		if inStrictMilestone(cfg, &pr.PullRequest) && determineReleaseNoteLabel(cfg, pr.PullRequest.Body) == releaseNoteNone && !containsComment(comments, strictMilestoneBody) {
+			comment(gc, log, pr, strictMilestoneBody, "")
+		}
+		if cfg.EmptyActionRequiredBehavior == emptyActionBlock && emptyActionRequired(cfg, pr.PullRequest.Body) && !containsComment(comments, emptyActionRequiredBody) {
+			comment(gc, log, pr, emptyActionRequiredBody, releaseNoteSuffix(cfg))
+		}
+		if cfg.BlockReferenceOnlyNotes && referenceOnlyNote(getReleaseNote(cfg, pr.PullRequest.Body)) && !containsComment(comments, referenceOnlyBody) {
+			comment(gc, log, pr, referenceOnlyBody, "")
+		}
+		if hasSuggestionFenceNote(pr.PullRequest.Body) && !containsComment(comments, suggestionFenceBody) {
+			comment(gc, log, pr, suggestionFenceBody, releaseNoteSuffix(cfg))
+		} else if hasMisfencedNote(cfg, pr.PullRequest.Body) && !containsComment(comments, misfencedNoteBody) {
+			comment(gc, log, pr, misfencedNoteBody, releaseNoteSuffix(cfg))
+		}
+	} else {
+		//going to apply some other release-note-label
+		// reconcileLabels removes the needed labels with the others.
+		dismissNeededGuidance(gc, log, cfg, pr, prLabels)
+		if welcome {
+			comment(gc, log, pr, welcomeBody, "")
+		}
+	}
+
+	if err := reconcileLabels(gc, log, cfg, org, repo, pr.Number, labelToAdd, prLabels, staleLabels); err != nil {
+		return err
+	}
+
+	if cfg.EmptyActionRequiredBehavior == emptyActionLabel {
+		rereview := labelToAdd == releaseNoteActionRequired && emptyActionRequired(cfg, pr.PullRequest.Body)
+		if rereview && !hasLabel(needsRereviewLabel, prLabels) {
+			if err := gc.AddLabel(org, repo, pr.Number, needsRereviewLabel); err != nil {
+				log.WithError(err).Errorf("Failed to add the label %q to %s/%s#%d.", needsRereviewLabel, org, repo, pr.Number)
+			}
+		} else if !rereview && hasLabel(needsRereviewLabel, prLabels) {
+			if err := gc.RemoveLabel(org, repo, pr.Number, needsRereviewLabel); err != nil {
+				log.WithError(err).Errorf("Failed to remove the label %q from %s/%s#%d.", needsRereviewLabel, org, repo, pr.Number)
+			}
+		}
+	}
+
+	if cfg.ActionRequiredReviewTeamID != 0 && labelToAdd == releaseNoteActionRequired && !hasLabel(releaseNoteActionRequired, prLabels) {
+		if err := requestActionReview(gc, cfg, pr); err != nil {
+			log.WithError(err).Errorf("Failed to request a review from team %d on %s/%s#%d.", cfg.ActionRequiredReviewTeamID, org, repo, pr.Number)
+		}
+	}
+
+	if len(cfg.SecurityNotePatterns) > 0 {
+		security := mentionsSecurity(cfg, getReleaseNote(cfg, pr.PullRequest.Body))
+		if security && !hasLabel(releaseNoteSecurity, prLabels) {
+			if err := gc.AddLabel(org, repo, pr.Number, releaseNoteSecurity); err != nil {
+				log.WithError(err).Errorf("Failed to add the label %q to %s/%s#%d.", releaseNoteSecurity, org, repo, pr.Number)
+			}
+		} else if !security && hasLabel(releaseNoteSecurity, prLabels) {
+			if err := gc.RemoveLabel(org, repo, pr.Number, releaseNoteSecurity); err != nil {
+				log.WithError(err).Errorf("Failed to remove the label %q from %s/%s#%d.", releaseNoteSecurity, org, repo, pr.Number)
+			}
+		}
+	}
+
+	if cfg.AreaNotes {
+		for _, area := range noteAreas(cfg, pr.PullRequest.Body) {
+			if hasLabel(area, prLabels) {
+				continue
+			}
+			if err := gc.AddLabel(org, repo, pr.Number, area); err != nil {
+				log.WithError(err).Errorf("Failed to add the label %q to %s/%s#%d.", area, org, repo, pr.Number)
+			}
+		}
+	}
+
+	var s suggester = &commentSuggester{gc: gc, pr: pr}
+	collector := &suggestionCollector{}
+	if cfg.ConsolidateSuggestions {
+		s = collector
+	}
+	note := getReleaseNote(cfg, pr.PullRequest.Body)
+	if cfg.WarnRelativeLinks {
+		if err := suggestAbsoluteLinks(s, note); err != nil {
+			log.WithError(err).Errorf("Failed to check release note links on %s/%s#%d.", org, repo, pr.Number)
+		}
+	}
+
+	if cfg.WarnLinkPlaceholders {
+		if err := suggestLinkTargets(s, note); err != nil {
+			log.WithError(err).Errorf("Failed to check release note links on %s/%s#%d.", org, repo, pr.Number)
+		}
+	}
+
+	if cfg.WarnMalformedMarkdown {
+		if err := suggestBalancedMarkdown(s, note); err != nil {
+			log.WithError(err).Errorf("Failed to check the release note markdown on %s/%s#%d.", org, repo, pr.Number)
+		}
+	}
+
+	if cfg.WarnVerboseNotes {
+		if err := suggestConciseNote(s, cfg, pr, note); err != nil {
+			log.WithError(err).Errorf("Failed to compare the release note to the description of %s/%s#%d.", org, repo, pr.Number)
+		}
+	}
+
+	if cfg.ExpectedNoteLanguage != "" {
+		if err := suggestNoteLanguage(s, log, cfg, note); err != nil {
+			log.WithError(err).Errorf("Failed to check the release note language on %s/%s#%d.", org, repo, pr.Number)
+		}
+	}
+
+	if len(cfg.ForbiddenFlagPatterns) > 0 {
+		if err := warnForbiddenFlags(s, cfg, note); err != nil {
+			log.WithError(err).Errorf("Failed to check release note flags on %s/%s#%d.", org, repo, pr.Number)
+		}
+	}
+
+	if cfg.ConsolidateSuggestions {
+		if err := collector.post(gc, pr); err != nil {
+			log.WithError(err).Errorf("Failed to post release note suggestions on %s/%s#%d.", org, repo, pr.Number)
+		}
+	}
+
+	return clearStaleComments(gc, log, cfg, pr, prLabels, comments)
+}
+
+// comment posts body to pr, addressed to its author, and logs any failure.
+// Only guidance for PRs that need a release note has suffix set to
+// releaseNoteSuffix, since it lists the labels the PR needs.
+func comment(gc githubClient, log *logrus.Entry, pr *github.PullRequestEvent, body, suffix string) {
+	text := plugins.FormatResponse(pr.PullRequest.User.Login, body, suffix)
+	if err := gc.CreateComment(pr.Repo.Owner.Login, pr.Repo.Name, pr.Number, text); err != nil {
+		log.WithError(err).Errorf("Failed to comment on %s/%s#%d with comment %q.", pr.Repo.Owner.Login, pr.Repo.Name, pr.Number, text)
+	}
+}
+
+// decideLabel determines the release note label a PR should have, or returns
+// an empty label if the PR doesn't need to follow the release note process.
+// If the PR's comments had to be listed they are returned too. If comment is
+// true the author may be told why a cherry-pick needs a release note.
+func decideLabel(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, prLabels []github.Label, comment bool) (string, []github.IssueComment, error) {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+
+	if cfg.RequireOnlyWhenLabeled != "" && !hasLabel(cfg.RequireOnlyWhenLabeled, prLabels) {
+		return "", nil, nil
+	}
+
+	var comments []github.IssueComment
+	// autoNone is set if the PR is exempt from needing a release note rather
+	// than having one.
+	autoNone := false
+	var labelToAdd string
+	if cfg.CacheClassifications {
+		labelToAdd = bodyLabels.label(cfg, org, repo, pr.Number, pr.PullRequest.Body, func() string {
+			return determineReleaseNoteLabel(cfg, pr.PullRequest.Body)
+		})
+	} else {
+		labelToAdd = determineReleaseNoteLabel(cfg, pr.PullRequest.Body)
+	}
+	if labelToAdd == releaseNoteLabelNeeded && cfg.NoteFromFile != "" {
+		labelToAdd = fileNoteLabel(gc, log, cfg, pr)
+	}
+	if labelToAdd == releaseNoteLabelNeeded && cfg.InheritDependsOnNote {
+		labelToAdd = dependencyNoteLabel(gc, log, cfg, org, repo, pr.PullRequest.Body)
+	}
+	if labelToAdd == releaseNoteLabelNeeded && cfg.InheritFromUpstreamRef {
+		labelToAdd = upstreamNoteLabel(gc, log, cfg, pr.PullRequest.Body)
+	}
+	if labelToAdd == releaseNoteLabelNeeded && len(cfg.BumpNotes) > 0 {
+		if note, ok := bumpNote(log, cfg, &pr.PullRequest); ok {
+			if comment {
+				recordBumpNote(gc, log, pr, note)
+			}
+			labelToAdd = noteLabel(cfg, note)
+		}
+	}
+	if labelToAdd == releaseNoteLabelNeeded && cfg.ConventionalCommitTitles {
+		labelToAdd = conventionalTitleLabel(cfg, &pr.PullRequest)
+	}
+	if labelToAdd == releaseNoteLabelNeeded && !hasTemplate(cfg, pr.PullRequest.Body) {
+		switch cfg.NoTemplateBehavior {
+		case noTemplateAutoNone:
+			if !changesNotePaths(gc, log, cfg, pr) {
+				labelToAdd = releaseNoteNone
+				autoNone = true
+			}
+		case noTemplateIgnore:
+			if !hasAnyLabel(cfg.ForceNeededLabels, prLabels) && !changesNotePaths(gc, log, cfg, pr) {
+				return "", nil, nil
+			}
+		}
+	}
+	if labelToAdd == releaseNoteLabelNeeded && cfg.RevertNoteBehavior == revertAutoNone && strings.HasPrefix(pr.PullRequest.Title, revertTitlePrefix) {
+		if !changesNotePaths(gc, log, cfg, pr) {
+			labelToAdd = releaseNoteNone
+			autoNone = true
+		}
+	}
+	if labelToAdd == releaseNote && hasAnyLabel(cfg.ActionRequiredLabels, prLabels) {
+		labelToAdd = releaseNoteActionRequired
+	}
+	if labelToAdd == releaseNoteActionRequired && actionRequiredWithoutDelimiter(cfg, pr.PullRequest.Body, prLabels) {
+		labelToAdd = releaseNoteLabelNeeded
+	}
+	if labelToAdd == releaseNoteActionRequired && cfg.EmptyActionRequiredBehavior == emptyActionBlock && emptyActionRequired(cfg, pr.PullRequest.Body) {
+		labelToAdd = releaseNoteLabelNeeded
+	}
+	if labelToAdd == releaseNoteLabelNeeded {
+		if !prMustFollowRelNoteProcess(gc, log, cfg, pr, prLabels, comment) {
+			// The cherry-pick's parents all have release notes.
+			if cfg.AutoPopulateCherryPickNote {
+				if label := populateFromParents(gc, log, cfg, pr, comment); label != "" {
+					return label, nil, nil
+				}
+			}
+			return "", nil, nil
+		}
+		// If /release-note-none has been left on PR then pretend the release-note body is "NONE" instead of empty.
+		// The latest command wins if several were left.
+		var err error
+		comments, err = gc.ListIssueComments(org, repo, pr.Number)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to list comments on %s/%s#%d. err: %v", org, repo, pr.Number, err)
+		}
+		label, err := latestCommandLabel(gc, cfg, comments)
+		if err != nil {
+			return "", nil, err
+		}
+		if label != "" {
+			labelToAdd = label
+		} else if !recordsNoneCommands(cfg) && hasLabel(releaseNoteNone, prLabels) {
+			// Keep a none label that a member already applied.
+			labelToAdd = releaseNoteNone
+		}
+	}
+	if labelToAdd == releaseNoteLabelNeeded && cfg.RequireNoteOverChangedLines > 0 {
+		// Small PRs don't need a release note.
+		lines, err := changedLines(gc, org, repo, pr.Number)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to get changes for %s/%s#%d.", org, repo, pr.Number)
+		} else if lines <= cfg.RequireNoteOverChangedLines && !changesNotePaths(gc, log, cfg, pr) {
+			labelToAdd = releaseNoteNone
+			autoNone = true
+		}
+	}
+	if autoNone && cfg.AutoNoneMinApprovals > 0 {
+		approvals, err := countApprovals(gc, org, repo, pr.Number)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to count approvals on %s/%s#%d.", org, repo, pr.Number)
+			labelToAdd = releaseNoteLabelNeeded
+		} else if approvals < cfg.AutoNoneMinApprovals {
+			labelToAdd = releaseNoteLabelNeeded
+		}
+	}
+	if labelToAdd == releaseNoteNone && !hasLabel(releaseNoteNone, prLabels) && cfg.ManualRemovalCooldown != "" {
+		// Don't fight a human who just removed the label.
+		removed, err := recentlyRemovedByHuman(gc, cfg, org, repo, pr.Number, releaseNoteNone, time.Now())
+		if err != nil {
+			log.WithError(err).Errorf("Failed to check for manual removal of %q on %s/%s#%d.", releaseNoteNone, org, repo, pr.Number)
+		} else if removed {
+			labelToAdd = releaseNoteLabelNeeded
+		}
+	}
+	if (labelToAdd == releaseNote || labelToAdd == releaseNoteActionRequired) && cfg.HardEnforceFlags && len(forbiddenFlags(cfg, getReleaseNote(cfg, pr.PullRequest.Body))) > 0 {
+		labelToAdd = releaseNoteLabelNeeded
+	}
+	if (labelToAdd == releaseNote || labelToAdd == releaseNoteActionRequired) && cfg.BlockReferenceOnlyNotes && referenceOnlyNote(getReleaseNote(cfg, pr.PullRequest.Body)) {
+		labelToAdd = releaseNoteLabelNeeded
+	}
+	if labelToAdd == releaseNoteNone && inStrictMilestone(cfg, &pr.PullRequest) {
+		labelToAdd = releaseNoteLabelNeeded
+	}
+	if labelToAdd == releaseNoteNone && hasAnyLabel(cfg.ForceNeededLabels, prLabels) {
+		// Reviewers asked for a real release note.
+		labelToAdd = releaseNoteLabelNeeded
+	}
+	if labelToAdd == releaseNoteLabelNeeded && comments == nil {
+		// The comments are needed to avoid repeating guidance.
+		var err error
+		comments, err = gc.ListIssueComments(org, repo, pr.Number)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to list comments on %s/%s#%d. err: %v", org, repo, pr.Number, err)
+		}
+	}
+	return labelToAdd, comments, nil
+}
+
+// postGuidance tells the author how to follow the release note process,
+// either in a comment or in a review requesting changes.
+func postGuidance(gc githubClient, cfg *plugins.ReleaseNote, org, repo string, number int, comment string) error {
+	if cfg.UseReviewForGuidance {
+		return gc.CreateReview(org, repo, number, github.DraftReview{
+			Body:   comment,
+			Action: github.RequestChanges,
+		})
+	}
+	return gc.CreateComment(org, repo, number, comment)
+}
+
+// ensureSingleGuidance deletes all but the first of the guidance comments the
+// bot posted on a PR, and returns whether there is one.
+func ensureSingleGuidance(gc githubClient, cfg *plugins.ReleaseNote, org, repo string, number int, comments []github.IssueComment) (bool, error) {
+	botName, err := gc.BotName()
+	if err != nil {
+		return false, err
+	}
+	body := releaseNoteBody(cfg)
+	isGuidance := func(c github.IssueComment) bool {
+		return github.NormLogin(c.User.Login) == github.NormLogin(botName) && strings.Contains(c.Body, body)
+	}
+	first := -1
+	for i, c := range comments {
+		if isGuidance(c) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return false, nil
+	}
+	return true, gc.DeleteStaleComments(org, repo, number, comments[first+1:], isGuidance)
+}
+
+// dismissGuidanceReviews dismisses the reviews requesting changes that were
+// created by postGuidance.
+func dismissGuidanceReviews(gc githubClient, cfg *plugins.ReleaseNote, org, repo string, number int) error {
+	botName, err := gc.BotName()
+	if err != nil {
+		return err
+	}
+	reviews, err := gc.ListReviews(org, repo, number)
+	if err != nil {
+		return err
+	}
+	for _, r := range reviews {
+		if r.User.Login != botName || r.State != github.ReviewStateChangesRequested {
+			continue
+		}
+		if !strings.Contains(r.Body, releaseNoteBody(cfg)) && !isParentComment(cfg, r.Body) {
+			continue
+		}
+		if err := gc.DismissReview(org, repo, number, r.ID, "The release note process has been followed."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// suggestAbsoluteLinks suggests absolute URLs if the release note contains
+// relative links, and withdraws the suggestion once they are gone. It never
+// changes labels.
+func suggestAbsoluteLinks(s suggester, note string) error {
+	links := relativeLinks(note)
+	if len(links) == 0 {
+		return s.suggest(relativeLinkBody, "")
+	}
+	return s.suggest(relativeLinkBody, fmt.Sprintf("The following links are relative: `%s`.", strings.Join(links, "`, `")))
+}
+
+// requestActionReview requests a review from the members of
+// cfg.ActionRequiredReviewTeamID who aren't the author or already requested.
+// It's only called when the PR becomes action required, so edits to a note
+// that already is don't request reviews again.
+func requestActionReview(gc githubClient, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent) error {
+	members, err := gc.ListTeamMembers(cfg.ActionRequiredReviewTeamID)
+	if err != nil {
+		return err
+	}
+	requested := map[string]bool{github.NormLogin(pr.PullRequest.User.Login): true}
+	for _, reviewer := range pr.PullRequest.RequestedReviewers {
+		requested[github.NormLogin(reviewer.Login)] = true
+	}
+	var logins []string
+	for _, member := range members {
+		if !requested[github.NormLogin(member.Login)] {
+			logins = append(logins, member.Login)
+		}
+	}
+	if len(logins) == 0 {
+		return nil
+	}
+	return gc.RequestReview(pr.Repo.Owner.Login, pr.Repo.Name, pr.Number, logins)
+}
+
+// suggestLinkTargets suggests filling in link targets if the release note
+// contains placeholders, eg. [docs](URL), and withdraws the suggestion once
+// they are gone. It never changes labels.
+func suggestLinkTargets(s suggester, note string) error {
+	placeholders := linkPlaceholderRe.FindAllString(note, -1)
+	if len(placeholders) == 0 {
+		return s.suggest(linkPlaceholderBody, "")
+	}
+	return s.suggest(linkPlaceholderBody, fmt.Sprintf("The following links have placeholder targets: `%s`.", strings.Join(placeholders, "`, `")))
+}
+
+// suggestBalancedMarkdown suggests checking the formatting of the release
+// note if its markdown is unbalanced, and withdraws the suggestion once it
+// isn't. It never changes labels.
+func suggestBalancedMarkdown(s suggester, note string) error {
+	problems := markdownProblems(note)
+	if len(problems) == 0 {
+		return s.suggest(malformedMarkdownBody, "")
+	}
+	return s.suggest(malformedMarkdownBody, fmt.Sprintf("The release note has: %s.", strings.Join(problems, ", ")))
+}
+
+// suggestConciseNote suggests a shorter release note if it repeats the PR
+// description, and withdraws the suggestion once it doesn't. It never changes
+// labels.
+func suggestConciseNote(s suggester, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, note string) error {
+	description := regexesFor(cfg).noteMatcher.ReplaceAllString(pr.PullRequest.Body, "")
+	if !repeatsDescription(note, description) {
+		return s.suggest(verboseNoteBody, "")
+	}
+	return s.suggest(verboseNoteBody, "Most of the words of the PR description also appear in the release note.")
+}
+
+// repeatsDescription returns whether most of the distinct words of a
+// description of at least minDescriptionWords words also appear in note.
+func repeatsDescription(note, description string) bool {
+	const minDescriptionWords = 10
+	descWords := wordSet(htmlCommentRe.ReplaceAllString(description, ""))
+	if len(descWords) < minDescriptionWords {
+		return false
+	}
+	noteWords := wordSet(note)
+	common := 0
+	for w := range descWords {
+		if noteWords[w] {
+			common++
+		}
+	}
+	return float64(common) >= 0.8*float64(len(descWords))
+}
+
+func wordSet(s string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[w] = true
+	}
+	return words
+}
+
+// warnForbiddenFlags warns about the release note if it matches any of
+// cfg.ForbiddenFlagPatterns, and withdraws the warning once it doesn't.
+func warnForbiddenFlags(s suggester, cfg *plugins.ReleaseNote, note string) error {
+	flags := forbiddenFlags(cfg, note)
+	if len(flags) == 0 {
+		return s.suggest(forbiddenFlagBody, "")
+	}
+	reason := fmt.Sprintf("The following flags are forbidden: `%s`.", strings.Join(flags, "`, `"))
+	if cfg.HardEnforceFlags {
+		reason += " The PR is blocked until they are removed."
+	}
+	return s.suggest(forbiddenFlagBody, reason)
+}
+
+// forbiddenFlags returns the parts of note matching cfg.ForbiddenFlagPatterns.
+func forbiddenFlags(cfg *plugins.ReleaseNote, note string) []string {
+	var out []string
+	for _, re := range regexesFor(cfg).forbiddenFlags {
+		if re != nil {
+			out = append(out, re.FindAllString(note, -1)...)
+		}
+	}
+	return out
+}
+
+// mentionsSecurity returns whether note matches any of
+// cfg.SecurityNotePatterns.
+func mentionsSecurity(cfg *plugins.ReleaseNote, note string) bool {
+	for _, re := range regexesFor(cfg).securityNotes {
+		if re != nil && re.MatchString(note) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestNoteLanguage suggests another language if the release note appears
+// not to be in cfg.ExpectedNoteLanguage, and withdraws the suggestion once it
+// is. The check is a best-effort heuristic, so it never changes labels.
+func suggestNoteLanguage(s suggester, log *logrus.Entry, cfg *plugins.ReleaseNote, note string) error {
+	scripts, ok := languageScripts[cfg.ExpectedNoteLanguage]
+	if !ok {
+		log.Errorf("Unsupported expected_note_language %q.", cfg.ExpectedNoteLanguage)
+		return nil
+	}
+	if !unexpectedScript(note, scripts) {
+		return s.suggest(noteLanguageBody, "")
+	}
+	return s.suggest(noteLanguageBody, fmt.Sprintf("The primary language of this repo is %q.", cfg.ExpectedNoteLanguage))
+}
+
+// unexpectedScript returns whether most letters in note are in none of
+// scripts.
+func unexpectedScript(note string, scripts []*unicode.RangeTable) bool {
+	var letters, unexpected int
+	for _, r := range note {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if !unicode.In(r, scripts...) {
+			unexpected++
+		}
+	}
+	return unexpected*2 > letters
+}
+
+// relativeLinks returns the targets of all markdown links in the note that
+// are not absolute URLs.
+func relativeLinks(note string) []string {
+	var out []string
+	for _, match := range markdownLinkRe.FindAllStringSubmatch(note, -1) {
+		u, err := url.Parse(match[1])
+		if err != nil || (!u.IsAbs() && u.Host == "") {
+			out = append(out, match[1])
+		}
+	}
+	return out
+}
+
+// readOnlyClient is a githubClient that logs label changes instead of making
+// them.
+type readOnlyClient struct {
+	githubClient
+	log *logrus.Entry
+}
+
+func (c *readOnlyClient) AddLabel(org, repo string, number int, label string) error {
+	c.log.Infof("Read-only repo, not adding the label %q to %s/%s#%d.", label, org, repo, number)
+	return nil
+}
+
+func (c *readOnlyClient) RemoveLabel(org, repo string, number int, label string) error {
+	c.log.Infof("Read-only repo, not removing the label %q from %s/%s#%d.", label, org, repo, number)
+	return nil
+}
+
+func (c *readOnlyClient) ReplaceLabels(org, repo string, number int, labels []string) error {
+	c.log.Infof("Read-only repo, not replacing the labels on %s/%s#%d with %q.", org, repo, number, labels)
+	return nil
+}
+
+// dedupClient is a githubClient that doesn't post a comment if the bot
+// already posted an identical one within window, eg. because the same
+// webhook was delivered twice.
+type dedupClient struct {
+	githubClient
+	log    *logrus.Entry
+	window time.Duration
+}
+
+func (c *dedupClient) CreateComment(org, repo string, number int, comment string) error {
+	botName, err := c.BotName()
+	if err != nil {
+		return err
+	}
+	comments, err := c.ListIssueComments(org, repo, number)
+	if err != nil {
+		return err
+	}
+	for _, ic := range comments {
+		if ic.Body == comment && github.NormLogin(ic.User.Login) == github.NormLogin(botName) && time.Since(ic.CreatedAt) < c.window {
+			c.log.Infof("Not posting a duplicate comment on %s/%s#%d.", org, repo, number)
+			return nil
+		}
+	}
+	return c.githubClient.CreateComment(org, repo, number, comment)
+}
+
+// commentLimitClient is a githubClient that stops posting comments on a PR
+// once the bot has posted max of them, eg. because of an edit loop. Records
+// of accepted commands are always posted and don't count towards max, since
+// later events decide labels from them.
+type commentLimitClient struct {
+	githubClient
+	log *logrus.Entry
+	max int
+}
+
+func (c *commentLimitClient) CreateComment(org, repo string, number int, comment string) error {
+	if strings.HasPrefix(comment, acceptedNoneMarker) || strings.HasPrefix(comment, recordedNoteMarker) {
+		return c.githubClient.CreateComment(org, repo, number, comment)
+	}
+	botName, err := c.BotName()
+	if err != nil {
+		return err
+	}
+	comments, err := c.ListIssueComments(org, repo, number)
+	if err != nil {
+		return err
+	}
+	posted := 0
+	for _, ic := range comments {
+		if github.NormLogin(ic.User.Login) == github.NormLogin(botName) && strings.Contains(ic.Body, botCommentMarker) {
+			posted++
+		}
+	}
+	if posted >= c.max {
+		c.log.Infof("Not commenting on %s/%s#%d, which already has %d release note comments.", org, repo, number, posted)
+		return nil
+	}
+	return c.githubClient.CreateComment(org, repo, number, comment+"\n"+botCommentMarker)
+}
+
+// wrapClient wraps gc according to the repo's config.
+func wrapClient(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, org, repo string) githubClient {
+	gc = withLabelNames(gc, cfg)
+	if isReadOnlyRepo(cfg, org, repo) {
+		gc = &readOnlyClient{githubClient: gc, log: log}
+	}
+	if cfg.DuplicateCommentWindow != "" {
+		window, err := time.ParseDuration(cfg.DuplicateCommentWindow)
+		if err != nil {
+			log.WithError(err).Errorf("Invalid duplicate_comment_window %q.", cfg.DuplicateCommentWindow)
+		} else {
+			gc = &dedupClient{githubClient: gc, log: log, window: window}
+		}
+	}
+	if cfg.MaxBotComments > 0 {
+		gc = &commentLimitClient{githubClient: gc, log: log, max: cfg.MaxBotComments}
+	}
+	return gc
+}
+
+func isReadOnlyRepo(cfg *plugins.ReleaseNote, org, repo string) bool {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for _, r := range cfg.ReadOnlyRepos {
+		if r == org || r == fullName {
+			return true
+		}
+	}
+	return false
+}
+
+// labelRecorder is a githubClient that records the labels successfully added
+// to and removed from a single PR.
+type labelRecorder struct {
+	githubClient
+	number         int
+	added, removed []string
+	// replaced is set once the managed labels have been replaced by added.
+	replaced bool
+}
+
+func (r *labelRecorder) AddLabel(org, repo string, number int, label string) error {
+	err := r.githubClient.AddLabel(org, repo, number, label)
+	if err == nil && number == r.number {
+		r.added = append(r.added, label)
+	}
+	return err
+}
+
+func (r *labelRecorder) RemoveLabel(org, repo string, number int, label string) error {
+	err := r.githubClient.RemoveLabel(org, repo, number, label)
+	if err == nil && number == r.number {
+		r.removed = append(r.removed, label)
+	}
+	return err
+}
+
+func (r *labelRecorder) ReplaceLabels(org, repo string, number int, labels []string) error {
+	err := r.githubClient.ReplaceLabels(org, repo, number, labels)
+	if err == nil && number == r.number {
+		r.replaced = true
+		r.added, r.removed = nil, nil
+		for _, l := range labels {
+			if isManagedLabel(l) {
+				r.added = append(r.added, l)
+			}
+		}
+	}
+	return err
+}
+
+// apply returns the sorted label set that results from applying the recorded
+// changes to labels.
+func (r *labelRecorder) apply(labels []string) []string {
+	set := map[string]bool{}
+	if !r.replaced {
+		for _, l := range labels {
+			set[l] = true
+		}
+	}
+	for _, l := range r.removed {
+		delete(set, l)
+	}
+	for _, l := range r.added {
+		set[l] = true
+	}
+	out := []string{}
+	for l := range set {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// managedLabels returns the sorted subset of labels managed by this plugin.
+func managedLabels(labels []github.Label) []string {
+	out := []string{}
+	for _, l := range allRNLabels {
+		if hasLabel(l, labels) {
+			out = append(out, l)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func clearStaleComments(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, prLabels []github.Label, comments []github.IssueComment) error {
+	if cfg.KeepStaleComments {
+		return nil
+	}
+	// Clean up old comments.
+	// If the PR must follow the process and hasn't yet completed the process, don't remove comments.
+	if prMustFollowRelNoteProcess(gc, log, cfg, pr, prLabels, false) && !releaseNoteAlreadyAdded(prLabels) {
+		return nil
+	}
+	botName, err := gc.BotName()
+	if err != nil {
+		return err
+	}
+	body := releaseNoteBody(cfg)
+	return gc.DeleteStaleComments(
+		pr.Repo.Owner.Login,
+		pr.Repo.Name,
+		pr.Number,
+		comments,
+		func(c github.IssueComment) bool { // isStale function
+			return c.User.Login == botName &&
+				(strings.Contains(c.Body, body) ||
+					isParentComment(cfg, c.Body) ||
+					strings.Contains(c.Body, suggestionFenceBody) ||
+					strings.Contains(c.Body, misfencedNoteBody) ||
+					strings.Contains(c.Body, missingParentBody) ||
+					strings.Contains(c.Body, strictMilestoneBody) ||
+					strings.Contains(c.Body, emptyActionRequiredBody) ||
+					strings.Contains(c.Body, actionDelimiterPrefix) ||
+					strings.Contains(c.Body, escalationBody) ||
+					strings.Contains(c.Body, deprecatedReleaseNoteBody))
+		},
+	)
+}
+
+// fileNoteLabel returns the label for the release note in the first file
+// changed by pr that matches cfg.NoteFromFile, or releaseNoteLabelNeeded if
+// there is none. A file without a release note block is the note itself.
+func fileNoteLabel(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent) string {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	changes, err := gc.GetPullRequestChanges(org, repo, pr.Number)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get changes for %s/%s#%d.", org, repo, pr.Number)
+		return releaseNoteLabelNeeded
+	}
+	for _, change := range changes {
+		if change.Status == "removed" {
+			continue
+		}
+		if match, err := path.Match(cfg.NoteFromFile, change.Filename); err != nil || !match {
+			continue
+		}
+		content, err := gc.GetFile(org, repo, change.Filename, pr.PullRequest.Head.SHA)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to get %s from %s/%s#%d.", change.Filename, org, repo, pr.Number)
+			continue
+		}
+		note := string(content)
+		if hasTemplate(cfg, note) {
+			note = getReleaseNote(cfg, note)
+		}
+		return noteLabel(cfg, note)
+	}
+	return releaseNoteLabelNeeded
+}
+
+// conventionalTitleLabel returns the label for the release note derived from
+// the Conventional Commits title of pr, or releaseNoteLabelNeeded if the
+// title doesn't give one.
+func conventionalTitleLabel(cfg *plugins.ReleaseNote, pr *github.PullRequest) string {
+	m := conventionalRe.FindStringSubmatch(strings.TrimSpace(pr.Title))
+	if m == nil {
+		return releaseNoteLabelNeeded
+	}
+	if m[2] == "!" || breakingChangeRe.MatchString(pr.Body) {
+		return releaseNoteActionRequired
+	}
+	switch strings.ToLower(m[1]) {
+	case "feat", "fix":
+		return noteLabel(cfg, m[3])
+	}
+	return releaseNoteLabelNeeded
+}
+
+// upstreamNoteLabel returns the release note label of the PR referenced by
+// an "Upstream: owner/repo#N" line in body, or releaseNoteLabelNeeded if
+// there is no such reference or the upstream PR has no release note.
+func upstreamNoteLabel(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, body string) string {
+	match := upstreamRefRe.FindStringSubmatch(body)
+	if match == nil {
+		return releaseNoteLabelNeeded
+	}
+	org, repo := match[1], match[2]
+	number, err := strconv.Atoi(match[3])
+	if err != nil {
+		return releaseNoteLabelNeeded
+	}
+	upstream, err := gc.GetPullRequest(org, repo, number)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get upstream PR %s/%s#%d.", org, repo, number)
+		return releaseNoteLabelNeeded
+	}
+	if upstream == nil {
+		return releaseNoteLabelNeeded
+	}
+	return determineReleaseNoteLabel(cfg, upstream.Body)
+}
+
+// dependencyNoteLabel returns the release note label of the first PR
+// referenced by a "Depends on #N" or "Part of #N" line in body that has a
+// release note, or releaseNoteLabelNeeded if there is none.
+func dependencyNoteLabel(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, org, repo, body string) string {
+	for _, match := range dependsOnRe.FindAllStringSubmatch(body, -1) {
+		number, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		dependency, err := gc.GetPullRequest(org, repo, number)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to get %s/%s#%d.", org, repo, number)
+			continue
+		}
+		if dependency == nil {
+			continue
+		}
+		if label := determineReleaseNoteLabel(cfg, dependency.Body); label != releaseNoteLabelNeeded {
+			return label
+		}
+	}
+	return releaseNoteLabelNeeded
+}
+
+// changesNotePaths returns true if pr changes a file matching any of
+// cfg.RequireNoteForPaths, so it must follow the release note process even
+// if it would otherwise be exempt. A pattern also matches the files under the
+// directories it matches, eg. "pkg/apis" matches "pkg/apis/core/types.go".
+// PRs whose changes can't be listed are assumed to match.
+func changesNotePaths(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent) bool {
+	if len(cfg.RequireNoteForPaths) == 0 {
+		return false
+	}
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	changes, err := gc.GetPullRequestChanges(org, repo, pr.Number)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get changes for %s/%s#%d.", org, repo, pr.Number)
+		return true
+	}
+	for _, change := range changes {
+		for _, pattern := range cfg.RequireNoteForPaths {
+			pattern = strings.TrimSuffix(pattern, "/")
+			for file := change.Filename; file != "." && file != "/"; file = path.Dir(file) {
+				if match, err := path.Match(pattern, file); err == nil && match {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// changedLines returns the total number of lines added and deleted by a PR.
+func changedLines(gc githubClient, org, repo string, number int) (int, error) {
+	changes, err := gc.GetPullRequestChanges(org, repo, number)
+	if err != nil {
+		return 0, err
+	}
+	lines := 0
+	for _, change := range changes {
+		lines += change.Additions + change.Deletions
+	}
+	return lines, nil
+}
+
+// escalateUnresolvedNote counts an edit that didn't add a release note in
+// the guidance comment, and mentions cfg.MentionOnNeeded once the count
+// reaches cfg.MentionAfterEdits. Mentions are posted in a new comment since
+// GitHub doesn't notify users mentioned in edited comments.
+func escalateUnresolvedNote(gc githubClient, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, comments []github.IssueComment) error {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	body := releaseNoteBody(cfg)
+	for _, c := range comments {
+		if !strings.Contains(c.Body, body) {
+			continue
+		}
+		match := unresolvedEditsRe.FindStringSubmatch(c.Body)
+		if match == nil {
+			continue
+		}
+		edits, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		edits++
+		body := unresolvedEditsRe.ReplaceAllString(c.Body, fmt.Sprintf(unresolvedEditsFormat, edits))
+		if err := gc.EditComment(org, repo, c.ID, body); err != nil {
+			return err
+		}
+		if edits < cfg.MentionAfterEdits || containsComment(comments, escalationBody) {
+			return nil
+		}
+		var mentions []string
+		for _, m := range cfg.MentionOnNeeded {
+			mentions = append(mentions, strings.TrimPrefix(m, "@"))
+		}
+		comment := plugins.FormatResponse(
+			strings.Join(mentions, " @"),
+			escalationBody,
+			fmt.Sprintf("This PR has been edited %d times since @%s was asked to add a release note.", edits, pr.PullRequest.User.Login),
+		)
+		return gc.CreateComment(org, repo, pr.Number, comment)
+	}
+	return nil
+}
+
+// hasSuggestionFenceNote returns true if the body contains a non-empty
+// ```suggestion block, which is usually a misplaced release note.
+func hasSuggestionFenceNote(body string) bool {
+	for _, match := range suggestionRe.FindAllStringSubmatch(body, -1) {
+		if strings.TrimSpace(match[1]) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMisfencedNote returns true if body has exactly one code block, eg.
+// ```go, whose contents look like prose rather than code, which is likely a
+// release note in the wrong fence.
+func hasMisfencedNote(cfg *plugins.ReleaseNote, body string) bool {
+	prose := 0
+	for _, match := range codeFenceRe.FindAllStringSubmatch(body, -1) {
+		info := strings.ToLower(match[1])
+		if info == "suggestion" || isNoteFence(cfg, info) {
+			continue
+		}
+		if looksLikeProse(match[2]) {
+			prose++
+		}
+	}
+	return prose == 1
+}
+
+func isNoteFence(cfg *plugins.ReleaseNote, info string) bool {
+	for _, fence := range noteFences(cfg) {
+		if strings.ToLower(fence) == info {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeProse returns true if s has a few words and hardly any of the
+// punctuation common in code.
+func looksLikeProse(s string) bool {
+	const minWords = 4
+	if len(strings.Fields(s)) < minWords {
+		return false
+	}
+	var chars, symbols int
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		chars++
+		if strings.ContainsRune("{}()[];=<>$|&*\"'#", r) {
+			symbols++
+		}
+	}
+	return symbols*20 < chars
+}
+
+// containsComment returns true if any of the comments contains body.
+func containsComment(comments []github.IssueComment, body string) bool {
+	for _, c := range comments {
+		if strings.Contains(c.Body, body) {
+			return true
+		}
+	}
+	return false
+}
+
+func ensureNoRelNoteNeededLabel(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, prLabels []github.Label) {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	if !hasNeededLabel(prLabels) {
+		return
+	}
+	format := "Failed to remove the label %q from %s/%s#%d."
+	for _, label := range []string{releaseNoteLabelNeeded, deprecatedReleaseNoteLabelNeeded} {
+		if !hasLabel(label, prLabels) {
+			continue
+		}
+		if err := gc.RemoveLabel(org, repo, pr.Number, label); err != nil {
+			log.WithError(err).Errorf(format, label, org, repo, pr.Number)
+		}
+	}
+	dismissNeededGuidance(gc, log, cfg, pr, prLabels)
+}
+
+// dismissNeededGuidance dismisses the bot's guidance reviews once a PR that
+// had a needed label no longer needs one.
+func dismissNeededGuidance(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, prLabels []github.Label) {
+	if !cfg.UseReviewForGuidance || !hasNeededLabel(prLabels) {
+		return
+	}
+	if err := dismissGuidanceReviews(gc, cfg, pr.Repo.Owner.Login, pr.Repo.Name, pr.Number); err != nil {
+		log.WithError(err).Errorf("Failed to dismiss release note reviews on %s/%s#%d.", pr.Repo.Owner.Login, pr.Repo.Name, pr.Number)
+	}
+}
+
+// determineReleaseNoteLabel returns the label to be added based on the contents of the 'release-note'
+// section of a PR's body text.
+func determineReleaseNoteLabel(cfg *plugins.ReleaseNote, body string) string {
+	note := getReleaseNote(cfg, body)
+	if strings.TrimSpace(note) == "" && cfg.HonorNoNoteCheckbox && noNoteCheckboxRe.MatchString(body) {
+		return releaseNoteNone
+	}
+	label := noteLabel(cfg, note)
+	if label == releaseNoteActionRequired && notActionMarkerRe.MatchString(body) {
+		// The author overrode the classification, eg. because the note says
+		// "no action required".
+		return releaseNote
+	}
+	return label
+}
+
+// noteLabel returns the label for a PR with the given release note.
+func noteLabel(cfg *plugins.ReleaseNote, note string) string {
+	composedReleaseNote := strings.ToLower(strings.TrimSpace(note))
+
+	if composedReleaseNote == "" {
+		return releaseNoteLabelNeeded
+	}
+	if composedReleaseNote == noReleaseNoteComment || isNoneSynonym(cfg, composedReleaseNote) {
+		return releaseNoteNone
+	}
+	if cfg.NoneSentinel != "" && composedReleaseNote == strings.ToLower(strings.TrimSpace(cfg.NoneSentinel)) {
+		return releaseNoteNone
+	}
+	if !strictNoneOnly(cfg) && leadingNoneRe.MatchString(composedReleaseNote) {
+		return releaseNoteNone
+	}
+	if regexesFor(cfg).actionRequired.MatchString(composedReleaseNote) {
+		return releaseNoteActionRequired
+	}
+	return releaseNote
+}
+
+// getReleaseNote returns the release note from a PR body
+// assumes that the PR body followed the PR template
+func getReleaseNote(cfg *plugins.ReleaseNote, body string) string {
+	note := stripFooters(rawReleaseNote(cfg, body))
+	if cfg.FrontMatterNotes && (note == "" || cfg.FrontMatterOverridesFence) {
+		if fm, ok := frontMatterNote(body); ok {
+			return fm
+		}
+	}
+	return note
+}
+
+// frontMatterNote returns the release-note key of the YAML front matter at
+// the top of body, eg.
+//
+//	---
+//	release-note: skip
+//	---
+//
+// A "skip" value is returned as "NONE".
+func frontMatterNote(body string) (string, bool) {
+	m := frontMatterRe.FindStringSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	var fm map[string]interface{}
+	if err := yaml.Unmarshal([]byte(m[1]), &fm); err != nil {
+		return "", false
+	}
+	note, ok := fm["release-note"].(string)
+	if !ok {
+		return "", false
+	}
+	note = strings.TrimSpace(note)
+	if strings.ToLower(note) == "skip" {
+		return "NONE", true
+	}
+	return note, note != ""
+}
+
+// stripFooters removes trailing commit footer lines, eg. "Signed-off-by:",
+// that were pasted into a release note.
+func stripFooters(note string) string {
+	lines := strings.Split(note, "\n")
+	for len(lines) > 0 && (footerRe.MatchString(lines[len(lines)-1]) || strings.TrimSpace(lines[len(lines)-1]) == "") {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func rawReleaseNote(cfg *plugins.ReleaseNote, body string) string {
+	if cfg.AreaNotes {
+		// Classify the notes of all areas together.
+		if blocks := regexesFor(cfg).areaBlock.FindAllStringSubmatch(body, -1); len(blocks) > 0 {
+			var notes []string
+			for _, m := range blocks {
+				if note := strings.TrimSpace(m[2]); note != "" {
+					notes = append(notes, note)
+				}
+			}
+			return strings.Join(notes, "\n")
+		}
+	}
+	notes, found := fencedNotes(cfg, body)
+	if !found {
+		if cfg.RstNoteDirective {
+			if note, ok := getRstReleaseNote(body); ok {
+				return note
+			}
+		}
+		if cfg.FormFieldNotes {
+			note, _ := getFormFieldNote(body)
+			return note
+		}
+		return ""
+	}
+	// Blocks that say there is no release note only count if all do.
+	var real []string
+	for _, note := range notes {
+		if noteLabel(cfg, note) != releaseNoteNone {
+			real = append(real, note)
+		}
+	}
+	if len(real) == 0 && len(notes) > 0 {
+		return notes[0]
+	}
+	return strings.Join(real, "\n\n")
+}
+
+// fencedNotes returns the non-empty release note blocks in body, eg. one per
+// user-facing change, and whether there were any blocks at all.
+func fencedNotes(cfg *plugins.ReleaseNote, body string) ([]string, bool) {
+	var notes []string
+	found := false
+	for rest := body; ; {
+		m := regexesFor(cfg).noteMatcher.FindStringSubmatchIndex(rest)
+		if m == nil {
+			return notes, found
+		}
+		found = true
+		note := rest[m[2]:m[3]]
+		end := m[1]
+		if nested, ok := nestedFenceNote(rest[m[2]:]); ok {
+			note = nested
+			end = m[2] + len(nested)
+		}
+		if note = strings.TrimSpace(note); note != "" {
+			notes = append(notes, note)
+		}
+		rest = rest[end:]
+	}
+}
+
+// nestedFenceNote returns the contents of a release note block that starts
+// at the beginning of rest and contains fenced code, eg.
+//
+//	```release-note
+//	The --foo flag now takes a list:
+//	```shell
+//	bar --foo=a,b
+//	```
+//	```
+//
+// A line opening a fence with an info string starts a nested block, which
+// the next bare fence closes. It returns false if the block contains no
+// nested blocks or isn't closed.
+func nestedFenceNote(rest string) (string, bool) {
+	var note bytes.Buffer
+	depth := 0
+	nested := false
+	for i, line := range strings.SplitAfter(rest, "\n") {
+		trimmed := strings.TrimSpace(line)
+		// The first line is the remainder of the opening fence's line.
+		if i > 0 && strings.HasPrefix(trimmed, "```") {
+			info := strings.TrimSpace(strings.TrimLeft(trimmed, "`"))
+			switch {
+			case info == "":
+				if depth == 0 {
+					return note.String(), nested
+				}
+				depth--
+			case !strings.Contains(info, "`"):
+				depth++
+				nested = true
+			}
+		}
+		note.WriteString(line)
+	}
+	return "", false
+}
+
+// getFormFieldNote returns the answer to the "Release note" field of a PR
+// body rendered from a GitHub form, eg.
+//
+//	### Release note
+//
+//	Some release note.
+//
+// and whether the field was found at all. An unanswered field, which GitHub
+// renders as "_No response_", means that no release note is needed, so it is
+// returned as "NONE".
+func getFormFieldNote(body string) (string, bool) {
+	lines := strings.Split(strings.Replace(body, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		if !formFieldRe.MatchString(line) {
+			continue
+		}
+		var answer []string
+		for _, l := range lines[i+1:] {
+			if formHeadingRe.MatchString(l) {
+				break
+			}
+			answer = append(answer, l)
+		}
+		note := strings.TrimSpace(strings.Join(answer, "\n"))
+		if note == formNoResponse {
+			return "NONE", true
+		}
+		// Fields rendered as code are fenced.
+		if m := formFenceRe.FindStringSubmatch(note); m != nil {
+			note = strings.TrimSpace(m[1])
+		}
+		return note, true
+	}
+	return "", false
+}
+
+// getRstReleaseNote returns the content of the first reStructuredText
+// release-note directive in body, eg.
+//
+//	.. release-note::
+//
+//	   Some release note.
+//
+// and whether the directive was found at all.
+func getRstReleaseNote(body string) (string, bool) {
+	lines := strings.Split(strings.Replace(body, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, rstNoteDirective) {
+			continue
+		}
+		// The directive may have its content on the same line.
+		note := []string{strings.TrimSpace(strings.TrimPrefix(line, rstNoteDirective))}
+		for _, l := range lines[i+1:] {
+			if strings.TrimSpace(l) != "" && strings.TrimLeft(l, " \t") == l {
+				// The first unindented line ends the directive.
+				break
+			}
+			note = append(note, strings.TrimSpace(l))
+		}
+		return strings.TrimSpace(strings.Join(note, "\n")), true
+	}
+	return "", false
+}
+
+func maxParents(cfg *plugins.ReleaseNote) int {
+	if cfg.MaxParents <= 0 {
+		return defaultMaxParents
+	}
+	return cfg.MaxParents
+}
+
+// isNoneSynonym returns whether the lowercased note is one of the synonyms of
+// none, eg. "n/a".
+func isNoneSynonym(cfg *plugins.ReleaseNote, note string) bool {
+	synonyms := cfg.NoneSynonyms
+	if len(synonyms) == 0 {
+		synonyms = defaultNoneSynonyms
+	}
+	for _, synonym := range synonyms {
+		if note == strings.ToLower(strings.TrimSpace(synonym)) {
+			return true
+		}
+	}
+	return false
+}
+
+func strictNoneOnly(cfg *plugins.ReleaseNote) bool {
+	return cfg.StrictNoneOnly == nil || *cfg.StrictNoneOnly
+}
+
+func noteFences(cfg *plugins.ReleaseNote) []string {
+	if len(cfg.NoteFences) == 0 {
+		return defaultNoteFences
+	}
+	return cfg.NoteFences
+}
+
+func noteHeading(cfg *plugins.ReleaseNote) string {
+	if cfg.NoteHeading == "" {
+		return defaultNoteHeading
+	}
+	return cfg.NoteHeading
+}
+
+func actionRequiredPhrases(cfg *plugins.ReleaseNote) []string {
+	if len(cfg.ActionRequiredPhrases) == 0 {
+		return defaultActionRequiredPhrases
+	}
+	return cfg.ActionRequiredPhrases
+}
+
+// regexesFor returns the regexes for cfg, compiling them only if no identical
+// config has been seen before.
+func regexesFor(cfg *plugins.ReleaseNote) *noteRegexes {
+	key := fmt.Sprintf("%q|%q|%q|%q|%q|%q|%q|%q", noteFences(cfg), noteHeading(cfg), actionRequiredPhrases(cfg), cfg.NoneSentinel, cfg.ForbiddenFlagPatterns, cfg.SecurityNotePatterns, bumpTitlePatterns(cfg), []string{cfg.Labels.ReleaseNote, cfg.Labels.None, cfg.Labels.ActionRequired})
+	regexCache.Lock()
+	defer regexCache.Unlock()
+	if res, ok := regexCache.entries[key]; ok {
+		return res
+	}
+	res := compileRegexes(cfg)
+	regexCache.entries[key] = res
+	return res
+}
+
+func compileRegexes(cfg *plugins.ReleaseNote) *noteRegexes {
+	// Try longer fences first so that "release-notes" isn't matched as
+	// "release-note" followed by note text starting with "s".
+	fences := quoteAll(noteFences(cfg))
+	sort.Slice(fences, func(i, j int) bool { return len(fences[i]) > len(fences[j]) })
+	fence := strings.Join(fences, "|")
+	heading := regexp.QuoteMeta(noteHeading(cfg))
+	// The heading may be separated from an untagged fence by a comment and
+	// a horizontal rule.
+	headingSeparator := `\s*(?:<!--[^<>]*-->\s*)?(?:(?:-{3,}|\*{3,}|_{3,})\s*)?`
+	// The commands are named after the labels as configured.
+	names := newLabelNames(cfg)
+	noneCommands := []string{regexp.QuoteMeta(names.name(releaseNoteNone))}
+	if sentinel := strings.TrimSpace(cfg.NoneSentinel); sentinel != "" {
+		noneCommands = append(noneCommands, regexp.QuoteMeta(sentinel))
+	}
+	actionRequiredCommand := regexp.QuoteMeta(names.name(releaseNoteActionRequired))
+	return &noteRegexes{
+		noteMatcher:            regexp.MustCompile(`(?s)(?:` + heading + `\*\*:` + headingSeparator + "```(?:" + fence + ")?|```(?:" + fence + "))(.+?)```"),
+		actionRequired:         regexp.MustCompile(`(?i)` + strings.Join(quoteAll(actionRequiredPhrases(cfg)), "|")),
+		areaBlock:              regexp.MustCompile("(?s)```(?:" + fence + ")[ \t]+area/([[:alnum:]_./-]+)[ \t]*\r?\n(.*?)```"),
+		noteCommand:            regexp.MustCompile(`(?mi)^/` + regexp.QuoteMeta(names.name(releaseNote)) + `\s*$`),
+		noneCommand:            regexp.MustCompile(`(?mi)^/(?:` + strings.Join(noneCommands, "|") + `)\s*$`),
+		actionRequiredCommand:  regexp.MustCompile(`(?mi)^/` + actionRequiredCommand + `\s*$`),
+		recordedActionRequired: regexp.MustCompile(`(?mi)^/` + actionRequiredCommand + `[ \t]+(\S.*?)\s*$`),
+		forbiddenFlags:         compilePatterns("forbidden_flag_patterns", cfg.ForbiddenFlagPatterns),
+		securityNotes:          compilePatterns("security_note_patterns", cfg.SecurityNotePatterns),
+		bumpTitles:             compilePatterns("bump_notes title_pattern", bumpTitlePatterns(cfg)),
+	}
+}
+
+// compilePatterns compiles the regexps configured in the named field. Invalid
+// ones are logged, once per config since the results are cached, and are nil.
+func compilePatterns(field string, patterns []string) []*regexp.Regexp {
+	var out []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			logrus.WithField("plugin", pluginName).WithError(err).Errorf("Invalid %s entry %q.", field, pattern)
+		}
+		out = append(out, re)
+	}
+	return out
+}
+
+// bumpTitlePatterns returns the title patterns of cfg.BumpNotes.
+func bumpTitlePatterns(cfg *plugins.ReleaseNote) []string {
+	var out []string
+	for _, bump := range cfg.BumpNotes {
+		out = append(out, bump.TitlePattern)
+	}
+	return out
+}
+
+func quoteAll(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		out = append(out, regexp.QuoteMeta(s))
+	}
+	return out
+}
+
+// noteStatus returns the commit status describing the label decided for a PR.
+// The description names the label if cfg renames it.
+func noteStatus(cfg *plugins.ReleaseNote, label string) github.Status {
+	status := github.Status{
+		State:   github.StatusSuccess,
+		Context: statusContext,
+	}
+	switch label {
+	case releaseNote:
+		status.Description = "release note present"
+	case releaseNoteActionRequired:
+		status.Description = "action required release note present"
+	case releaseNoteNone:
+		status.Description = "no release note needed"
+	case releaseNoteLabelNeeded:
+		status.State = github.StatusFailure
+		status.Description = "needs release note"
+	default:
+		status.Description = "release note not required"
+	}
+	if name := newLabelNames(cfg).name(label); name != label {
+		status.Description += fmt.Sprintf(" (%s)", name)
+	}
+	return status
+}
+
+// noteAreas returns the area labels, eg. area/network, of the release note
+// blocks in body that are tagged with an allowed area.
+func noteAreas(cfg *plugins.ReleaseNote, body string) []string {
+	var areas []string
+	seen := map[string]bool{}
+	for _, m := range regexesFor(cfg).areaBlock.FindAllStringSubmatch(body, -1) {
+		area := "area/" + m[1]
+		if seen[area] || strings.TrimSpace(m[2]) == "" {
+			continue
+		}
+		for _, allowed := range cfg.AllowedAreas {
+			if strings.TrimPrefix(allowed, "area/") == m[1] {
+				seen[area] = true
+				areas = append(areas, area)
+				break
+			}
+		}
+	}
+	return areas
+}
+
+// hasTemplate returns true if the body contains a release note fence or the
+// release note heading, even if the release note itself is empty.
+func hasTemplate(cfg *plugins.ReleaseNote, body string) bool {
+	for _, fence := range noteFences(cfg) {
+		if strings.Contains(body, "```"+fence) {
+			return true
+		}
+	}
+	if cfg.RstNoteDirective {
+		if _, ok := getRstReleaseNote(body); ok {
+			return true
+		}
+	}
+	return strings.Contains(body, noteHeading(cfg))
+}
+
+// hasNeededLabel returns true if the PR carries either the current or the
+// deprecated release-note-label-needed label. During the transition some PRs
+// only carry the deprecated one; handlePR migrates them to the current label.
+func hasNeededLabel(prLabels []github.Label) bool {
+	return hasLabel(releaseNoteLabelNeeded, prLabels) ||
+		hasLabel(deprecatedReleaseNoteLabelNeeded, prLabels)
+}
+
+func releaseNoteAlreadyAdded(prLabels []github.Label) bool {
+	return hasLabel(releaseNote, prLabels) ||
+		hasLabel(releaseNoteActionRequired, prLabels) ||
+		hasLabel(releaseNoteNone, prLabels)
+}
+
+// isParentComment returns whether body is a comment made by parentComment,
+// including those made before it added parentNoteMarker.
+func isParentComment(cfg *plugins.ReleaseNote, body string) bool {
+	return strings.Contains(body, parentNoteMarker) || strings.Contains(body, parentReleaseNoteBody(cfg))
+}
+
+// populateFromParents returns the label for the release notes of the
+// cherry-pick pr's parents taken together, or "" if none of them has a note
+// other than "none". If comment is true, the combined note is recorded on pr
+// unless it already was.
+func populateFromParents(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, comment bool) string {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	parents := getCherrypickParentPRNums(pr.PullRequest.Body)
+	if max := maxParents(cfg); len(parents) > max {
+		parents = parents[:max]
+	}
+	var notes, lines []string
+	for _, number := range parents {
+		parent, err := gc.GetPullRequest(org, repo, number)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to get %s/%s#%d (parent of #%d).", org, repo, number, pr.Number)
+			continue
+		}
+		if parent == nil {
+			continue
+		}
+		if note := getReleaseNote(cfg, parent.Body); note != "" && noteLabel(cfg, note) != releaseNoteNone {
+			notes = append(notes, note)
+			lines = append(lines, ChangelogLineFor(log, cfg, note, number, parent.User.Login))
+		}
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	note := strings.Join(notes, "\n")
+	if comment {
+		comments, err := gc.ListIssueComments(org, repo, pr.Number)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to list comments on %s/%s#%d.", org, repo, pr.Number)
+		} else if !containsComment(comments, populatedNoteMarker) {
+			body := fmt.Sprintf("%s\n%s\n```release-note\n%s\n```", populatedNoteMarker, populatedNoteBody, strings.Join(lines, "\n"))
+			if err := gc.CreateComment(org, repo, pr.Number, body); err != nil {
+				log.WithError(err).Errorf("Failed to comment on %s/%s#%d with comment %q.", org, repo, pr.Number, body)
+			}
+		}
+	}
+	return noteLabel(cfg, note)
+}
+
+// bumpNoteData is passed to the bump note templates.
+type bumpNoteData struct {
+	Title string
+	// Groups are the named groups of the title pattern.
+	Groups map[string]string
+}
+
+// bumpNote returns the release note synthesized for pr by the first of
+// cfg.BumpNotes matching its author and title.
+func bumpNote(log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequest) (string, bool) {
+	titles := regexesFor(cfg).bumpTitles
+	for i, bump := range cfg.BumpNotes {
+		authored := false
+		for _, author := range bump.Authors {
+			authored = authored || github.NormLogin(author) == github.NormLogin(pr.User.Login)
+		}
+		if !authored {
+			continue
+		}
+		re := titles[i]
+		if re == nil {
+			continue
+		}
+		m := re.FindStringSubmatch(pr.Title)
+		if m == nil {
+			continue
+		}
+		data := bumpNoteData{Title: pr.Title, Groups: map[string]string{}}
+		for j, name := range re.SubexpNames() {
+			if name != "" {
+				data.Groups[name] = m[j]
+			}
+		}
+		note, ok := renderTemplate(log, "bump_notes note_template", bump.NoteTemplate, data)
+		if note = strings.TrimSpace(note); ok && note != "" {
+			return note, true
+		}
+	}
+	return "", false
+}
+
+// recordBumpNote records the release note synthesized for pr on it, unless
+// it already was.
+func recordBumpNote(gc githubClient, log *logrus.Entry, pr *github.PullRequestEvent, note string) {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	comments, err := gc.ListIssueComments(org, repo, pr.Number)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to list comments on %s/%s#%d.", org, repo, pr.Number)
+		return
+	}
+	if containsComment(comments, bumpNoteMarker) {
+		return
+	}
+	body := fmt.Sprintf("%s\n%s\n```release-note\n%s\n```", bumpNoteMarker, bumpNoteBody, note)
+	if err := gc.CreateComment(org, repo, pr.Number, body); err != nil {
+		log.WithError(err).Errorf("Failed to comment on %s/%s#%d with comment %q.", org, repo, pr.Number, body)
+	}
+}
+
+// satisfiedByParents returns whether pr is a cherry-pick that doesn't need
+// its own release note because its parents have one.
+func satisfiedByParents(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, prLabels []github.Label) bool {
+	if !isReleaseBranch(cfg, pr.PullRequest.Base.Ref) || len(getCherrypickParentPRNums(pr.PullRequest.Body)) == 0 || trustedCherryPickBotNote(cfg, pr) {
+		return false
+	}
+	return !prMustFollowRelNoteProcess(gc, log, cfg, pr, prLabels, false)
+}
+
+// warnTooManyParents asks the author of pr to clean up its body, unless the
+// bot already did.
+func warnTooManyParents(gc githubClient, log *logrus.Entry, pr *github.PullRequestEvent, max int) {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	body := fmt.Sprintf(tooManyParentsFormat, max, max)
+	comments, err := gc.ListIssueComments(org, repo, pr.Number)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to list comments on %s/%s#%d.", org, repo, pr.Number)
+		return
+	}
+	if containsComment(comments, body) {
+		return
+	}
+	comment(gc, log, pr, body, "")
+}
+
+// parentStatus is the release note status of a cherry-pick's parent PR, as
+// passed to ParentCommentTemplate.
+type parentStatus struct {
+	Number  int
+	HasNote bool
+}
+
+// renderTemplate renders the template text of the given config option with
+// data. It returns false if text is empty or can't be rendered.
+func renderTemplate(log *logrus.Entry, option, text string, data interface{}) (string, bool) {
+	if text == "" {
+		return "", false
+	}
+	tmpl, err := template.New(option).Parse(text)
+	if err != nil {
+		log.WithError(err).Errorf("Invalid %s %q.", option, text)
+		return "", false
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		log.WithError(err).Errorf("Failed to render the %s.", option)
+		return "", false
+	}
+	return b.String(), true
+}
+
+// rejectionData is passed to the rejection templates.
+type rejectionData struct {
+	Label string
+	User  string
+	// Precondition describes the unmet none precondition, if any.
+	Precondition string
+}
+
+// rejectComment replies to ic with the rendered rejection template, or with
+// defaultResp if the template is unset.
+func rejectComment(gc githubClient, log *logrus.Entry, ic github.IssueCommentEvent, option, text, defaultResp string, data rejectionData) error {
+	resp, ok := renderTemplate(log, option, text, data)
+	if !ok {
+		resp = defaultResp
+	}
+	return gc.CreateComment(ic.Repo.Owner.Login, ic.Repo.Name, ic.Issue.Number, plugins.FormatICResponse(ic.Comment, resp))
+}
+
+// parentComment returns the comment asking for a release note on a
+// cherry-pick with noteless parents, rendered with cfg.ParentCommentTemplate
+// if it is set. The comment always ends with parentNoteMarker.
+func parentComment(log *logrus.Entry, cfg *plugins.ReleaseNote, author string, statuses []parentStatus, notelessParents []string) string {
+	data := struct {
+		Author  string
+		Parents []parentStatus
+	}{author, statuses}
+	if comment, ok := renderTemplate(log, "parent_comment_template", cfg.ParentCommentTemplate, data); ok {
+		return comment + "\n" + parentNoteMarker
+	}
+	names := newLabelNames(cfg)
+	return plugins.FormatResponse(
+		author,
+		parentReleaseNoteBody(cfg),
+		fmt.Sprintf("The following parent PRs have neither the %q nor the %q labels: %s.",
+			names.name(releaseNote),
+			names.name(releaseNoteActionRequired),
+			strings.Join(notelessParents, ", "),
+		),
+	) + "\n" + parentNoteMarker
+}
+
+func prMustFollowRelNoteProcess(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, prLabels []github.Label, comment bool) bool {
+	base := pr.PullRequest.Base.Ref
+	if isPrimaryBranch(cfg, base) {
+		return true
+	}
+	// Only release branches can be cherry-picked into. If the base ref is
+	// missing, eg. because the branch was deleted, or any other branch, eg. a
+	// feature branch, don't let the PR skip the process.
+	if !isReleaseBranch(cfg, base) {
+		if base == "" {
+			log.Warnf("Missing base ref for %s/%s#%d, requiring a release note.", pr.Repo.Owner.Login, pr.Repo.Name, pr.Number)
+		} else {
+			log.Debugf("Base ref %q of %s/%s#%d isn't a release branch, requiring a release note.", base, pr.Repo.Owner.Login, pr.Repo.Name, pr.Number)
+		}
+		return true
+	}
+
+	parents := getCherrypickParentPRNums(pr.PullRequest.Body)
+	if max := maxParents(cfg); len(parents) > max {
+		log.Warnf("%s/%s#%d references %d cherry-pick parents, only checking the first %d.", pr.Repo.Owner.Login, pr.Repo.Name, pr.Number, len(parents), max)
+		if comment {
+			warnTooManyParents(gc, log, pr, max)
+		}
+		parents = parents[:max]
+	}
+	// if it has no parents it needs to follow the release note process
+	if len(parents) == 0 {
+		return true
+	}
+	if trustedCherryPickBotNote(cfg, pr) {
+		return false
+	}
+
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+
+	var statuses []parentStatus
+	var notelessParents []string
+	for _, parent := range parents {
+		// If the parent didn't set a release note, the CP must
+		parentLabels, err := gc.GetIssueLabels(org, repo, parent)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to list labels on PR #%d (parent of #%d).", parent, pr.Number)
+			continue
+		}
+		statuses = append(statuses, parentStatus{Number: parent, HasNote: hasReleaseNote(parentLabels)})
+		if !hasReleaseNote(parentLabels) {
+			notelessParents = append(notelessParents, "#"+strconv.Itoa(parent))
+		}
+	}
+	if len(notelessParents) == 0 {
+		// All of the parents set the releaseNote or releaseNoteActionRequired label,
+		// so this cherrypick PR needs to do nothing.
+		return false
+	}
+
+	if comment && !hasNeededLabel(prLabels) {
+		comment := parentComment(log, cfg, pr.PullRequest.User.Login, statuses, notelessParents)
+		if err := postGuidance(gc, cfg, org, repo, pr.Number, comment); err != nil {
+			log.WithError(err).Errorf("Error creating comment on %s/%s#%d with comment %q.", org, repo, pr.Number, comment)
+		}
+	}
+	return true
+}
+
+// recentlyRemovedByHuman returns true if someone other than the bot removed
+// label from the PR within cfg.ManualRemovalCooldown before now.
+func recentlyRemovedByHuman(gc githubClient, cfg *plugins.ReleaseNote, org, repo string, number int, label string, now time.Time) (bool, error) {
+	cooldown, err := time.ParseDuration(cfg.ManualRemovalCooldown)
+	if err != nil {
+		return false, fmt.Errorf("invalid manual_removal_cooldown: %v", err)
+	}
+	botName, err := gc.BotName()
+	if err != nil {
+		return false, err
+	}
+	events, err := gc.ListIssueEvents(org, repo, number)
+	if err != nil {
+		return false, fmt.Errorf("failed to list events on %s/%s#%d: %v", org, repo, number, err)
+	}
+	for _, e := range events {
+		if e.Event == github.ListedIssueEventUnlabeled &&
+			strings.ToLower(e.Label.Name) == label &&
+			github.NormLogin(e.Actor.Login) != github.NormLogin(botName) &&
+			now.Sub(e.CreatedAt) < cooldown {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// emptyActionRequired returns whether the release note in body says that
+// action is required without saying anything else.
+func emptyActionRequired(cfg *plugins.ReleaseNote, body string) bool {
+	note := regexesFor(cfg).actionRequired.ReplaceAllString(getReleaseNote(cfg, body), "")
+	return strings.IndexFunc(note, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0
+}
+
+// referenceOnlyNote returns whether note, with markdown stripped, only
+// consists of issue or PR references and URLs.
+func referenceOnlyNote(note string) bool {
+	plain := stripMarkdown(note)
+	if !noteReferenceRe.MatchString(plain) {
+		return false
+	}
+	rest := noteReferenceRe.ReplaceAllString(plain, "")
+	return strings.IndexFunc(rest, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0
+}
+
+// actionRequiredWithoutDelimiter returns true if the PR's own release note
+// requires action but doesn't contain cfg.ActionRequiredDelimiter.
+func actionRequiredWithoutDelimiter(cfg *plugins.ReleaseNote, body string, prLabels []github.Label) bool {
+	if cfg.ActionRequiredDelimiter == "" {
+		return false
+	}
+	note := getReleaseNote(cfg, body)
+	if note == "" || strings.Contains(note, cfg.ActionRequiredDelimiter) {
+		return false
+	}
+	label := determineReleaseNoteLabel(cfg, body)
+	return label == releaseNoteActionRequired ||
+		label == releaseNote && hasAnyLabel(cfg.ActionRequiredLabels, prLabels)
+}
+
+func actionDelimiterBody(cfg *plugins.ReleaseNote) string {
+	return fmt.Sprintf("%s. Please start the action with a line like:\n```\n%s\n```", actionDelimiterPrefix, cfg.ActionRequiredDelimiter)
+}
+
+// trustedCherryPickBotNote returns true if the PR was opened by the
+// configured cherry-pick bot, which copies the parents' release notes, and
+// has a release note of its own.
+func trustedCherryPickBotNote(cfg *plugins.ReleaseNote, pr *github.PullRequestEvent) bool {
+	if !cfg.TrustCherryPickBotNote || cfg.CherryPickBot == "" {
+		return false
+	}
+	if github.NormLogin(pr.PullRequest.User.Login) != github.NormLogin(cfg.CherryPickBot) {
+		return false
+	}
+	return determineReleaseNoteLabel(cfg, pr.PullRequest.Body) != releaseNoteLabelNeeded
+}
+
+// shouldWelcome returns true if cfg.WelcomeFirstTimers is set and the author
+// of pr is a first-timer who should be welcomed now, ie. when the PR is
+// opened or, if cfg.LabelDelay deferred that, on the first event after the
+// delay.
+func shouldWelcome(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr *github.PullRequestEvent, comments []github.IssueComment) bool {
+	if !cfg.WelcomeFirstTimers {
+		return false
+	}
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	switch {
+	case pr.Action == github.PullRequestActionOpened:
+	case cfg.LabelDelay != "":
+		if comments == nil {
+			var err error
+			if comments, err = gc.ListIssueComments(org, repo, pr.Number); err != nil {
+				log.WithError(err).Errorf("Failed to list comments on %s/%s#%d.", org, repo, pr.Number)
+				return false
+			}
+		}
+		if containsComment(comments, welcomeBody) {
+			return false
+		}
+	default:
+		return false
+	}
+	return isFirstTimer(gc, log, org, repo, pr.PullRequest.User.Login)
+}
+
+// isFirstTimer returns true if user has never had a PR merged into org/repo.
+func isFirstTimer(gc githubClient, log *logrus.Entry, org, repo, user string) bool {
+	query := fmt.Sprintf("repo:%s/%s is:pr is:merged author:%s", org, repo, user)
+	merged, err := gc.FindIssues(query, "", false)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to search for merged PRs by %s in %s/%s.", user, org, repo)
+		return false
+	}
+	return len(merged) == 0
+}
+
+// hasReleaseNote returns true if a PR is labeled as having a release note,
+// which cherry-picks of the PR may rely on instead of adding their own.
+func hasReleaseNote(prLabels []github.Label) bool {
+	return hasLabel(releaseNote, prLabels) ||
+		hasLabel(releaseNoteActionRequired, prLabels)
+}
+
+// isPrimaryBranch returns true if ref is a branch that development happens
+// on, which PRs therefore can't be cherry-picked into.
+func isPrimaryBranch(cfg *plugins.ReleaseNote, ref string) bool {
+	for _, pattern := range primaryBranches(cfg) {
+		if matched, err := path.Match(pattern, ref); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isSkippedBranch returns true if ref matches one of cfg.SkipBranches, so PRs
+// targeting it are exempt from the release note process.
+func isSkippedBranch(cfg *plugins.ReleaseNote, ref string) bool {
+	for _, pattern := range cfg.SkipBranches {
+		if matched, err := path.Match(pattern, ref); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func primaryBranches(cfg *plugins.ReleaseNote) []string {
+	if len(cfg.PrimaryBranches) == 0 {
+		return defaultPrimaryBranches
+	}
+	return cfg.PrimaryBranches
+}
+
+// isReleaseBranch returns true if ref is a branch that PRs can be
+// cherry-picked into.
+func isReleaseBranch(cfg *plugins.ReleaseNote, ref string) bool {
+	return strings.HasPrefix(ref, "release-") && !isPrimaryBranch(cfg, ref)
+}
+
+func getCherrypickParentPRNums(body string) []int {
 	lines := strings.Split(body, "\n")
 
 	var out []int
@@ -391,6 +2956,15 @@ func getCherrypickParentPRNums(body string) []int {
 	return out
 }
 
+func hasAnyLabel(labels []string, issueLabels []github.Label) bool {
+	for _, l := range labels {
+		if hasLabel(l, issueLabels) {
+			return true
+		}
+	}
+	return false
+}
+
 func hasLabel(label string, issueLabels []github.Label) bool {
 	label = strings.ToLower(label)
 	for _, l := range issueLabels {
@@ -400,3 +2974,30 @@ func hasLabel(label string, issueLabels []github.Label) bool {
 	}
 	return false
 }
+
+// isBot returns whether user is a bot account rather than a person.
+func isBot(user github.User) bool {
+	return user.Type == github.UserTypeBot || strings.HasSuffix(user.Login, "[bot]")
+}
+
+// ignoredBot returns whether user is a bot whose /release-note-none commands
+// are ignored because it isn't in cfg.NoneCommandAllowedBots.
+func ignoredBot(cfg *plugins.ReleaseNote, user github.User) bool {
+	return len(cfg.NoneCommandAllowedBots) > 0 && isBot(user) && !allowedBot(cfg, user.Login)
+}
+
+// recordsNoneCommands returns whether cfg restricts who may use
+// /release-note-none. If so, the bot records each command it accepts and
+// later events honor only the recorded commands.
+func recordsNoneCommands(cfg *plugins.ReleaseNote) bool {
+	return len(cfg.NonePreconditions) > 0 || len(cfg.NoneCommandAllowedBots) > 0
+}
+
+func allowedBot(cfg *plugins.ReleaseNote, login string) bool {
+	for _, bot := range cfg.NoneCommandAllowedBots {
+		if github.NormLogin(bot) == github.NormLogin(login) {
+			return true
+		}
+	}
+	return false
+}
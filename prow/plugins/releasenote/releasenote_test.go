@@ -18,14 +18,18 @@ package releasenote
 
 import (
 	"fmt"
+	"io/ioutil"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/test-infra/prow/github"
 	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/plugins"
 )
 
 func TestReleaseNoteComment(t *testing.T) {
@@ -183,7 +187,7 @@ func TestReleaseNoteComment(t *testing.T) {
 		for _, l := range tc.currentLabels {
 			ice.Issue.Labels = append(ice.Issue.Labels, github.Label{Name: l})
 		}
-		if err := handleComment(fc, logrus.WithField("plugin", pluginName), ice); err != nil {
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, ice); err != nil {
 			t.Errorf("For case %s, did not expect error: %v", tc.name, err)
 		}
 		if tc.shouldComment && len(fc.IssueComments[5]) == 0 {
@@ -258,15 +262,22 @@ func newFakeClient(body, branch string, initialLabels, comments []string, parent
 }
 
 func TestReleaseNotePR(t *testing.T) {
+	// The invalid pattern is ignored.
+	securityPatterns := []string{`CVE-\d`, `(?i)security fix`, `(`}
 	tests := []struct {
 		name          string
+		title         string
 		initialLabels []string
-		body          string
-		branch        string // Defaults to master
-		parentPRs     map[int]string
-		issueComments []string
-		labelsAdded   []string
-		labelsRemoved []string
+		// existingLabels are the labels in the repo besides the release
+		// note labels.
+		existingLabels []string
+		body           string
+		branch         string // Defaults to master
+		parentPRs      map[int]string
+		issueComments  []string
+		cfg            *plugins.ReleaseNote // Defaults to the zero config
+		labelsAdded    []string
+		labelsRemoved  []string
 	}{
 		{
 			name:          "LGTM with release-note",
@@ -290,6 +301,11 @@ func TestReleaseNotePR(t *testing.T) {
 			body:          "```release-note\n```",
 			issueComments: []string{"/release-note-none "},
 		},
+		{
+			name:          "LGTM with release-note-none, empty block, no comment",
+			initialLabels: []string{lgtmLabel, releaseNoteNone},
+			body:          "```release-note\n```",
+		},
 		{
 			name:          "LGTM with release-note-action-required",
 			initialLabels: []string{lgtmLabel, releaseNoteActionRequired},
@@ -439,16 +455,341 @@ func TestReleaseNotePR(t *testing.T) {
 			labelsAdded:   []string{releaseNoteLabelNeeded},
 			labelsRemoved: []string{releaseNote},
 		},
+		{
+			name:          "deprecated release-note-label-needed only, no note",
+			initialLabels: []string{deprecatedReleaseNoteLabelNeeded},
+			labelsAdded:   []string{releaseNoteLabelNeeded},
+			labelsRemoved: []string{deprecatedReleaseNoteLabelNeeded},
+		},
+		{
+			name:          "deprecated release-note-label-needed only, with note",
+			initialLabels: []string{deprecatedReleaseNoteLabelNeeded},
+			body:          "```release-note\n note note note.\n```",
+			labelsAdded:   []string{releaseNote},
+			labelsRemoved: []string{deprecatedReleaseNoteLabelNeeded},
+		},
+		{
+			name:          "deprecated release-note-label-needed only, cherry-pick of noted parent",
+			initialLabels: []string{deprecatedReleaseNoteLabelNeeded},
+			branch:        "release-1.2",
+			body:          "Cherry pick of #2 on release-1.2.",
+			parentPRs:     map[int]string{2: releaseNote},
+			labelsRemoved: []string{deprecatedReleaseNoteLabelNeeded},
+		},
+		{
+			name:          "action required label present with a plain note is upgraded",
+			initialLabels: []string{"kind/action-required"},
+			body:          "```release-note\nA plain note.\n```",
+			cfg:           &plugins.ReleaseNote{ActionRequiredLabels: []string{"kind/action-required"}},
+			labelsAdded:   []string{releaseNoteActionRequired},
+		},
+		{
+			name:        "action required labels configured, plain note without the label",
+			body:        "```release-note\nA plain note.\n```",
+			cfg:         &plugins.ReleaseNote{ActionRequiredLabels: []string{"kind/action-required"}},
+			labelsAdded: []string{releaseNote},
+		},
+		{
+			name:          "action required label present with a none block stays none",
+			initialLabels: []string{"kind/action-required"},
+			body:          "```release-note\nNONE\n```",
+			cfg:           &plugins.ReleaseNote{ActionRequiredLabels: []string{"kind/action-required"}},
+			labelsAdded:   []string{releaseNoteNone},
+		},
+		{
+			name:        "release-next is primary",
+			body:        "Cherry pick of #2 on release-1.20.",
+			branch:      "release-next",
+			parentPRs:   map[int]string{2: releaseNote},
+			cfg:         &plugins.ReleaseNote{PrimaryBranches: []string{"master", "release-next"}},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:      "release-1.20 is a release branch",
+			body:      "Cherry pick of #2 on release-1.20.",
+			branch:    "release-1.20",
+			parentPRs: map[int]string{2: releaseNote},
+			cfg:       &plugins.ReleaseNote{PrimaryBranches: []string{"master", "release-next"}},
+		},
+		{
+			name:        "primary branch glob patterns",
+			body:        "Cherry pick of #2 on release-1.20.",
+			branch:      "release-next",
+			parentPRs:   map[int]string{2: releaseNote},
+			cfg:         &plugins.ReleaseNote{PrimaryBranches: []string{"release-n*"}},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "main is primary",
+			body:        "Cherry pick of #2 on release-1.20.",
+			branch:      "main",
+			parentPRs:   map[int]string{2: releaseNote},
+			cfg:         &plugins.ReleaseNote{PrimaryBranches: []string{"main"}},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "release branches are primary",
+			body:        "Cherry pick of #2 on release-1.20.",
+			branch:      "release-1.20",
+			parentPRs:   map[int]string{2: releaseNote},
+			cfg:         &plugins.ReleaseNote{PrimaryBranches: []string{"master", "release-*"}},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "master is primary by default",
+			body:        "Cherry pick of #2 on release-1.20.",
+			parentPRs:   map[int]string{2: releaseNote},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:      "release-next is a release branch by default",
+			body:      "Cherry pick of #2 on release-1.20.",
+			branch:    "release-next",
+			parentPRs: map[int]string{2: releaseNote},
+		},
+		{
+			name:           "two areas",
+			existingLabels: []string{"area/network", "area/storage"},
+			body:           "```release-note area/network\nThe proxy is faster.\n```\n```release-note area/storage\nVolumes resize online.\n```",
+			cfg:            &plugins.ReleaseNote{AreaNotes: true, AllowedAreas: []string{"network", "area/storage"}},
+			labelsAdded:    []string{releaseNote, "area/network", "area/storage"},
+		},
+		{
+			name:           "one action required area",
+			existingLabels: []string{"area/network", "area/storage"},
+			body:           "```release-note area/network\nThe proxy is faster.\n```\n```release-note area/storage\nAction required: migrate your volumes.\n```",
+			cfg:            &plugins.ReleaseNote{AreaNotes: true, AllowedAreas: []string{"network", "area/storage"}},
+			labelsAdded:    []string{releaseNoteActionRequired, "area/network", "area/storage"},
+		},
+		{
+			name:           "area not in the allowlist",
+			existingLabels: []string{"area/network", "area/storage"},
+			body:           "```release-note area/network\nThe proxy is faster.\n```\n```release-note area/bogus\nSomething.\n```",
+			cfg:            &plugins.ReleaseNote{AreaNotes: true, AllowedAreas: []string{"network", "area/storage"}},
+			labelsAdded:    []string{releaseNote, "area/network"},
+		},
+		{
+			name:           "empty area blocks",
+			existingLabels: []string{"area/network", "area/storage"},
+			body:           "```release-note area/network\n```\n```release-note area/storage\n```",
+			cfg:            &plugins.ReleaseNote{AreaNotes: true, AllowedAreas: []string{"network", "area/storage"}},
+			labelsAdded:    []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:           "area notes, plain release note block",
+			existingLabels: []string{"area/network", "area/storage"},
+			body:           "```release-note\nThe proxy is faster.\n```",
+			cfg:            &plugins.ReleaseNote{AreaNotes: true, AllowedAreas: []string{"network", "area/storage"}},
+			labelsAdded:    []string{releaseNote},
+		},
+		{
+			name:           "area notes disabled",
+			existingLabels: []string{"area/network", "area/storage"},
+			body:           "```release-note area/network\nThe proxy is faster.\n```",
+			cfg:            &plugins.ReleaseNote{AllowedAreas: []string{"network", "area/storage"}},
+			labelsAdded:    []string{releaseNote},
+		},
+		{
+			name:          "area labels aren't removed along with other release note labels",
+			initialLabels: []string{releaseNoteNone, "area/network"},
+			body:          "```release-note area/network\nThe proxy is faster.\n```",
+			cfg:           &plugins.ReleaseNote{AreaNotes: true, AllowedAreas: []string{"network"}},
+			labelsAdded:   []string{releaseNote},
+			labelsRemoved: []string{releaseNoteNone},
+		},
+		{
+			name:        "checked no-note checkbox",
+			body:        "- [x] No release note needed\n\n```release-note\n```",
+			cfg:         &plugins.ReleaseNote{HonorNoNoteCheckbox: true},
+			labelsAdded: []string{releaseNoteNone},
+		},
+		{
+			name:        "no-note checkbox checked with an upper case X",
+			body:        "* [X] No release note needed.\n\n```release-note\n```",
+			cfg:         &plugins.ReleaseNote{HonorNoNoteCheckbox: true},
+			labelsAdded: []string{releaseNoteNone},
+		},
+		{
+			name:        "unchecked no-note checkbox with an empty block",
+			body:        "- [ ] No release note needed\n\n```release-note\n```",
+			cfg:         &plugins.ReleaseNote{HonorNoNoteCheckbox: true},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "checked no-note checkbox with a real note",
+			body:        "- [x] No release note needed\n\n```release-note\nA note.\n```",
+			cfg:         &plugins.ReleaseNote{HonorNoNoteCheckbox: true},
+			labelsAdded: []string{releaseNote},
+		},
+		{
+			name:           "cherry-pick satisfied by its parent",
+			existingLabels: []string{releaseNoteInherited},
+			body:           "Cherry pick of #2 on release-1.8.\n```release-note\n```",
+			branch:         "release-1.8",
+			parentPRs:      map[int]string{2: releaseNote},
+			cfg:            &plugins.ReleaseNote{LabelInheritedNotes: true},
+			labelsAdded:    []string{releaseNoteInherited},
+		},
+		{
+			name:           "cherry-pick with its own note",
+			existingLabels: []string{releaseNoteInherited},
+			body:           "Cherry pick of #2 on release-1.8.\n```release-note\nA note.\n```",
+			branch:         "release-1.8",
+			parentPRs:      map[int]string{2: releaseNote},
+			cfg:            &plugins.ReleaseNote{LabelInheritedNotes: true},
+			labelsAdded:    []string{releaseNote},
+		},
+		{
+			name:           "cherry-pick that gained its own note",
+			initialLabels:  []string{releaseNoteInherited},
+			existingLabels: []string{releaseNoteInherited},
+			body:           "Cherry pick of #2 on release-1.8.\n```release-note\nA note.\n```",
+			branch:         "release-1.8",
+			parentPRs:      map[int]string{2: releaseNote},
+			cfg:            &plugins.ReleaseNote{LabelInheritedNotes: true},
+			labelsAdded:    []string{releaseNote},
+			labelsRemoved:  []string{releaseNoteInherited},
+		},
+		{
+			name:           "inherited notes not labeled",
+			existingLabels: []string{releaseNoteInherited},
+			body:           "Cherry pick of #2 on release-1.8.\n```release-note\n```",
+			branch:         "release-1.8",
+			parentPRs:      map[int]string{2: releaseNote},
+		},
+		{
+			name:           "note mentioning a CVE",
+			existingLabels: []string{releaseNoteSecurity},
+			body:           "```release-note\nFixes CVE-2017-1002101 in the subpath handling.\n```",
+			cfg:            &plugins.ReleaseNote{SecurityNotePatterns: securityPatterns},
+			labelsAdded:    []string{releaseNote, releaseNoteSecurity},
+		},
+		{
+			name:           "note mentioning a security fix",
+			existingLabels: []string{releaseNoteSecurity},
+			body:           "```release-note\nSecurity fix: tokens are no longer logged.\n```",
+			cfg:            &plugins.ReleaseNote{SecurityNotePatterns: securityPatterns},
+			labelsAdded:    []string{releaseNote, releaseNoteSecurity},
+		},
+		{
+			name:           "note not mentioning security",
+			existingLabels: []string{releaseNoteSecurity},
+			body:           "```release-note\nThe foo command now supports the --bar flag.\n```",
+			cfg:            &plugins.ReleaseNote{SecurityNotePatterns: securityPatterns},
+			labelsAdded:    []string{releaseNote},
+		},
+		{
+			name:           "security label is removed once the note no longer matches",
+			initialLabels:  []string{releaseNote, releaseNoteSecurity},
+			existingLabels: []string{releaseNoteSecurity},
+			body:           "```release-note\nThe foo command now supports the --bar flag.\n```",
+			cfg:            &plugins.ReleaseNote{SecurityNotePatterns: securityPatterns},
+			labelsRemoved:  []string{releaseNoteSecurity},
+		},
+		{
+			name:        "reverts require a note by default",
+			title:       `Revert "Add the --bar flag"`,
+			body:        "```release-note\n\n```",
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "revert note behavior require",
+			title:       `Revert "Add the --bar flag"`,
+			body:        "```release-note\n\n```",
+			cfg:         &plugins.ReleaseNote{RevertNoteBehavior: revertRequire},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "revert note behavior auto-none",
+			title:       `Revert "Add the --bar flag"`,
+			body:        "```release-note\n\n```",
+			cfg:         &plugins.ReleaseNote{RevertNoteBehavior: revertAutoNone},
+			labelsAdded: []string{releaseNoteNone},
+		},
+		{
+			name:        "revert note behavior auto-none doesn't apply to other PRs",
+			title:       "Add the --bar flag",
+			body:        "```release-note\n\n```",
+			cfg:         &plugins.ReleaseNote{RevertNoteBehavior: revertAutoNone},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "revert note behavior require honors the note",
+			title:       `Revert "Add the --bar flag"`,
+			body:        "```release-note\nReverted the --bar flag of the foo command.\n```",
+			cfg:         &plugins.ReleaseNote{RevertNoteBehavior: revertRequire},
+			labelsAdded: []string{releaseNote},
+		},
+		{
+			name:        "revert note behavior auto-none honors the note",
+			title:       `Revert "Add the --bar flag"`,
+			body:        "```release-note\nReverted the --bar flag of the foo command.\n```",
+			cfg:         &plugins.ReleaseNote{RevertNoteBehavior: revertAutoNone},
+			labelsAdded: []string{releaseNote},
+		},
+		{
+			name:        "feat title",
+			title:       "feat(cli): support the --bar flag",
+			body:        "```release-note\n\n```",
+			cfg:         &plugins.ReleaseNote{ConventionalCommitTitles: true},
+			labelsAdded: []string{releaseNote},
+		},
+		{
+			name:        "fix title without a block",
+			title:       "fix: don't crash on empty configs",
+			cfg:         &plugins.ReleaseNote{ConventionalCommitTitles: true},
+			labelsAdded: []string{releaseNote},
+		},
+		{
+			name:        "breaking fix title",
+			title:       "fix!: reject invalid configs",
+			body:        "```release-note\n\n```",
+			cfg:         &plugins.ReleaseNote{ConventionalCommitTitles: true},
+			labelsAdded: []string{releaseNoteActionRequired},
+		},
+		{
+			name:        "breaking change footer",
+			title:       "feat: rename the --foo flag",
+			body:        "```release-note\n\n```\n\nBREAKING CHANGE: --foo is now --bar.",
+			cfg:         &plugins.ReleaseNote{ConventionalCommitTitles: true},
+			labelsAdded: []string{releaseNoteActionRequired},
+		},
+		{
+			name:        "chore title",
+			title:       "chore: bump dependencies",
+			body:        "```release-note\n\n```",
+			cfg:         &plugins.ReleaseNote{ConventionalCommitTitles: true},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "plain title with conventional commit titles",
+			title:       "Support the --bar flag",
+			body:        "```release-note\n\n```",
+			cfg:         &plugins.ReleaseNote{ConventionalCommitTitles: true},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "fenced block wins over the conventional commit title",
+			title:       "feat!: support the --bar flag",
+			body:        "```release-note\nNONE\n```",
+			cfg:         &plugins.ReleaseNote{ConventionalCommitTitles: true},
+			labelsAdded: []string{releaseNoteNone},
+		},
 	}
 	for _, test := range tests {
 		if test.branch == "" {
 			test.branch = "master"
 		}
+		if test.cfg == nil {
+			test.cfg = &plugins.ReleaseNote{}
+		}
 		fc, pr := newFakeClient(test.body, test.branch, test.initialLabels, test.issueComments, test.parentPRs)
+		fc.ExistingLabels = append(fc.ExistingLabels, test.existingLabels...)
+		pr.PullRequest.Title = test.title
 
-		err := handlePR(fc, logrus.WithField("plugin", pluginName), pr)
+		err := handlePR(fc, logrus.WithField("plugin", pluginName), test.cfg, pr)
 		if err != nil {
-			t.Fatalf("Unexpected error from handlePR: %v", err)
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
 		}
 
 		// Check that all the correct labels (and only the correct labels) were added.
@@ -537,16 +878,3889 @@ func TestGetReleaseNote(t *testing.T) {
 			expectedReleaseNote:         "",
 			expectedReleaseNoteVariable: releaseNoteLabelNeeded,
 		},
+		{
+			body:                        "**Release note**:\n```release-note\nsomething great.\n```\n---\nOther section.\n```\ncode\n```",
+			expectedReleaseNote:         "something great.",
+			expectedReleaseNoteVariable: releaseNote,
+		},
+		{
+			body:                        "**Which issue this PR fixes**: #1\n```\ncode\n```\n---\n**Release note**:\n```release-note\nsomething great.\n```",
+			expectedReleaseNote:         "something great.",
+			expectedReleaseNoteVariable: releaseNote,
+		},
+		{
+			body:                        "**Release note**:\n---\n```\nsomething great.\n```\n---",
+			expectedReleaseNote:         "something great.",
+			expectedReleaseNoteVariable: releaseNote,
+		},
+		{
+			body:                        "```release-note\nsomething great.\n---\nwith action required.\n```\n---\n```\nNONE\n```",
+			expectedReleaseNote:         "something great.\n---\nwith action required.",
+			expectedReleaseNoteVariable: releaseNoteActionRequired,
+		},
+		{
+			body:                        "**Release note**:\n\n---\n\nNo fence here.\n---\n",
+			expectedReleaseNote:         "",
+			expectedReleaseNoteVariable: releaseNoteLabelNeeded,
+		},
+		{
+			body:                        "```release-note\nNONE\n\nSigned-off-by: Jane Doe <jane@example.com>\n```",
+			expectedReleaseNote:         "NONE",
+			expectedReleaseNoteVariable: releaseNoteNone,
+		},
+		{
+			body:                        "```release-note\nsomething great.\nCo-authored-by: John Doe <john@example.com>\nSigned-off-by: Jane Doe <jane@example.com>\n```",
+			expectedReleaseNote:         "something great.",
+			expectedReleaseNoteVariable: releaseNote,
+		},
+		{
+			body:                        "```release-note\nSigned-off-by: Jane Doe <jane@example.com>\n```",
+			expectedReleaseNote:         "",
+			expectedReleaseNoteVariable: releaseNoteLabelNeeded,
+		},
+		{
+			body:                        "```release-note\nThe signed-off-by: check is now optional.\n```",
+			expectedReleaseNote:         "The signed-off-by: check is now optional.",
+			expectedReleaseNoteVariable: releaseNote,
+		},
+		{
+			body:                        "```release-note\nThe --foo flag now takes a list. Action required: update invocations like:\n```shell\nbar --foo=a,b\n```\nto quote the list.\n```\nMore description.",
+			expectedReleaseNote:         "The --foo flag now takes a list. Action required: update invocations like:\n```shell\nbar --foo=a,b\n```\nto quote the list.",
+			expectedReleaseNoteVariable: releaseNoteActionRequired,
+		},
+		{
+			body:                        "**Release note**:\n```release-note\nUse:\n  ```yaml\n  foo: bar\n  ```\n```\n",
+			expectedReleaseNote:         "Use:\n  ```yaml\n  foo: bar\n  ```",
+			expectedReleaseNoteVariable: releaseNote,
+		},
+		{
+			body:                        "```release-note\nAdded the --foo flag.\n```\n\n```release-note\nAdded the --bar flag.\n```",
+			expectedReleaseNote:         "Added the --foo flag.\n\nAdded the --bar flag.",
+			expectedReleaseNoteVariable: releaseNote,
+		},
+		{
+			body:                        "```release-note\nNONE\n```\n\n```release-note\nAdded the --bar flag.\n```",
+			expectedReleaseNote:         "Added the --bar flag.",
+			expectedReleaseNoteVariable: releaseNote,
+		},
+		{
+			body:                        "```release-note\nNONE\n```\n```release-note\n  \n```\n```release-note\nnone\n```",
+			expectedReleaseNote:         "NONE",
+			expectedReleaseNoteVariable: releaseNoteNone,
+		},
+		{
+			body:                        "```release-note\n\n```\n```release-note\n \t\n```",
+			expectedReleaseNote:         "",
+			expectedReleaseNoteVariable: releaseNoteLabelNeeded,
+		},
+		{
+			body:                        "```release-note\nAdded the --foo flag.\n```\n```release-note\n\n```\n```release-note\nAction required: --bar was removed.\n```",
+			expectedReleaseNote:         "Added the --foo flag.\n\nAction required: --bar was removed.",
+			expectedReleaseNoteVariable: releaseNoteActionRequired,
+		},
+		{
+			body:                        "```release-note\nThe --foo flag now takes a list:\n```shell\nbar --foo=a,b\n```\n```\n\n```release-note\nAdded the --bar flag.\n```",
+			expectedReleaseNote:         "The --foo flag now takes a list:\n```shell\nbar --foo=a,b\n```\n\nAdded the --bar flag.",
+			expectedReleaseNoteVariable: releaseNote,
+		},
 	}
 
 	for testNum, test := range tests {
-		calculatedReleaseNote := getReleaseNote(test.body)
+		calculatedReleaseNote := getReleaseNote(&plugins.ReleaseNote{}, test.body)
 		if test.expectedReleaseNote != calculatedReleaseNote {
 			t.Errorf("Test %v: Expected %v as the release note, got %v", testNum, test.expectedReleaseNote, calculatedReleaseNote)
 		}
-		calculatedLabel := determineReleaseNoteLabel(test.body)
+		calculatedLabel := determineReleaseNoteLabel(&plugins.ReleaseNote{}, test.body)
 		if test.expectedReleaseNoteVariable != calculatedLabel {
 			t.Errorf("Test %v: Expected %v as the release note label, got %v", testNum, test.expectedReleaseNoteVariable, calculatedLabel)
 		}
 	}
 }
+
+func TestRegexesFor(t *testing.T) {
+	base := &plugins.ReleaseNote{Repos: []string{"org"}}
+	if regexesFor(base) != regexesFor(&plugins.ReleaseNote{Repos: []string{"org/repo"}}) {
+		t.Error("Expected the same compiled regexes for configs with identical regex fields.")
+	}
+	if regexesFor(base) != regexesFor(&plugins.ReleaseNote{NoteFences: defaultNoteFences}) {
+		t.Error("Expected the same compiled regexes for an empty config and an explicitly defaulted config.")
+	}
+	changed := []*plugins.ReleaseNote{
+		{NoteFences: []string{"changelog"}},
+		{NoteHeading: "Changelog"},
+		{ActionRequiredPhrases: []string{"breaking change"}},
+	}
+	for _, cfg := range changed {
+		if regexesFor(base) == regexesFor(cfg) {
+			t.Errorf("Expected new compiled regexes for config %+v.", *cfg)
+		}
+	}
+
+	cfg := &plugins.ReleaseNote{
+		NoteFences:            []string{"changelog"},
+		ActionRequiredPhrases: []string{"breaking change"},
+	}
+	if label := determineReleaseNoteLabel(cfg, "```changelog\nBreaking Change: foo was removed.\n```"); label != releaseNoteActionRequired {
+		t.Errorf("Expected %s from a configured fence and phrase, got %s.", releaseNoteActionRequired, label)
+	}
+}
+
+func TestNoteFences(t *testing.T) {
+	withChangelog := []string{"release-note", "release-notes", "changelog"}
+	tests := []struct {
+		name     string
+		fences   []string
+		body     string
+		expected string
+	}{
+		{
+			name:     "release-note fence by default",
+			body:     "```release-note\nThe foo command now supports the --bar flag.\n```",
+			expected: "The foo command now supports the --bar flag.",
+		},
+		{
+			name:     "release-notes fence by default",
+			body:     "```release-notes\nThe foo command now supports the --bar flag.\n```",
+			expected: "The foo command now supports the --bar flag.",
+		},
+		{
+			name: "no changelog fence by default",
+			body: "```changelog\nThe foo command now supports the --bar flag.\n```",
+		},
+		{
+			name:     "configured changelog fence",
+			fences:   withChangelog,
+			body:     "```changelog\nThe foo command now supports the --bar flag.\n```",
+			expected: "The foo command now supports the --bar flag.",
+		},
+		{
+			name:     "default fences along with a configured changelog fence",
+			fences:   withChangelog,
+			body:     "```release-notes\nThe foo command now supports the --bar flag.\n```",
+			expected: "The foo command now supports the --bar flag.",
+		},
+	}
+	for _, test := range tests {
+		cfg := &plugins.ReleaseNote{NoteFences: test.fences}
+		if actual := getReleaseNote(cfg, test.body); actual != test.expected {
+			t.Errorf("(%s): Expected release note %q, but got %q.", test.name, test.expected, actual)
+		}
+	}
+}
+
+const benchmarkBody = "**What this PR does / why we need it**:\nStuff.\n\n**Release note**:\n```release-note\nSomething with action required.\n```\n"
+
+func BenchmarkDetermineReleaseNoteLabel(b *testing.B) {
+	cfg := &plugins.ReleaseNote{}
+	for i := 0; i < b.N; i++ {
+		determineReleaseNoteLabel(cfg, benchmarkBody)
+	}
+}
+
+// BenchmarkDetermineReleaseNoteLabelUncached compiles the regexes on every
+// call, which is what handlePR would do without regexCache.
+func BenchmarkDetermineReleaseNoteLabelUncached(b *testing.B) {
+	cfg := &plugins.ReleaseNote{}
+	for i := 0; i < b.N; i++ {
+		res := compileRegexes(cfg)
+		note := strings.ToLower(strings.TrimSpace(res.noteMatcher.FindStringSubmatch(benchmarkBody)[1]))
+		res.actionRequired.MatchString(note)
+	}
+}
+
+func TestRelativeLinkSuggestion(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectComment bool
+	}{
+		{
+			name:          "relative link",
+			body:          "```release-note\nAdded a flag, [see](../docs/x.md).\n```",
+			expectComment: true,
+		},
+		{
+			name: "absolute link",
+			body: "```release-note\nAdded a flag, [see](https://k8s.io/docs/x.md).\n```",
+		},
+		{
+			name: "no links",
+			body: "```release-note\nAdded a flag.\n```",
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", []string{releaseNote}, nil, nil)
+		cfg := &plugins.ReleaseNote{WarnRelativeLinks: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		commented := len(fc.IssueCommentsAdded) > 0
+		if commented != test.expectComment {
+			t.Errorf("(%s): Expected comment: %t, but got comments %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+		if len(fc.LabelsRemoved) > 0 || len(fc.LabelsAdded) != 1 {
+			t.Errorf("(%s): Expected labels to be untouched, but added %q and removed %q.", test.name, fc.LabelsAdded, fc.LabelsRemoved)
+		}
+	}
+}
+
+func TestRequireNoteOverChangedLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		additions   int
+		deletions   int
+		labelsAdded []string
+	}{
+		{
+			name:        "large PR with empty block is blocked",
+			body:        "```release-note\n```",
+			additions:   80,
+			deletions:   30,
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "small PR with empty block is auto-none",
+			body:        "```release-note\n```",
+			additions:   5,
+			deletions:   5,
+			labelsAdded: []string{releaseNoteNone},
+		},
+		{
+			name:        "large PR with a real note passes",
+			body:        "```release-note\nA real note.\n```",
+			additions:   80,
+			deletions:   30,
+			labelsAdded: []string{releaseNote},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		fc.PullRequestChanges = map[int][]github.PullRequestChange{
+			1: {
+				{Filename: "a.go", Additions: test.additions},
+				{Filename: "b.go", Deletions: test.deletions},
+			},
+		}
+		cfg := &plugins.ReleaseNote{RequireNoteOverChangedLines: 100}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		expectLabels := formatLabels(1, test.labelsAdded...)
+		if !reflect.DeepEqual(fc.LabelsAdded, expectLabels) {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expectLabels, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestInheritDependsOnNote(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		labelsAdded []string
+	}{
+		{
+			name:        "empty block inherits the note of the PR it depends on",
+			body:        "Depends on #2\n```release-note\n```",
+			labelsAdded: []string{releaseNoteActionRequired},
+		},
+		{
+			name:        "part of a stack whose top PR has no note",
+			body:        "Part of #3\n```release-note\n```",
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "own note wins",
+			body:        "Depends on #2\n```release-note\nMy own note.\n```",
+			labelsAdded: []string{releaseNote},
+		},
+		{
+			name:        "references to other repos are ignored",
+			body:        "Depends on other/repo#2\n```release-note\n```",
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		fc.PullRequests = map[int]*github.PullRequest{
+			2: {Number: 2, Body: "```release-note\nFoo was removed, action required.\n```"},
+			3: {Number: 3, Body: "```release-note\n```"},
+		}
+		cfg := &plugins.ReleaseNote{InheritDependsOnNote: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		expectLabels := formatLabels(1, test.labelsAdded...)
+		if !reflect.DeepEqual(fc.LabelsAdded, expectLabels) {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expectLabels, fc.LabelsAdded)
+		}
+	}
+}
+
+// fieldsHook records the fields of every log entry it fires for.
+type fieldsHook struct {
+	entries []logrus.Fields
+}
+
+func (h *fieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *fieldsHook) Fire(e *logrus.Entry) error {
+	h.entries = append(h.entries, e.Data)
+	return nil
+}
+
+func TestLabelDiffLogged(t *testing.T) {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	hook := &fieldsHook{}
+	logger.Hooks.Add(hook)
+
+	fc, pr := newFakeClient("```release-note\nA note.\n```", "master", []string{lgtmLabel, releaseNoteLabelNeeded}, nil, nil)
+	if err := handlePR(fc, logger.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+
+	var found bool
+	for _, fields := range hook.entries {
+		before, ok := fields["labels_before"]
+		if !ok {
+			continue
+		}
+		if found {
+			t.Errorf("Expected a single entry with labels_before, but got another: %v", fields)
+		}
+		found = true
+		if expected := []string{releaseNoteLabelNeeded}; !reflect.DeepEqual(before, expected) {
+			t.Errorf("Expected labels_before %q, but got %q.", expected, before)
+		}
+		if expected := []string{releaseNote}; !reflect.DeepEqual(fields["labels_after"], expected) {
+			t.Errorf("Expected labels_after %q, but got %q.", expected, fields["labels_after"])
+		}
+	}
+	if !found {
+		t.Error("Expected an entry with labels_before and labels_after fields, but found none.")
+	}
+}
+
+func TestSuggestionFenceHint(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		issueComments []string
+		expectHint    bool
+	}{
+		{
+			name:       "empty release-note block and populated suggestion block",
+			body:       "```release-note\n```\n```suggestion\nAdded the --foo flag.\n```",
+			expectHint: true,
+		},
+		{
+			name:          "hint already posted",
+			body:          "```release-note\n```\n```suggestion\nAdded the --foo flag.\n```",
+			issueComments: []string{suggestionFenceBody},
+		},
+		{
+			name: "empty suggestion block",
+			body: "```release-note\n```\n```suggestion\n```",
+		},
+		{
+			name: "correctly placed note",
+			body: "```release-note\nAdded the --foo flag.\n```",
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, test.issueComments, nil)
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		var hinted bool
+		for _, c := range fc.IssueCommentsAdded {
+			if strings.Contains(c, suggestionFenceBody) {
+				hinted = true
+			}
+		}
+		if hinted != test.expectHint {
+			t.Errorf("(%s): Expected hint: %t, but got comments %q.", test.name, test.expectHint, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestReadOnlyRepos(t *testing.T) {
+	tests := []struct {
+		name          string
+		readOnlyRepos []string
+		expectChanges bool
+	}{
+		{
+			name:          "repo is read-only",
+			readOnlyRepos: []string{"other/repo", "org/repo"},
+		},
+		{
+			name:          "org is read-only",
+			readOnlyRepos: []string{"org"},
+		},
+		{
+			name:          "other repos are read-only",
+			readOnlyRepos: []string{"org/other"},
+			expectChanges: true,
+		},
+	}
+	for _, test := range tests {
+		cfg := &plugins.ReleaseNote{ReadOnlyRepos: test.readOnlyRepos}
+
+		fc, pr := newFakeClient("```release-note\nA note.\n```", "master", []string{releaseNoteLabelNeeded}, nil, nil)
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if changed := len(fc.LabelsAdded) > 1 || len(fc.LabelsRemoved) > 0; changed != test.expectChanges {
+			t.Errorf("(%s): Expected label changes from handlePR: %t, but added %q and removed %q.", test.name, test.expectChanges, fc.LabelsAdded, fc.LabelsRemoved)
+		}
+
+		fc = &fakegithub.FakeClient{IssueComments: map[int][]github.IssueComment{}}
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: "/release-note-none", User: github.User{Login: "a"}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      1,
+				PullRequest: &struct{}{},
+				Labels:      []github.Label{{Name: releaseNoteLabelNeeded}},
+			},
+			Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+		}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		if changed := len(fc.LabelsAdded) > 0 || len(fc.LabelsRemoved) > 0; changed != test.expectChanges {
+			t.Errorf("(%s): Expected label changes from handleComment: %t, but added %q and removed %q.", test.name, test.expectChanges, fc.LabelsAdded, fc.LabelsRemoved)
+		}
+	}
+}
+
+func TestUseReviewForGuidance(t *testing.T) {
+	cfg := &plugins.ReleaseNote{UseReviewForGuidance: true}
+	fc, pr := newFakeClient("```release-note\n```", "master", nil, nil, nil)
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if len(fc.IssueCommentsAdded) != 0 {
+		t.Errorf("Expected no comments, but got %q.", fc.IssueCommentsAdded)
+	}
+	if len(fc.Reviews[1]) != 1 || fc.Reviews[1][0].State != github.ReviewStateChangesRequested {
+		t.Fatalf("Expected a single review requesting changes, but got %+v.", fc.Reviews[1])
+	}
+
+	// The needed label is now on the PR, so resolving the note should dismiss the review.
+	pr.PullRequest.Body = "```release-note\nA note.\n```"
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if expected := []string{"org/repo#1:0"}; !reflect.DeepEqual(fc.ReviewsDismissed, expected) {
+		t.Errorf("Expected reviews %q to be dismissed, but got %q.", expected, fc.ReviewsDismissed)
+	}
+	if len(fc.Reviews[1]) != 1 {
+		t.Errorf("Expected no new reviews, but got %+v.", fc.Reviews[1])
+	}
+}
+
+func TestMentionOnNeeded(t *testing.T) {
+	cfg := &plugins.ReleaseNote{
+		MentionOnNeeded:   []string{"kubernetes/release-team", "@someone"},
+		MentionAfterEdits: 2,
+	}
+	fc, pr := newFakeClient("```release-note\n```", "master", nil, nil, nil)
+	mentions := func() int {
+		var n int
+		for _, c := range fc.IssueCommentsAdded {
+			if strings.Contains(c, "@kubernetes/release-team @someone") {
+				n++
+			}
+		}
+		return n
+	}
+	// The first event posts the guidance, and each following edit counts
+	// towards the threshold.
+	for edit, expected := range []int{0, 0, 1, 1} {
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("Unexpected error from handlePR: %v", err)
+		}
+		if got := mentions(); got != expected {
+			t.Errorf("After event %d, expected %d mention comments, but got %d: %q", edit, expected, got, fc.IssueCommentsAdded)
+		}
+	}
+	if !strings.Contains(fc.IssueComments[1][0].Body, fmt.Sprintf(unresolvedEditsFormat, 3)) {
+		t.Errorf("Expected the guidance comment to count 3 unresolved edits, but got %q.", fc.IssueComments[1][0].Body)
+	}
+}
+
+func TestHasNeededLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   []string
+		expected bool
+	}{
+		{name: "no labels"},
+		{name: "other labels", labels: []string{lgtmLabel, releaseNote}},
+		{name: "current label", labels: []string{releaseNoteLabelNeeded}, expected: true},
+		{name: "deprecated label only", labels: []string{deprecatedReleaseNoteLabelNeeded}, expected: true},
+		{name: "both labels", labels: []string{releaseNoteLabelNeeded, deprecatedReleaseNoteLabelNeeded}, expected: true},
+	}
+	for _, test := range tests {
+		var labels []github.Label
+		for _, l := range test.labels {
+			labels = append(labels, github.Label{Name: l})
+		}
+		if actual := hasNeededLabel(labels); actual != test.expected {
+			t.Errorf("(%s): Expected %t, but got %t.", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestNoTemplateBehavior(t *testing.T) {
+	tests := []struct {
+		name          string
+		behavior      string
+		body          string
+		labelsAdded   []string
+		expectComment bool
+	}{
+		{
+			name:          "default blocks PRs without a template",
+			body:          "Fixes a bug.",
+			labelsAdded:   []string{releaseNoteLabelNeeded},
+			expectComment: true,
+		},
+		{
+			name:          "block blocks PRs without a template",
+			behavior:      noTemplateBlock,
+			body:          "Fixes a bug.",
+			labelsAdded:   []string{releaseNoteLabelNeeded},
+			expectComment: true,
+		},
+		{
+			name:        "auto-none labels PRs without a template release-note-none",
+			behavior:    noTemplateAutoNone,
+			body:        "Fixes a bug.",
+			labelsAdded: []string{releaseNoteNone},
+		},
+		{
+			name:     "ignore leaves PRs without a template unlabeled",
+			behavior: noTemplateIgnore,
+			body:     "Fixes a bug.",
+		},
+		{
+			name:          "auto-none still blocks an empty fence",
+			behavior:      noTemplateAutoNone,
+			body:          "Fixes a bug.\n```release-note\n```",
+			labelsAdded:   []string{releaseNoteLabelNeeded},
+			expectComment: true,
+		},
+		{
+			name:          "ignore still blocks an empty heading",
+			behavior:      noTemplateIgnore,
+			body:          "Fixes a bug.\n**Release note**:\n",
+			labelsAdded:   []string{releaseNoteLabelNeeded},
+			expectComment: true,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{NoTemplateBehavior: test.behavior}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, test.labelsAdded...); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		if commented := len(fc.IssueCommentsAdded) > 0; commented != test.expectComment {
+			t.Errorf("(%s): Expected comment: %t, but got comments %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestReportStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		branch        string
+		parentPRs     map[int]string
+		action        github.PullRequestEventAction
+		reportStatus  bool
+		labels        plugins.ReleaseNoteLabels
+		expectedState string
+		expectedDesc  string
+	}{
+		{
+			name:          "release note present",
+			body:          "```release-note\nA note.\n```",
+			reportStatus:  true,
+			expectedState: github.StatusSuccess,
+			expectedDesc:  "release note present",
+		},
+		{
+			name:          "action required release note",
+			body:          "```release-note\nAction required: do things.\n```",
+			reportStatus:  true,
+			expectedState: github.StatusSuccess,
+			expectedDesc:  "action required release note present",
+		},
+		{
+			name:          "release note none",
+			body:          "```release-note\nNONE\n```",
+			reportStatus:  true,
+			expectedState: github.StatusSuccess,
+			expectedDesc:  "no release note needed",
+		},
+		{
+			name:          "needs release note",
+			body:          "```release-note\n```",
+			reportStatus:  true,
+			expectedState: github.StatusFailure,
+			expectedDesc:  "needs release note",
+		},
+		{
+			name:          "renamed labels are named",
+			body:          "```release-note\nNONE\n```",
+			reportStatus:  true,
+			labels:        plugins.ReleaseNoteLabels{None: "changelog/none"},
+			expectedState: github.StatusSuccess,
+			expectedDesc:  "no release note needed (changelog/none)",
+		},
+		{
+			name:          "cherry-pick of a PR with a release note",
+			body:          "Cherry pick of #2 on release-1.8.",
+			branch:        "release-1.8",
+			parentPRs:     map[int]string{2: releaseNote},
+			reportStatus:  true,
+			expectedState: github.StatusSuccess,
+			expectedDesc:  "release note not required",
+		},
+		{
+			name:          "new commits get a status",
+			body:          "```release-note\nA note.\n```",
+			action:        github.PullRequestActionSynchronize,
+			reportStatus:  true,
+			expectedState: github.StatusSuccess,
+			expectedDesc:  "release note present",
+		},
+		{
+			name: "no status unless configured",
+			body: "```release-note\nA note.\n```",
+		},
+		{
+			name:   "new commits are ignored unless a status is configured",
+			body:   "```release-note\n```",
+			action: github.PullRequestActionSynchronize,
+		},
+	}
+	for _, test := range tests {
+		if test.branch == "" {
+			test.branch = "master"
+		}
+		fc, pr := newFakeClient(test.body, test.branch, nil, nil, test.parentPRs)
+		fc.ExistingLabels = append(fc.ExistingLabels, test.labels.None)
+		pr.PullRequest.Head.SHA = "abcdef"
+		if test.action != "" {
+			pr.Action = test.action
+		}
+		cfg := &plugins.ReleaseNote{ReportStatus: test.reportStatus, Labels: test.labels}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		statuses := fc.CreatedStatuses["abcdef"]
+		if test.expectedDesc == "" {
+			if len(statuses) > 0 {
+				t.Errorf("(%s): Expected no status, but got %v.", test.name, statuses)
+			}
+			if pr.Action == github.PullRequestActionSynchronize && len(fc.LabelsAdded) > 0 {
+				t.Errorf("(%s): Expected no labels to be added, but got %q.", test.name, fc.LabelsAdded)
+			}
+			continue
+		}
+		expected := []github.Status{{State: test.expectedState, Description: test.expectedDesc, Context: statusContext}}
+		if !reflect.DeepEqual(statuses, expected) {
+			t.Errorf("(%s): Expected statuses %v, but got %v.", test.name, expected, statuses)
+		}
+	}
+}
+
+func TestUnexpectedBaseRef(t *testing.T) {
+	tests := []struct {
+		name   string
+		branch string
+	}{
+		{name: "empty base ref", branch: ""},
+		{name: "unexpected base ref", branch: "renamed-branch"},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("Cherry pick of #2 on release-1.8.", test.branch, nil, nil, map[int]string{2: releaseNote})
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		// The parent's release note must not satisfy the process.
+		expected := formatLabels(2, releaseNote)
+		expected = append(expected, formatLabels(1, releaseNoteLabelNeeded)...)
+		if !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestRstNoteDirective(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		disabled      bool
+		expectedNote  string
+		expectedLabel string
+	}{
+		{
+			name:          "rst directive note",
+			body:          "Adds a flag.\n\n.. release-note::\n\n   The ``--verbose`` flag is now supported.\n   It defaults to false.\n\nSee the docs.",
+			expectedNote:  "The ``--verbose`` flag is now supported.\nIt defaults to false.",
+			expectedLabel: releaseNote,
+		},
+		{
+			name:          "rst directive with none",
+			body:          ".. release-note::\n\n    NONE\n",
+			expectedNote:  "NONE",
+			expectedLabel: releaseNoteNone,
+		},
+		{
+			name:          "rst directive with content on the directive line",
+			body:          ".. release-note:: Action required: rename your config.\r\n",
+			expectedNote:  "Action required: rename your config.",
+			expectedLabel: releaseNoteActionRequired,
+		},
+		{
+			name:          "empty rst directive",
+			body:          ".. release-note::\n\nSee the docs.",
+			expectedLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:          "markdown fence takes precedence",
+			body:          "```release-note\nThe markdown note.\n```\n\n.. release-note::\n\n   The rst note.\n",
+			expectedNote:  "The markdown note.",
+			expectedLabel: releaseNote,
+		},
+		{
+			name:          "rst directive ignored unless enabled",
+			body:          ".. release-note::\n\n   The rst note.\n",
+			disabled:      true,
+			expectedLabel: releaseNoteLabelNeeded,
+		},
+	}
+	for _, test := range tests {
+		cfg := &plugins.ReleaseNote{RstNoteDirective: !test.disabled}
+		if note := getReleaseNote(cfg, test.body); note != test.expectedNote {
+			t.Errorf("(%s): Expected note %q, but got %q.", test.name, test.expectedNote, note)
+		}
+		if label := determineReleaseNoteLabel(cfg, test.body); label != test.expectedLabel {
+			t.Errorf("(%s): Expected label %q, but got %q.", test.name, test.expectedLabel, label)
+		}
+	}
+}
+
+func TestNonePreconditions(t *testing.T) {
+	tests := []struct {
+		name          string
+		user          string
+		labels        []string
+		reviews       []github.Review
+		preconditions []plugins.NonePrecondition
+		expectLabel   bool
+	}{
+		{
+			name:        "no preconditions",
+			user:        "m",
+			expectLabel: true,
+		},
+		{
+			name:          "member outside the team",
+			user:          "m",
+			preconditions: []plugins.NonePrecondition{{TeamIDs: []int{42}}},
+		},
+		{
+			name:          "member of the team",
+			user:          "sig-lead",
+			preconditions: []plugins.NonePrecondition{{TeamIDs: []int{42}}},
+			expectLabel:   true,
+		},
+		{
+			name:          "unapproved PR",
+			user:          "m",
+			reviews:       []github.Review{{State: github.ReviewStateCommented}},
+			preconditions: []plugins.NonePrecondition{{Approved: true}},
+		},
+		{
+			name:          "approved PR",
+			user:          "m",
+			reviews:       []github.Review{{State: github.ReviewStateApproved}},
+			preconditions: []plugins.NonePrecondition{{Approved: true}},
+			expectLabel:   true,
+		},
+		{
+			name:          "any condition of a precondition is enough",
+			user:          "m",
+			labels:        []string{"approved"},
+			preconditions: []plugins.NonePrecondition{{TeamIDs: []int{42}, Labels: []string{"approved"}}},
+			expectLabel:   true,
+		},
+		{
+			name:   "every precondition must be met",
+			user:   "sig-lead",
+			labels: []string{"approved"},
+			preconditions: []plugins.NonePrecondition{
+				{TeamIDs: []int{42}},
+				{Approved: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		fc := &fakegithub.FakeClient{
+			IssueComments: map[int][]github.IssueComment{},
+			OrgMembers:    []string{"m", "sig-lead"},
+			Reviews:       map[int][]github.Review{5: test.reviews},
+		}
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: "/release-note-none", User: github.User{Login: test.user}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      5,
+				PullRequest: &struct{}{},
+			},
+		}
+		for _, l := range test.labels {
+			ice.Issue.Labels = append(ice.Issue.Labels, github.Label{Name: l})
+		}
+		cfg := &plugins.ReleaseNote{NonePreconditions: test.preconditions}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		if labeled := len(fc.LabelsAdded) > 0; labeled != test.expectLabel {
+			t.Errorf("(%s): Expected label: %t, but got labels %q.", test.name, test.expectLabel, fc.LabelsAdded)
+		}
+		var rejected, recorded bool
+		for _, c := range fc.IssueComments[5] {
+			if strings.HasPrefix(c.Body, acceptedNoneMarker) {
+				recorded = true
+			} else {
+				rejected = true
+			}
+		}
+		if rejected == test.expectLabel {
+			t.Errorf("(%s): Expected a comment explaining the precondition: %t, but got %v.", test.name, !test.expectLabel, fc.IssueComments[5])
+		}
+		// Only commands accepted under preconditions are recorded.
+		if expectRecord := test.expectLabel && len(test.preconditions) > 0; recorded != expectRecord {
+			t.Errorf("(%s): Expected the command to be recorded: %t, but got %v.", test.name, expectRecord, fc.IssueComments[5])
+		}
+
+		// A later PR event only honors the command if it was accepted.
+		fc.IssueComments[5] = append(fc.IssueComments[5], ice.Comment)
+		fc.LabelsAdded = nil
+		pr := &github.PullRequestEvent{
+			Action:      github.PullRequestActionEdited,
+			Number:      5,
+			PullRequest: github.PullRequest{Number: 5, User: github.User{Login: "a"}, Base: github.PullRequestBranch{Ref: "master"}},
+			Repo:        github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+		}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if honored := len(sliceDifference(formatLabels(5, releaseNoteNone), fc.LabelsAdded)) == 0; honored != test.expectLabel {
+			t.Errorf("(%s): Expected a later event to honor the command: %t, but got labels added %q.", test.name, test.expectLabel, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestMissingParentReference(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		branch        string
+		parentPRs     map[int]string
+		comments      []string
+		expectMessage bool
+	}{
+		{
+			name:          "release branch PR without a parent reference",
+			body:          "```release-note\n```",
+			branch:        "release-1.8",
+			expectMessage: true,
+		},
+		{
+			name:     "already told about the missing reference",
+			body:     "```release-note\n```",
+			branch:   "release-1.8",
+			comments: []string{missingParentBody},
+		},
+		{
+			name:      "release branch PR with a noteless parent",
+			body:      "Cherry pick of #2 on release-1.8.",
+			branch:    "release-1.8",
+			parentPRs: map[int]string{2: releaseNoteNone},
+		},
+		{
+			name:   "release branch PR with its own note",
+			body:   "```release-note\nA note.\n```",
+			branch: "release-1.8",
+		},
+		{
+			name:   "master PR without a note",
+			body:   "```release-note\n```",
+			branch: "master",
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, test.branch, nil, test.comments, test.parentPRs)
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		messaged := false
+		for _, c := range fc.IssueCommentsAdded {
+			if strings.Contains(c, missingParentBody) {
+				messaged = true
+			}
+		}
+		if messaged != test.expectMessage {
+			t.Errorf("(%s): Expected the missing parent message: %t, but got comments %q.", test.name, test.expectMessage, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+// deleteCounter counts calls to DeleteStaleComments.
+type deleteCounter struct {
+	*fakegithub.FakeClient
+	calls int
+}
+
+func (d *deleteCounter) DeleteStaleComments(org, repo string, number int, comments []github.IssueComment, isStale func(github.IssueComment) bool) error {
+	d.calls++
+	return d.FakeClient.DeleteStaleComments(org, repo, number, comments, isStale)
+}
+
+func TestKeepStaleComments(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		fc, pr := newFakeClient("```release-note\nA note.\n```", "master", []string{releaseNote}, nil, nil)
+		fc.IssueComments[1] = []github.IssueComment{{ID: 1, Body: releaseNoteBody(&plugins.ReleaseNote{}), User: github.User{Login: "k8s-ci-robot"}}}
+		dc := &deleteCounter{FakeClient: fc}
+		if err := handlePR(dc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{KeepStaleComments: keep}, pr); err != nil {
+			t.Fatalf("(keep=%t): Unexpected error from handlePR: %v", keep, err)
+		}
+		if expected := formatLabels(1, releaseNote); !reflect.DeepEqual(fc.LabelsAdded, expected) || len(fc.LabelsRemoved) > 0 {
+			t.Errorf("(keep=%t): Expected labels %q, but got %q added and %q removed.", keep, expected, fc.LabelsAdded, fc.LabelsRemoved)
+		}
+		if keep {
+			if dc.calls > 0 || len(fc.IssueCommentsDeleted) > 0 {
+				t.Errorf("(keep=true): Expected no stale comment cleanup, but got %d calls deleting %q.", dc.calls, fc.IssueCommentsDeleted)
+			}
+		} else if dc.calls != 1 || len(fc.IssueCommentsDeleted) != 1 {
+			t.Errorf("(keep=false): Expected the stale comment to be deleted, but got %d calls deleting %q.", dc.calls, fc.IssueCommentsDeleted)
+		}
+	}
+}
+
+func TestWelcomeFirstTimers(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		action        github.PullRequestEventAction
+		mergedPRs     []github.Issue
+		disabled      bool
+		expectWelcome bool
+		expectNeeded  bool
+	}{
+		{
+			name:          "first-timer without a note",
+			body:          "```release-note\n```",
+			expectWelcome: true,
+			expectNeeded:  true,
+		},
+		{
+			name:          "first-timer with a note",
+			body:          "```release-note\nA note.\n```",
+			expectWelcome: true,
+		},
+		{
+			name:         "repeat contributor without a note",
+			body:         "```release-note\n```",
+			mergedPRs:    []github.Issue{{Number: 2, PullRequest: &struct{}{}}},
+			expectNeeded: true,
+		},
+		{
+			name:         "first-timer editing their PR",
+			body:         "```release-note\n```",
+			action:       github.PullRequestActionEdited,
+			expectNeeded: true,
+		},
+		{
+			name:         "disabled",
+			body:         "```release-note\n```",
+			disabled:     true,
+			expectNeeded: true,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		fc.Issues = test.mergedPRs
+		pr.Action = github.PullRequestActionOpened
+		if test.action != "" {
+			pr.Action = test.action
+		}
+		cfg := &plugins.ReleaseNote{WelcomeFirstTimers: !test.disabled}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		var welcomed, needed, suffixed bool
+		for _, c := range fc.IssueCommentsAdded {
+			welcomed = welcomed || strings.Contains(c, welcomeBody)
+			needed = needed || strings.Contains(c, releaseNoteBody(&plugins.ReleaseNote{}))
+			suffixed = suffixed || strings.Contains(c, releaseNoteSuffix(&plugins.ReleaseNote{}))
+		}
+		if welcomed != test.expectWelcome || needed != test.expectNeeded {
+			t.Errorf("(%s): Expected welcome: %t and needed: %t, but got comments %q.", test.name, test.expectWelcome, test.expectNeeded, fc.IssueCommentsAdded)
+		}
+		// Only PRs that need a note are told which labels are required.
+		if suffixed != test.expectNeeded {
+			t.Errorf("(%s): Expected the required labels to be listed: %t, but got comments %q.", test.name, test.expectNeeded, fc.IssueCommentsAdded)
+		}
+		if len(fc.IssueCommentsAdded) > 1 {
+			t.Errorf("(%s): Expected at most one comment, but got %q.", test.name, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestManualRemovalCooldown(t *testing.T) {
+	tests := []struct {
+		name        string
+		cooldown    string
+		events      []github.ListedIssueEvent
+		expectLabel string
+	}{
+		{
+			name:     "recent manual removal",
+			cooldown: "24h",
+			events: []github.ListedIssueEvent{
+				{Event: github.ListedIssueEventUnlabeled, Label: github.Label{Name: releaseNoteNone}, Actor: github.User{Login: "maintainer"}, CreatedAt: time.Now().Add(-time.Hour)},
+			},
+			expectLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:     "old manual removal",
+			cooldown: "24h",
+			events: []github.ListedIssueEvent{
+				{Event: github.ListedIssueEventUnlabeled, Label: github.Label{Name: releaseNoteNone}, Actor: github.User{Login: "maintainer"}, CreatedAt: time.Now().Add(-48 * time.Hour)},
+			},
+			expectLabel: releaseNoteNone,
+		},
+		{
+			name:     "recent removal by the bot",
+			cooldown: "24h",
+			events: []github.ListedIssueEvent{
+				{Event: github.ListedIssueEventUnlabeled, Label: github.Label{Name: releaseNoteNone}, Actor: github.User{Login: "k8s-ci-robot"}, CreatedAt: time.Now().Add(-time.Hour)},
+			},
+			expectLabel: releaseNoteNone,
+		},
+		{
+			name: "recent manual removal without a cooldown",
+			events: []github.ListedIssueEvent{
+				{Event: github.ListedIssueEventUnlabeled, Label: github.Label{Name: releaseNoteNone}, Actor: github.User{Login: "maintainer"}, CreatedAt: time.Now().Add(-time.Hour)},
+			},
+			expectLabel: releaseNoteNone,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\nNONE\n```", "master", nil, nil, nil)
+		fc.IssueEvents = map[int][]github.ListedIssueEvent{1: test.events}
+		cfg := &plugins.ReleaseNote{ManualRemovalCooldown: test.cooldown}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, test.expectLabel); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestActionRequiredDelimiter(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		initialLabels  []string
+		delimiter      string
+		expectLabel    string
+		expectGuidance bool
+	}{
+		{
+			name:        "action required with the delimiter",
+			body:        "```release-note\nThe foo flag was renamed.\n**Action required:** rename foo to bar.\n```",
+			delimiter:   "**Action required:**",
+			expectLabel: releaseNoteActionRequired,
+		},
+		{
+			name:           "action required without the delimiter",
+			body:           "```release-note\nThe foo flag was renamed, action required.\n```",
+			delimiter:      "**Action required:**",
+			expectLabel:    releaseNoteLabelNeeded,
+			expectGuidance: true,
+		},
+		{
+			name:           "action required by label without the delimiter",
+			body:           "```release-note\nThe foo flag was renamed.\n```",
+			initialLabels:  []string{"kind/action-required"},
+			delimiter:      "**Action required:**",
+			expectLabel:    releaseNoteLabelNeeded,
+			expectGuidance: true,
+		},
+		{
+			name:        "note that doesn't require action",
+			body:        "```release-note\nThe foo flag was added.\n```",
+			delimiter:   "**Action required:**",
+			expectLabel: releaseNote,
+		},
+		{
+			name:        "no delimiter configured",
+			body:        "```release-note\nThe foo flag was renamed, action required.\n```",
+			expectLabel: releaseNoteActionRequired,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		cfg := &plugins.ReleaseNote{
+			ActionRequiredDelimiter: test.delimiter,
+			ActionRequiredLabels:    []string{"kind/action-required"},
+		}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		expected := formatLabels(1, append(test.initialLabels, test.expectLabel)...)
+		if !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		guided := false
+		for _, c := range fc.IssueCommentsAdded {
+			guided = guided || strings.Contains(c, actionDelimiterPrefix)
+		}
+		if guided != test.expectGuidance {
+			t.Errorf("(%s): Expected delimiter guidance: %t, but got comments %q.", test.name, test.expectGuidance, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+// staleLabelClient returns the PR's initial labels, as if label changes
+// hadn't propagated yet.
+type staleLabelClient struct {
+	*fakegithub.FakeClient
+	labels []github.Label
+}
+
+func (c *staleLabelClient) GetIssueLabels(org, repo string, number int) ([]github.Label, error) {
+	return c.labels, nil
+}
+
+func TestDuplicateCommentWindow(t *testing.T) {
+	for _, window := range []string{"", "1m"} {
+		fc, pr := newFakeClient("```release-note\n```", "master", nil, nil, nil)
+		gc := &staleLabelClient{FakeClient: fc}
+		cfg := &plugins.ReleaseNote{DuplicateCommentWindow: window}
+		for i := 0; i < 2; i++ {
+			if err := handlePR(gc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+				t.Fatalf("(window=%q): Unexpected error from handlePR: %v", window, err)
+			}
+		}
+		expected := 2
+		if window != "" {
+			expected = 1
+		}
+		if len(fc.IssueCommentsAdded) != expected {
+			t.Errorf("(window=%q): Expected %d comments, but got %q.", window, expected, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestStrictNoneOnly(t *testing.T) {
+	strict, lenient := true, false
+	tests := []struct {
+		name     string
+		note     string
+		strict   *bool
+		expected string
+	}{
+		{name: "exact none", note: "none", expected: releaseNoteNone},
+		{name: "exact NONE", note: "NONE", expected: releaseNoteNone},
+		{name: "none with prose", note: "none for users; internal metric renamed", expected: releaseNote},
+		{name: "none with prose, strict", note: "none; internal metric renamed", strict: &strict, expected: releaseNote},
+		{name: "exact none, lenient", note: "None", strict: &lenient, expected: releaseNoteNone},
+		{name: "none with prose, lenient", note: "none; internal metric renamed", strict: &lenient, expected: releaseNoteNone},
+		{name: "word starting with none, lenient", note: "nonexistent paths are now ignored", strict: &lenient, expected: releaseNote},
+	}
+	for _, test := range tests {
+		cfg := &plugins.ReleaseNote{StrictNoneOnly: test.strict}
+		body := "```release-note\n" + test.note + "\n```"
+		if actual := determineReleaseNoteLabel(cfg, body); actual != test.expected {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestNoneSynonyms(t *testing.T) {
+	tests := []struct {
+		name     string
+		note     string
+		synonyms []string
+		expected string
+	}{
+		{name: "none", note: "none", expected: releaseNoteNone},
+		{name: "N/A", note: "N/A", expected: releaseNoteNone},
+		{name: "na", note: "na", expected: releaseNoteNone},
+		{name: "not applicable", note: "  Not Applicable ", expected: releaseNoteNone},
+		{name: "sentence starting with none", note: "None of the APIs changed but X did", expected: releaseNote},
+		{name: "sentence containing n/a", note: "The status column shows n/a for pending jobs.", expected: releaseNote},
+		{name: "configured synonym", note: "Keine", synonyms: []string{"keine"}, expected: releaseNoteNone},
+		{name: "configured synonyms replace the defaults", note: "n/a", synonyms: []string{"keine"}, expected: releaseNote},
+		{name: "none with configured synonyms", note: "NONE", synonyms: []string{"keine"}, expected: releaseNoteNone},
+	}
+	for _, test := range tests {
+		cfg := &plugins.ReleaseNote{NoneSynonyms: test.synonyms}
+		body := "```release-note\n" + test.note + "\n```"
+		if actual := determineReleaseNoteLabel(cfg, body); actual != test.expected {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestRecordActionRequiredNotes(t *testing.T) {
+	tests := []struct {
+		name          string
+		user          string
+		disabled      bool
+		expectLabel   bool
+		expectComment string
+	}{
+		{
+			name:          "member providing a note",
+			user:          "m",
+			expectLabel:   true,
+			expectComment: recordedNoteMarker + "\n" + fmt.Sprintf(recordedNoteFormat, "m") + "\n```release-note\nRename foo to bar.\n```",
+		},
+		{
+			name:          "non-member",
+			user:          "a",
+			expectComment: "you can only record a release note",
+		},
+		{
+			name:     "disabled",
+			user:     "m",
+			disabled: true,
+		},
+	}
+	for _, test := range tests {
+		fc := &fakegithub.FakeClient{
+			IssueComments: map[int][]github.IssueComment{},
+			OrgMembers:    []string{"m"},
+		}
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: "/release-note-action-required Rename foo to bar.", User: github.User{Login: test.user}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      5,
+				PullRequest: &struct{}{},
+				Labels:      []github.Label{{Name: releaseNoteLabelNeeded}},
+			},
+		}
+		cfg := &plugins.ReleaseNote{RecordActionRequiredNotes: !test.disabled}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		if labeled := reflect.DeepEqual(fc.LabelsAdded, []string{"/#5:" + releaseNoteActionRequired}); labeled != test.expectLabel {
+			t.Errorf("(%s): Expected label: %t, but got %q.", test.name, test.expectLabel, fc.LabelsAdded)
+		}
+		if test.expectLabel && !reflect.DeepEqual(fc.LabelsRemoved, []string{"/#5:" + releaseNoteLabelNeeded}) {
+			t.Errorf("(%s): Expected %q to be removed, but got %q.", test.name, releaseNoteLabelNeeded, fc.LabelsRemoved)
+		}
+		if test.expectComment == "" {
+			if len(fc.IssueComments[5]) > 0 {
+				t.Errorf("(%s): Expected no comments, but got %v.", test.name, fc.IssueComments[5])
+			}
+		} else if len(fc.IssueComments[5]) != 1 || !strings.Contains(fc.IssueComments[5][0].Body, test.expectComment) {
+			t.Errorf("(%s): Expected a comment containing %q, but got %v.", test.name, test.expectComment, fc.IssueComments[5])
+		}
+	}
+}
+
+func TestRecordedNoteSatisfiesProcess(t *testing.T) {
+	fc, pr := newFakeClient("```release-note\n```", "master", []string{releaseNoteActionRequired}, nil, nil)
+	fc.IssueComments[1] = []github.IssueComment{
+		{Body: recordedNoteMarker + "\nA recorded note.", User: github.User{Login: "k8s-ci-robot"}},
+	}
+	cfg := &plugins.ReleaseNote{RecordActionRequiredNotes: true}
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if expected := formatLabels(1, releaseNoteActionRequired); !reflect.DeepEqual(fc.LabelsAdded, expected) || len(fc.LabelsRemoved) > 0 {
+		t.Errorf("Expected labels to stay %q, but got %q added and %q removed.", expected, fc.LabelsAdded, fc.LabelsRemoved)
+	}
+}
+
+func TestTrustCherryPickBotNote(t *testing.T) {
+	tests := []struct {
+		name         string
+		author       string
+		body         string
+		expectFollow bool
+	}{
+		{
+			name:   "bot cherry-pick with its own note",
+			author: "cherrypick-robot",
+			body:   "Cherry pick of #2 on release-1.8.\n```release-note\nA note.\n```",
+		},
+		{
+			name:         "bot cherry-pick without a note",
+			author:       "cherrypick-robot",
+			body:         "Cherry pick of #2 on release-1.8.\n```release-note\n```",
+			expectFollow: true,
+		},
+		{
+			name:         "human cherry-pick with its own note",
+			author:       "cjwagner",
+			body:         "Cherry pick of #2 on release-1.8.\n```release-note\nA note.\n```",
+			expectFollow: true,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "release-1.8", nil, nil, map[int]string{2: releaseNoteNone})
+		pr.PullRequest.User.Login = test.author
+		cfg := &plugins.ReleaseNote{TrustCherryPickBotNote: true, CherryPickBot: "cherrypick-robot"}
+		prLabels, _ := fc.GetIssueLabels("org", "repo", 1)
+		if follow := prMustFollowRelNoteProcess(fc, logrus.WithField("plugin", pluginName), cfg, pr, prLabels, false); follow != test.expectFollow {
+			t.Errorf("(%s): Expected the PR to follow the process: %t, but got %t.", test.name, test.expectFollow, follow)
+		}
+	}
+
+	// A human cherry-pick of a noteless parent is told about the parent.
+	fc, pr := newFakeClient("Cherry pick of #2 on release-1.8.", "release-1.8", nil, nil, map[int]string{2: releaseNoteNone})
+	cfg := &plugins.ReleaseNote{TrustCherryPickBotNote: true, CherryPickBot: "cherrypick-robot"}
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if !strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), parentReleaseNoteBody(&plugins.ReleaseNote{})) {
+		t.Errorf("Expected a comment about the noteless parent, but got %q.", fc.IssueCommentsAdded)
+	}
+	if expected := append(formatLabels(2, releaseNoteNone), formatLabels(1, releaseNoteLabelNeeded)...); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+		t.Errorf("Expected labels %q, but got %q.", expected, fc.LabelsAdded)
+	}
+}
+
+func TestReplyOnNonPR(t *testing.T) {
+	tests := []struct {
+		name        string
+		comment     string
+		reply       bool
+		expectReply bool
+	}{
+		{name: "command on an issue", comment: "/release-note-none", reply: true, expectReply: true},
+		{name: "command on an issue, replies disabled", comment: "/release-note-none"},
+		{name: "other comment on an issue", comment: "I like release notes.", reply: true},
+	}
+	for _, test := range tests {
+		fc := &fakegithub.FakeClient{
+			IssueComments: map[int][]github.IssueComment{},
+			OrgMembers:    []string{"m"},
+		}
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: test.comment, User: github.User{Login: "m"}},
+			Issue:   github.Issue{User: github.User{Login: "a"}, Number: 5},
+		}
+		cfg := &plugins.ReleaseNote{ReplyOnNonPR: test.reply}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		if replied := len(fc.IssueComments[5]) > 0; replied != test.expectReply {
+			t.Errorf("(%s): Expected reply: %t, but got comments %v.", test.name, test.expectReply, fc.IssueComments[5])
+		}
+		if len(fc.LabelsAdded) > 0 {
+			t.Errorf("(%s): Expected no labels on an issue, but got %q.", test.name, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestForceNeededLabels(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		initialLabels []string
+		changes       []github.PullRequestChange
+		expectLabel   string
+	}{
+		{
+			name:          "force label overrides auto-none for a small PR",
+			body:          "```release-note\n```",
+			initialLabels: []string{"needs-release-note"},
+			changes:       []github.PullRequestChange{{Additions: 1}},
+			expectLabel:   releaseNoteLabelNeeded,
+		},
+		{
+			name:          "force label overrides a none note",
+			body:          "```release-note\nNONE\n```",
+			initialLabels: []string{"needs-release-note"},
+			expectLabel:   releaseNoteLabelNeeded,
+		},
+		{
+			name:          "force label with a real note",
+			body:          "```release-note\nA note.\n```",
+			initialLabels: []string{"needs-release-note"},
+			expectLabel:   releaseNote,
+		},
+		{
+			name:        "small PR without the force label",
+			body:        "```release-note\n```",
+			changes:     []github.PullRequestChange{{Additions: 1}},
+			expectLabel: releaseNoteNone,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		fc.PullRequestChanges = map[int][]github.PullRequestChange{1: test.changes}
+		cfg := &plugins.ReleaseNote{
+			ForceNeededLabels:           []string{"needs-release-note"},
+			RequireNoteOverChangedLines: 10,
+		}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		expected := formatLabels(1, append(test.initialLabels, test.expectLabel)...)
+		if !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestBatchLabelChanges(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		initialLabels []string
+		batch         bool
+
+		expectReplaced []string
+		expectAdded    []string
+		expectRemoved  []string
+	}{
+		{
+			name:           "add and removes are replaced by a single call",
+			body:           "```release-note\nA note.\n```",
+			initialLabels:  []string{"lgtm", releaseNoteLabelNeeded, deprecatedReleaseNoteLabelNeeded, releaseNoteNone},
+			batch:          true,
+			expectReplaced: []string{"org/repo#1:lgtm,release-note"},
+		},
+		{
+			name:           "managed labels are matched regardless of case",
+			body:           "```release-note\nA note.\n```",
+			initialLabels:  []string{"lgtm", "Release-Note-None", "Do-Not-Merge/Release-Note-Label-Needed"},
+			batch:          true,
+			expectReplaced: []string{"org/repo#1:lgtm,release-note"},
+		},
+		{
+			name:          "a single change doesn't need a replace",
+			body:          "```release-note\nA note.\n```",
+			initialLabels: []string{"lgtm"},
+			batch:         true,
+			expectAdded:   []string{releaseNote},
+		},
+		{
+			name:          "without batching each change is its own call",
+			body:          "```release-note\nA note.\n```",
+			initialLabels: []string{"lgtm", releaseNoteLabelNeeded, releaseNoteNone},
+			expectAdded:   []string{releaseNote},
+			expectRemoved: []string{releaseNoteLabelNeeded, releaseNoteNone},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		cfg := &plugins.ReleaseNote{BatchLabelChanges: test.batch}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(fc.LabelsReplaced, test.expectReplaced) {
+			t.Errorf("(%s): Expected labels to be replaced with %q, but got %q.", test.name, test.expectReplaced, fc.LabelsReplaced)
+		}
+		added := sliceDifference(fc.LabelsAdded, formatLabels(1, test.initialLabels...))
+		if expected := formatLabels(1, test.expectAdded...); len(added) != len(expected) || len(sliceDifference(expected, added)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expected, added)
+		}
+		expected := formatLabels(1, test.expectRemoved...)
+		if missing := sliceDifference(expected, fc.LabelsRemoved); len(missing) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, expected, fc.LabelsRemoved)
+		}
+		if extra := sliceDifference(fc.LabelsRemoved, expected); len(extra) > 0 {
+			t.Errorf("(%s): Unexpected labels %q were removed.", test.name, extra)
+		}
+	}
+}
+
+func TestNeededLabelReasserted(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		removedLabel string
+		expectAdded  []string
+	}{
+		{
+			name:         "needed label removed while the note is empty",
+			body:         "```release-note\n```",
+			removedLabel: releaseNoteLabelNeeded,
+			expectAdded:  []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:         "needed label removed once the note is present",
+			body:         "```release-note\nA note.\n```",
+			removedLabel: releaseNoteLabelNeeded,
+			expectAdded:  []string{releaseNote},
+		},
+		{
+			name:         "other label removed",
+			body:         "```release-note\n```",
+			removedLabel: "lgtm",
+		},
+	}
+	for _, test := range tests {
+		// The fake doesn't track removals, so the PR starts without the label.
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		pr.Action = github.PullRequestActionUnlabeled
+		pr.Label = github.Label{Name: test.removedLabel}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, test.expectAdded...); len(fc.LabelsAdded) != len(expected) || len(sliceDifference(expected, fc.LabelsAdded)) > 0 {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		if len(fc.IssueCommentsAdded) > 0 {
+			t.Errorf("(%s): Expected no comments, but got %q.", test.name, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+// prRequestRecorder is a githubClient that records the PRs requested with
+// GetPullRequest.
+type prRequestRecorder struct {
+	githubClient
+	requested []string
+}
+
+func (r *prRequestRecorder) GetPullRequest(org, repo string, number int) (*github.PullRequest, error) {
+	r.requested = append(r.requested, fmt.Sprintf("%s/%s#%d", org, repo, number))
+	return r.githubClient.GetPullRequest(org, repo, number)
+}
+
+func TestInheritFromUpstreamRef(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		expectRequested []string
+		expectLabel     string
+	}{
+		{
+			name:            "downstream PR inherits the upstream note",
+			body:            "Upstream: upstream-org/upstream-repo#5\n```release-note\n```",
+			expectRequested: []string{"upstream-org/upstream-repo#5"},
+			expectLabel:     releaseNote,
+		},
+		{
+			name:        "no upstream reference",
+			body:        "Mirrored by hand.\n```release-note\n```",
+			expectLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:        "own note wins",
+			body:        "Upstream: upstream-org/upstream-repo#5\n```release-note\nNONE\n```",
+			expectLabel: releaseNoteNone,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		fc.PullRequests = map[int]*github.PullRequest{
+			5: {Number: 5, Body: "```release-note\nAn upstream note.\n```"},
+		}
+		gc := &prRequestRecorder{githubClient: fc}
+		cfg := &plugins.ReleaseNote{InheritFromUpstreamRef: true}
+		if err := handlePR(gc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(gc.requested, test.expectRequested) {
+			t.Errorf("(%s): Expected PRs %q to be requested, but got %q.", test.name, test.expectRequested, gc.requested)
+		}
+		expected := formatLabels(1, test.expectLabel)
+		if !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestLabelDelay(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      github.PullRequestEventAction
+		createdAt   time.Time
+		expectLabel []string
+	}{
+		{
+			name:      "just opened",
+			action:    github.PullRequestActionOpened,
+			createdAt: time.Now(),
+		},
+		{
+			name:        "edited after the delay with the note still empty",
+			action:      github.PullRequestActionEdited,
+			createdAt:   time.Now().Add(-time.Hour),
+			expectLabel: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:      "edited during the delay",
+			action:    github.PullRequestActionEdited,
+			createdAt: time.Now().Add(-time.Minute),
+		},
+	}
+	sink := &capturingSink{}
+	SetDecisionSink(sink)
+	defer SetDecisionSink(nil)
+	for _, test := range tests {
+		sink.decisions = map[string]Decision{}
+		fc, pr := newFakeClient("```release-note\n```", "master", nil, nil, nil)
+		pr.Action = test.action
+		pr.PullRequest.CreatedAt = test.createdAt
+		pr.PullRequest.Head.SHA = "sha"
+		cfg := &plugins.ReleaseNote{LabelDelay: "5m", ReportStatus: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, test.expectLabel...); len(fc.LabelsAdded) != len(expected) || len(sliceDifference(expected, fc.LabelsAdded)) > 0 {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		statuses := fc.CreatedStatuses["sha"]
+		if len(statuses) != 1 {
+			t.Fatalf("(%s): Expected one status, but got %v.", test.name, statuses)
+		}
+		if test.expectLabel == nil {
+			if len(fc.IssueCommentsAdded) > 0 {
+				t.Errorf("(%s): Expected no comments, but got %q.", test.name, fc.IssueCommentsAdded)
+			}
+			if len(sink.decisions) > 0 {
+				t.Errorf("(%s): Expected no decision during the delay, but got %v.", test.name, sink.decisions)
+			}
+			if statuses[0].State != github.StatusPending {
+				t.Errorf("(%s): Expected a pending status during the delay, but got %v.", test.name, statuses[0])
+			}
+		} else if statuses[0].State != github.StatusFailure || len(sink.decisions) != 1 {
+			t.Errorf("(%s): Expected a failing status and a decision, but got %v and %v.", test.name, statuses[0], sink.decisions)
+		}
+	}
+}
+
+func TestLabelDelayCommentsOnce(t *testing.T) {
+	fc, pr := newFakeClient("Cherry pick of #2 on release-1.8.", "release-1.8", nil, nil, map[int]string{2: releaseNoteNone})
+	cfg := &plugins.ReleaseNote{LabelDelay: "5m"}
+	pr.PullRequest.CreatedAt = time.Now()
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if len(fc.IssueCommentsAdded) > 0 {
+		t.Errorf("Expected no comments during the delay, but got %q.", fc.IssueCommentsAdded)
+	}
+
+	pr.PullRequest.CreatedAt = time.Now().Add(-time.Hour)
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	parentComments := 0
+	for _, comment := range fc.IssueCommentsAdded {
+		if strings.Contains(comment, parentNoteMarker) {
+			parentComments++
+		}
+	}
+	if parentComments != 1 {
+		t.Errorf("Expected the parent note comment once, after the delay, but got %q.", fc.IssueCommentsAdded)
+	}
+}
+
+func TestLabelDelayWelcomesFirstTimers(t *testing.T) {
+	for _, body := range []string{"```release-note\n```", "```release-note\nA note.\n```"} {
+		fc, pr := newFakeClient(body, "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{LabelDelay: "5m", WelcomeFirstTimers: true}
+		pr.Action = github.PullRequestActionOpened
+		pr.PullRequest.CreatedAt = time.Now()
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%q): Unexpected error from handlePR: %v", body, err)
+		}
+		if len(fc.IssueCommentsAdded) > 0 {
+			t.Errorf("(%q): Expected no comments during the delay, but got %q.", body, fc.IssueCommentsAdded)
+		}
+
+		// The first events after the delay greet the author once.
+		pr.PullRequest.CreatedAt = time.Now().Add(-time.Hour)
+		pr.Action = github.PullRequestActionEdited
+		for i := 0; i < 2; i++ {
+			if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+				t.Fatalf("(%q): Unexpected error from handlePR: %v", body, err)
+			}
+		}
+		welcomes := 0
+		for _, comment := range fc.IssueCommentsAdded {
+			if strings.Contains(comment, welcomeBody) {
+				welcomes++
+			}
+		}
+		if welcomes != 1 {
+			t.Errorf("(%q): Expected the author to be welcomed once after the delay, but got comments %q.", body, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestStrictMilestones(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		action        github.PullRequestEventAction
+		milestone     *github.Milestone
+		initialLabels []string
+		expectAdded   []string
+		expectRemoved []string
+		expectComment bool
+	}{
+		{
+			name:          "gaining a strict milestone blocks a none note",
+			body:          "```release-note\nNONE\n```",
+			action:        github.PullRequestActionMilestoned,
+			milestone:     &github.Milestone{Title: "v1.9"},
+			initialLabels: []string{releaseNoteNone},
+			expectAdded:   []string{releaseNoteLabelNeeded},
+			expectRemoved: []string{releaseNoteNone},
+			expectComment: true,
+		},
+		{
+			name:          "losing a strict milestone relaxes a none note",
+			body:          "```release-note\nNONE\n```",
+			action:        github.PullRequestActionDemilestoned,
+			initialLabels: []string{releaseNoteLabelNeeded},
+			expectAdded:   []string{releaseNoteNone},
+			expectRemoved: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:          "other milestones accept none",
+			body:          "```release-note\nNONE\n```",
+			action:        github.PullRequestActionMilestoned,
+			milestone:     &github.Milestone{Title: "v1.10"},
+			initialLabels: []string{releaseNoteNone},
+		},
+		{
+			name:          "real notes are accepted in a strict milestone",
+			body:          "```release-note\nA note.\n```",
+			action:        github.PullRequestActionMilestoned,
+			milestone:     &github.Milestone{Title: "v1.9"},
+			initialLabels: []string{releaseNote},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		pr.Action = test.action
+		pr.PullRequest.Milestone = test.milestone
+		cfg := &plugins.ReleaseNote{StrictMilestones: []string{"v1.9"}}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		added := sliceDifference(fc.LabelsAdded, formatLabels(1, test.initialLabels...))
+		if expected := formatLabels(1, test.expectAdded...); len(added) != len(expected) || len(sliceDifference(expected, added)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expected, added)
+		}
+		if missing := sliceDifference(formatLabels(1, test.expectRemoved...), fc.LabelsRemoved); len(missing) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, test.expectRemoved, fc.LabelsRemoved)
+		}
+		commented := false
+		for _, c := range fc.IssueCommentsAdded {
+			if strings.Contains(c, strictMilestoneBody) {
+				commented = true
+			}
+		}
+		if commented != test.expectComment {
+			t.Errorf("(%s): Expected a strict milestone comment: %t, but got %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestParentCommentTemplate(t *testing.T) {
+	tmpl := "@{{.Author}}: Bitte Release Notes ergänzen.\n| PR | Note |\n|---|---|\n{{range .Parents}}| #{{.Number}} | {{if .HasNote}}ja{{else}}nein{{end}} |\n{{end}}"
+	cfg := &plugins.ReleaseNote{ParentCommentTemplate: tmpl}
+
+	fc, pr := newFakeClient("Cherry pick of #2 on release-1.8.\nCherry pick of #3 on release-1.8.", "release-1.8", nil, nil, map[int]string{2: releaseNoteNone, 3: releaseNote})
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	expected := "org/repo#1:@cjwagner: Bitte Release Notes ergänzen.\n| PR | Note |\n|---|---|\n| #2 | nein |\n| #3 | ja |\n\n" + parentNoteMarker
+	if len(fc.IssueCommentsAdded) == 0 || fc.IssueCommentsAdded[0] != expected {
+		t.Errorf("Expected the comment %q, but got %q.", expected, fc.IssueCommentsAdded)
+	}
+
+	// Once the PR has its own note, both custom and old style parent
+	// comments are cleaned up.
+	fc, pr = newFakeClient("Cherry pick of #2 on release-1.8.\n```release-note\nA note.\n```", "release-1.8", []string{releaseNote}, nil, map[int]string{2: releaseNoteNone})
+	fc.IssueComments[1] = []github.IssueComment{
+		{ID: 1, Body: "@cjwagner: custom\n" + parentNoteMarker, User: github.User{Login: "k8s-ci-robot"}},
+		{ID: 2, Body: plugins.FormatResponse("cjwagner", parentReleaseNoteBody(&plugins.ReleaseNote{}), "old"), User: github.User{Login: "k8s-ci-robot"}},
+		{ID: 3, Body: "An unrelated comment.", User: github.User{Login: "k8s-ci-robot"}},
+	}
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if expected := []string{"org/repo#1", "org/repo#2"}; !reflect.DeepEqual(fc.IssueCommentsDeleted, expected) {
+		t.Errorf("Expected comments %q to be deleted, but got %q.", expected, fc.IssueCommentsDeleted)
+	}
+}
+
+func TestNoteFromFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		changes     []github.PullRequestChange
+		expectLabel string
+	}{
+		{
+			name:        "release notes file with a note",
+			body:        "See releasenotes/1.md.\n```release-note\n```",
+			changes:     []github.PullRequestChange{{Filename: "main.go"}, {Filename: "releasenotes/1.md", Status: "added"}},
+			expectLabel: releaseNote,
+		},
+		{
+			name:        "release notes file with a release note block",
+			body:        "```release-note\n```",
+			changes:     []github.PullRequestChange{{Filename: "releasenotes/2.md", Status: "modified"}},
+			expectLabel: releaseNoteNone,
+		},
+		{
+			name:        "no release notes file",
+			body:        "```release-note\n```",
+			changes:     []github.PullRequestChange{{Filename: "main.go"}},
+			expectLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:        "removed release notes file",
+			body:        "```release-note\n```",
+			changes:     []github.PullRequestChange{{Filename: "releasenotes/1.md", Status: "removed"}},
+			expectLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:        "body note wins",
+			body:        "```release-note\nNONE\n```",
+			changes:     []github.PullRequestChange{{Filename: "releasenotes/1.md", Status: "added"}},
+			expectLabel: releaseNoteNone,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		pr.PullRequest.Head.SHA = "abc"
+		fc.PullRequestChanges = map[int][]github.PullRequestChange{1: test.changes}
+		fc.RemoteFiles = map[string]map[string]string{
+			"releasenotes/1.md": {"abc": "The foo command now supports the --bar flag.\n"},
+			"releasenotes/2.md": {"abc": "# Release notes\n```release-note\nNONE\n```\n"},
+		}
+		cfg := &plugins.ReleaseNote{NoteFromFile: "releasenotes/*.md"}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		expected := formatLabels(1, test.expectLabel)
+		if !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestForbiddenFlagPatterns(t *testing.T) {
+	tests := []struct {
+		name          string
+		note          string
+		hard          bool
+		expectLabel   string
+		expectWarning bool
+	}{
+		{
+			name:          "forbidden flag warns",
+			note:          "Added --enable-foo-alpha to the scheduler.",
+			expectLabel:   releaseNote,
+			expectWarning: true,
+		},
+		{
+			name:          "forbidden flag blocks under hard enforcement",
+			note:          "Added --enable-foo-alpha to the scheduler.",
+			hard:          true,
+			expectLabel:   releaseNoteLabelNeeded,
+			expectWarning: true,
+		},
+		{
+			name:        "clean note",
+			note:        "Added --enable-foo to the scheduler.",
+			hard:        true,
+			expectLabel: releaseNote,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n"+test.note+"\n```", "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{
+			ForbiddenFlagPatterns: []string{`--[[:alnum:]-]+-alpha\b`},
+			HardEnforceFlags:      test.hard,
+		}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		expected := formatLabels(1, test.expectLabel)
+		if !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		warned := false
+		for _, c := range fc.IssueCommentsAdded {
+			if strings.Contains(c, forbiddenFlagBody) {
+				if !strings.Contains(c, "--enable-foo-alpha") {
+					t.Errorf("(%s): Expected the warning to name the flag, but got %q.", test.name, c)
+				}
+				warned = true
+			}
+		}
+		if warned != test.expectWarning {
+			t.Errorf("(%s): Expected a warning: %t, but got %q.", test.name, test.expectWarning, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+// labelRequestCounter is a githubClient that counts GetIssueLabels calls.
+type labelRequestCounter struct {
+	githubClient
+	calls int
+}
+
+func (c *labelRequestCounter) GetIssueLabels(org, repo string, number int) ([]github.Label, error) {
+	c.calls++
+	return c.githubClient.GetIssueLabels(org, repo, number)
+}
+
+func TestMaxParents(t *testing.T) {
+	tests := []struct {
+		name          string
+		parents       int
+		expectCalls   int
+		expectComment bool
+	}{
+		{
+			name:        "within the cap",
+			parents:     3,
+			expectCalls: 3,
+		},
+		{
+			name:          "exceeding the cap",
+			parents:       10,
+			expectCalls:   5,
+			expectComment: true,
+		},
+	}
+	for _, test := range tests {
+		var lines []string
+		parentPRs := map[int]string{}
+		for i := 0; i < test.parents; i++ {
+			lines = append(lines, fmt.Sprintf("Cherry pick of #%d on release-1.8.", i+2))
+			parentPRs[i+2] = releaseNote
+		}
+		fc, pr := newFakeClient(strings.Join(lines, "\n"), "release-1.8", nil, nil, parentPRs)
+		gc := &labelRequestCounter{githubClient: fc}
+		cfg := &plugins.ReleaseNote{MaxParents: 5}
+		if follow := prMustFollowRelNoteProcess(gc, logrus.WithField("plugin", pluginName), cfg, pr, nil, true); follow {
+			t.Errorf("(%s): Expected the parents' notes to be enough.", test.name)
+		}
+		if gc.calls != test.expectCalls {
+			t.Errorf("(%s): Expected %d parents to be checked, but got %d.", test.name, test.expectCalls, gc.calls)
+		}
+		if commented := len(fc.IssueCommentsAdded) > 0; commented != test.expectComment {
+			t.Errorf("(%s): Expected a comment: %t, but got %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+
+		// The author is only asked once.
+		prMustFollowRelNoteProcess(gc, logrus.WithField("plugin", pluginName), cfg, pr, nil, true)
+		if len(fc.IssueCommentsAdded) > 1 {
+			t.Errorf("(%s): Expected at most one comment, but got %q.", test.name, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestEmptyActionRequiredBehavior(t *testing.T) {
+	tests := []struct {
+		name          string
+		behavior      string
+		note          string
+		initialLabels []string
+		expectAdded   []string
+		expectRemoved []string
+		expectComment bool
+	}{
+		{
+			name:        "accepted by default",
+			note:        "ACTION REQUIRED",
+			expectAdded: []string{releaseNoteActionRequired},
+		},
+		{
+			name:          "blocked",
+			behavior:      emptyActionBlock,
+			note:          "Action required:",
+			expectAdded:   []string{releaseNoteLabelNeeded},
+			expectComment: true,
+		},
+		{
+			name:        "labeled for re-review",
+			behavior:    emptyActionLabel,
+			note:        "ACTION REQUIRED",
+			expectAdded: []string{releaseNoteActionRequired, needsRereviewLabel},
+		},
+		{
+			name:          "re-review label removed once the action is described",
+			behavior:      emptyActionLabel,
+			note:          "ACTION REQUIRED: Remove the --foo flag.",
+			initialLabels: []string{releaseNoteActionRequired, needsRereviewLabel},
+			expectRemoved: []string{needsRereviewLabel},
+		},
+		{
+			name:        "described action isn't blocked",
+			behavior:    emptyActionBlock,
+			note:        "ACTION REQUIRED: Remove the --foo flag.",
+			expectAdded: []string{releaseNoteActionRequired},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n"+test.note+"\n```", "master", test.initialLabels, nil, nil)
+		fc.ExistingLabels = append(fc.ExistingLabels, needsRereviewLabel)
+		cfg := &plugins.ReleaseNote{EmptyActionRequiredBehavior: test.behavior}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		added := sliceDifference(fc.LabelsAdded, formatLabels(1, test.initialLabels...))
+		if expected := formatLabels(1, test.expectAdded...); len(added) != len(expected) || len(sliceDifference(expected, added)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expected, added)
+		}
+		expected := formatLabels(1, test.expectRemoved...)
+		if missing := sliceDifference(expected, fc.LabelsRemoved); len(missing) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, expected, fc.LabelsRemoved)
+		}
+		if extra := sliceDifference(fc.LabelsRemoved, expected); len(extra) > 0 {
+			t.Errorf("(%s): Unexpected labels %q were removed.", test.name, extra)
+		}
+		commented := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), emptyActionRequiredBody)
+		if commented != test.expectComment {
+			t.Errorf("(%s): Expected a comment: %t, but got %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestExpectedNoteLanguage(t *testing.T) {
+	tests := []struct {
+		name             string
+		note             string
+		expectSuggestion bool
+	}{
+		{
+			name: "english note",
+			note: "The foo command now supports the --bar flag.",
+		},
+		{
+			name: "english note with a non-latin name",
+			note: "Added a Japanese translation (日本語) of the docs.",
+		},
+		{
+			name:             "russian note",
+			note:             "Команда foo теперь поддерживает флаг --bar.",
+			expectSuggestion: true,
+		},
+		{
+			name:             "chinese note",
+			note:             "foo 命令现在支持 --bar 标志。",
+			expectSuggestion: true,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n"+test.note+"\n```", "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{ExpectedNoteLanguage: "en"}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, releaseNote); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		suggested := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), noteLanguageBody)
+		if suggested != test.expectSuggestion {
+			t.Errorf("(%s): Expected a suggestion: %t, but got %q.", test.name, test.expectSuggestion, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestBaseChanged(t *testing.T) {
+	baseChange := func(from string) *github.PullRequestEventChanges {
+		return &github.PullRequestEventChanges{Base: &github.BaseChange{Ref: github.EditedFrom{From: from}}}
+	}
+	tests := []struct {
+		name          string
+		branch        string
+		changes       *github.PullRequestEventChanges
+		initialLabels []string
+		expectAdded   []string
+		expectRemoved []string
+	}{
+		{
+			name:        "rebased from a release branch onto master",
+			branch:      "master",
+			changes:     baseChange("release-1.20"),
+			expectAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:          "rebased from master onto a release branch",
+			branch:        "release-1.20",
+			changes:       baseChange("master"),
+			initialLabels: []string{releaseNoteLabelNeeded},
+			expectRemoved: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:    "title edit",
+			branch:  "master",
+			changes: &github.PullRequestEventChanges{Title: &github.EditedFrom{From: "Old title"}},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("Cherry pick of #2 on release-1.20.\n```release-note\n```", test.branch, test.initialLabels, nil, map[int]string{2: releaseNote})
+		pr.Changes = test.changes
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		added := sliceDifference(fc.LabelsAdded, append(formatLabels(1, test.initialLabels...), formatLabels(2, releaseNote)...))
+		if expected := formatLabels(1, test.expectAdded...); len(added) != len(expected) || len(sliceDifference(expected, added)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expected, added)
+		}
+		if missing := sliceDifference(formatLabels(1, test.expectRemoved...), fc.LabelsRemoved); len(missing) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, test.expectRemoved, fc.LabelsRemoved)
+		}
+		if test.expectAdded == nil && len(fc.IssueCommentsAdded) > 0 {
+			t.Errorf("(%s): Expected no comments, but got %q.", test.name, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestRejectionTemplates(t *testing.T) {
+	cfg := &plugins.ReleaseNote{
+		PermissionRejectionTemplate:   "@{{.User}} may not add {{.Label}}, see https://example.com/contributing.",
+		PreconditionRejectionTemplate: "{{.Label}} needs you to {{.Precondition}}.",
+		PrecedenceRejectionTemplate:   "{{.Label}} conflicts with the note in the PR body.",
+		NonePreconditions:             []plugins.NonePrecondition{{Labels: []string{"approved"}}},
+	}
+	tests := []struct {
+		name      string
+		commenter string
+		body      string
+		cfg       *plugins.ReleaseNote
+		expected  string
+	}{
+		{
+			name:      "permission",
+			commenter: "outsider",
+			cfg:       cfg,
+			expected:  "@outsider may not add release-note-none, see https://example.com/contributing.",
+		},
+		{
+			name:      "precondition",
+			commenter: "a",
+			cfg:       cfg,
+			expected:  "release-note-none needs you to " + describeNonePrecondition(cfg.NonePreconditions[0]) + ".",
+		},
+		{
+			name:      "precedence",
+			commenter: "a",
+			body:      "```release-note\nA note.\n```",
+			cfg:       &plugins.ReleaseNote{PrecedenceRejectionTemplate: cfg.PrecedenceRejectionTemplate},
+			expected:  "release-note-none conflicts with the note in the PR body.",
+		},
+		{
+			name:      "default permission reply",
+			commenter: "outsider",
+			cfg:       &plugins.ReleaseNote{},
+			expected:  "you can only set the release note label to release-note-none if you are the PR author or an org member.",
+		},
+	}
+	for _, test := range tests {
+		fc, _ := newFakeClient(test.body, "master", nil, nil, nil)
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: "/release-note-none", User: github.User{Login: test.commenter}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      1,
+				Body:        test.body,
+				PullRequest: &struct{}{},
+			},
+			Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+		}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), test.cfg, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		if len(fc.IssueCommentsAdded) != 1 || !strings.Contains(fc.IssueCommentsAdded[0], test.expected) {
+			t.Errorf("(%s): Expected a reply containing %q, but got %q.", test.name, test.expected, fc.IssueCommentsAdded)
+		}
+		if len(fc.LabelsAdded) > 0 {
+			t.Errorf("(%s): Expected no labels, but got %q.", test.name, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestFlagNoteChangesAfterApproval(t *testing.T) {
+	oldBody := "```release-note\nA note.\n```"
+	tests := []struct {
+		name     string
+		body     string
+		reviews  []github.Review
+		events   []github.ListedIssueEvent
+		expected bool
+	}{
+		{
+			name:     "note edited after an approving review",
+			body:     "```release-note\nA different note.\n```",
+			reviews:  []github.Review{{State: github.ReviewStateApproved, SubmittedAt: time.Now().Add(-time.Hour)}},
+			expected: true,
+		},
+		{
+			name:     "note edited after the approved label was added",
+			body:     "```release-note\nA different note.\n```",
+			events:   []github.ListedIssueEvent{{Event: github.ListedIssueEventLabeled, Label: github.Label{Name: "approved"}, CreatedAt: time.Now().Add(-time.Hour)}},
+			expected: true,
+		},
+		{
+			name:    "note edited before approval",
+			body:    "```release-note\nA different note.\n```",
+			reviews: []github.Review{{State: github.ReviewStateCommented, SubmittedAt: time.Now().Add(-time.Hour)}},
+		},
+		{
+			name:    "approved PR edited outside the note",
+			body:    "Some context.\n" + oldBody,
+			reviews: []github.Review{{State: github.ReviewStateApproved, SubmittedAt: time.Now().Add(-time.Hour)}},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", []string{releaseNote}, nil, nil)
+		fc.ExistingLabels = append(fc.ExistingLabels, noteChangedLabel)
+		fc.Reviews = map[int][]github.Review{1: test.reviews}
+		fc.IssueEvents = map[int][]github.ListedIssueEvent{1: test.events}
+		pr.Changes = &github.PullRequestEventChanges{Body: &github.EditedFrom{From: oldBody}}
+		cfg := &plugins.ReleaseNote{FlagNoteChangesAfterApproval: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		labeled := len(sliceDifference(formatLabels(1, noteChangedLabel), fc.LabelsAdded)) == 0
+		commented := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), noteChangedBody)
+		if labeled != test.expected || commented != test.expected {
+			t.Errorf("(%s): Expected the change to be flagged: %t, but got labels %q and comments %q.", test.name, test.expected, fc.LabelsAdded, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestFlagNoteChangesAfterApprovalOnce(t *testing.T) {
+	fc, pr := newFakeClient("", "master", []string{releaseNote}, nil, nil)
+	fc.ExistingLabels = append(fc.ExistingLabels, noteChangedLabel)
+	fc.Reviews = map[int][]github.Review{1: {{State: github.ReviewStateApproved, SubmittedAt: time.Now().Add(-time.Hour)}}}
+	cfg := &plugins.ReleaseNote{FlagNoteChangesAfterApproval: true}
+	notes := []string{"A note.", "A different note.", "Yet another note."}
+	for i := 1; i < len(notes); i++ {
+		pr.PullRequest.Body = "```release-note\n" + notes[i] + "\n```"
+		pr.Changes = &github.PullRequestEventChanges{Body: &github.EditedFrom{From: "```release-note\n" + notes[i-1] + "\n```"}}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("Unexpected error from handlePR: %v", err)
+		}
+	}
+	if len(fc.IssueCommentsAdded) != 1 {
+		t.Fatalf("Expected one comment for both edits, but got %q.", fc.IssueCommentsAdded)
+	}
+	if comment := fc.IssueCommentsAdded[0]; !strings.Contains(comment, noteChangedBody) || strings.Contains(comment, "One of the following labels is required") {
+		t.Errorf("Expected only the note changed comment, but got %q.", comment)
+	}
+}
+
+func TestAutoPopulateCherryPickNote(t *testing.T) {
+	body := "Cherry pick of #2 on release-1.8.\nCherry pick of #3 on release-1.8.\nCherry pick of #4 on release-1.8.\n```release-note\n```"
+	fc, pr := newFakeClient(body, "release-1.8", nil, nil, map[int]string{2: releaseNote, 3: releaseNoteActionRequired, 4: releaseNote})
+	fc.PullRequests = map[int]*github.PullRequest{
+		2: {Number: 2, Body: "```release-note\nFoo now supports bar.\n```", User: github.User{Login: "alice"}},
+		3: {Number: 3, Body: "```release-note\nACTION REQUIRED: The baz flag was removed.\n```", User: github.User{Login: "bob"}},
+		4: {Number: 4, Body: "```release-note\nNONE\n```", User: github.User{Login: "carol"}},
+	}
+	cfg := &plugins.ReleaseNote{AutoPopulateCherryPickNote: true}
+	for i := 0; i < 2; i++ {
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("Unexpected error from handlePR: %v", err)
+		}
+	}
+	expected := fmt.Sprintf("org/repo#1:%s\n%s\n```release-note\nFoo now supports bar. (#2, @alice)\nACTION REQUIRED: The baz flag was removed. (#3, @bob)\n```", populatedNoteMarker, populatedNoteBody)
+	if !reflect.DeepEqual(fc.IssueCommentsAdded, []string{expected}) {
+		t.Errorf("Expected the comment %q once, but got %q.", expected, fc.IssueCommentsAdded)
+	}
+	labels, _ := fc.GetIssueLabels("org", "repo", 1)
+	if expected := []github.Label{{Name: releaseNoteActionRequired}}; !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected the cherry-pick to be labeled %v, but got %v.", expected, labels)
+	}
+}
+
+func TestFrontMatterNotes(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		overrides bool
+		expected  string
+	}{
+		{
+			name:     "front matter skip",
+			body:     "---\nrelease-note: skip\n---\nSome description.",
+			expected: releaseNoteNone,
+		},
+		{
+			name:     "front matter none",
+			body:     "---\nrelease-note: none\n---\n```release-note\n```",
+			expected: releaseNoteNone,
+		},
+		{
+			name:     "front matter string note",
+			body:     "---\nrelease-note: \"The foo command now supports the --bar flag.\"\nkind: feature\n---\nSome description.",
+			expected: releaseNote,
+		},
+		{
+			name:     "fenced block takes precedence",
+			body:     "---\nrelease-note: skip\n---\n```release-note\nA real note.\n```",
+			expected: releaseNote,
+		},
+		{
+			name:      "front matter takes precedence if configured",
+			body:      "---\nrelease-note: skip\n---\n```release-note\nA real note.\n```",
+			overrides: true,
+			expected:  releaseNoteNone,
+		},
+		{
+			name:     "front matter without a release note",
+			body:     "---\nkind: feature\n---\nSome description.",
+			expected: releaseNoteLabelNeeded,
+		},
+		{
+			name:     "horizontal rules aren't front matter",
+			body:     "Some description.\n---\nrelease-note: skip\n---\n",
+			expected: releaseNoteLabelNeeded,
+		},
+	}
+	for _, test := range tests {
+		cfg := &plugins.ReleaseNote{FrontMatterNotes: true, FrontMatterOverridesFence: test.overrides}
+		if actual := determineReleaseNoteLabel(cfg, test.body); actual != test.expected {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expected, actual)
+		}
+	}
+	if actual := determineReleaseNoteLabel(&plugins.ReleaseNote{}, "---\nrelease-note: skip\n---\n"); actual != releaseNoteLabelNeeded {
+		t.Errorf("Expected front matter to be ignored by default, but got %q.", actual)
+	}
+}
+
+func TestNoneCommandAllowedBots(t *testing.T) {
+	cfg := &plugins.ReleaseNote{NoneCommandAllowedBots: []string{"release-bot"}}
+	tests := []struct {
+		name      string
+		commenter github.User
+		cfg       *plugins.ReleaseNote
+		honored   bool
+	}{
+		{
+			name:      "allowlisted bot",
+			commenter: github.User{Login: "release-bot", Type: github.UserTypeBot},
+			cfg:       cfg,
+			honored:   true,
+		},
+		{
+			name:      "bot not in the allowlist",
+			commenter: github.User{Login: "other-bot", Type: github.UserTypeBot},
+			cfg:       cfg,
+		},
+		{
+			name:      "app not in the allowlist",
+			commenter: github.User{Login: "some-app[bot]"},
+			cfg:       cfg,
+		},
+		{
+			name:      "person",
+			commenter: github.User{Login: "member", Type: "User"},
+			cfg:       cfg,
+			honored:   true,
+		},
+		{
+			name:      "any bot without an allowlist",
+			commenter: github.User{Login: "other-bot", Type: github.UserTypeBot},
+			cfg:       &plugins.ReleaseNote{},
+			honored:   true,
+		},
+	}
+	for _, test := range tests {
+		fc, _ := newFakeClient("", "master", nil, nil, nil)
+		fc.OrgMembers = []string{"release-bot", "other-bot", "some-app[bot]", "member"}
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: "/release-note-none", User: test.commenter},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      1,
+				Labels:      []github.Label{{Name: releaseNoteLabelNeeded}},
+				PullRequest: &struct{}{},
+			},
+			Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+		}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), test.cfg, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		added := len(fc.LabelsAdded) == 1 && fc.LabelsAdded[0] == formatLabels(1, releaseNoteNone)[0]
+		if added != test.honored {
+			t.Errorf("(%s): Expected the command to be honored: %t, but got labels added %v.", test.name, test.honored, fc.LabelsAdded)
+		}
+		if !test.honored && len(fc.IssueCommentsAdded) != 0 {
+			t.Errorf("(%s): Expected an ignored command not to be answered, but got %v.", test.name, fc.IssueCommentsAdded)
+		}
+
+		// A later PR event must not apply an ignored command either.
+		comments := append(fc.IssueComments[1], ice.Comment)
+		fc, pr := newFakeClient("", "master", nil, nil, nil)
+		fc.IssueComments[1] = comments
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), test.cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if added := len(sliceDifference(formatLabels(1, releaseNoteNone), fc.LabelsAdded)) == 0; added != test.honored {
+			t.Errorf("(%s): Expected a later event to honor the command: %t, but got labels added %v.", test.name, test.honored, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestResolveInsteadOfRemove(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		initialLabels []string
+		resolve       bool
+
+		expectAdded   []string
+		expectRemoved []string
+	}{
+		{
+			name:          "satisfied PR gets the resolved label",
+			body:          "```release-note\nA note.\n```",
+			initialLabels: []string{releaseNoteLabelNeeded},
+			resolve:       true,
+			expectAdded:   []string{releaseNote, releaseNoteWasNeeded},
+			expectRemoved: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:          "without the option the needed label is just removed",
+			body:          "```release-note\nA note.\n```",
+			initialLabels: []string{releaseNoteLabelNeeded},
+			expectAdded:   []string{releaseNote},
+			expectRemoved: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:          "PR that was never blocked doesn't get the resolved label",
+			body:          "```release-note\nA note.\n```",
+			initialLabels: []string{releaseNoteNone},
+			resolve:       true,
+			expectAdded:   []string{releaseNote},
+			expectRemoved: []string{releaseNoteNone},
+		},
+		{
+			name:          "resolved label isn't added twice",
+			body:          "```release-note\nA note.\n```",
+			initialLabels: []string{releaseNoteLabelNeeded, releaseNoteWasNeeded},
+			resolve:       true,
+			expectAdded:   []string{releaseNote},
+			expectRemoved: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:          "still blocked PR keeps the needed label",
+			initialLabels: []string{releaseNoteLabelNeeded},
+			resolve:       true,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		fc.ExistingLabels = append(fc.ExistingLabels, releaseNoteWasNeeded)
+		cfg := &plugins.ReleaseNote{ResolveInsteadOfRemove: test.resolve}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		added := sliceDifference(fc.LabelsAdded, formatLabels(1, test.initialLabels...))
+		if expected := formatLabels(1, test.expectAdded...); len(added) != len(expected) || len(sliceDifference(expected, added)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expected, added)
+		}
+		expected := formatLabels(1, test.expectRemoved...)
+		if len(sliceDifference(expected, fc.LabelsRemoved)) > 0 || len(sliceDifference(fc.LabelsRemoved, expected)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, expected, fc.LabelsRemoved)
+		}
+	}
+
+	// The resolved label is also part of a batched replacement.
+	fc, pr := newFakeClient("```release-note\nA note.\n```", "master", []string{"lgtm", releaseNoteLabelNeeded}, nil, nil)
+	fc.ExistingLabels = append(fc.ExistingLabels, releaseNoteWasNeeded)
+	cfg := &plugins.ReleaseNote{ResolveInsteadOfRemove: true, BatchLabelChanges: true}
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if expected := []string{"org/repo#1:lgtm,release-note," + releaseNoteWasNeeded}; !reflect.DeepEqual(fc.LabelsReplaced, expected) {
+		t.Errorf("Expected labels to be replaced with %q, but got %q.", expected, fc.LabelsReplaced)
+	}
+}
+
+func TestNoneSentinel(t *testing.T) {
+	cfg := &plugins.ReleaseNote{NoneSentinel: "keine"}
+	tests := []struct {
+		name     string
+		command  string
+		cfg      *plugins.ReleaseNote
+		expected bool
+	}{
+		{
+			name:     "default command",
+			command:  "/release-note-none",
+			cfg:      &plugins.ReleaseNote{},
+			expected: true,
+		},
+		{
+			name:     "default command with a sentinel",
+			command:  "/release-note-none",
+			cfg:      cfg,
+			expected: true,
+		},
+		{
+			name:     "alias command",
+			command:  "/keine",
+			cfg:      cfg,
+			expected: true,
+		},
+		{
+			name:    "alias command without a sentinel",
+			command: "/keine",
+			cfg:     &plugins.ReleaseNote{},
+		},
+	}
+	for _, test := range tests {
+		fc, _ := newFakeClient("", "master", nil, nil, nil)
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: test.command, User: github.User{Login: "a"}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      1,
+				Labels:      []github.Label{{Name: releaseNoteLabelNeeded}},
+				PullRequest: &struct{}{},
+			},
+			Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+		}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), test.cfg, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		applied := len(fc.LabelsAdded) == 1 && fc.LabelsAdded[0] == formatLabels(1, releaseNoteNone)[0]
+		if applied != test.expected {
+			t.Errorf("(%s): Expected %s to be applied: %t, but got labels added %v.", test.name, releaseNoteNone, test.expected, fc.LabelsAdded)
+		}
+	}
+
+	if actual := determineReleaseNoteLabel(cfg, "```release-note\nKeine\n```"); actual != releaseNoteNone {
+		t.Errorf("Expected the sentinel to mark the note as none, but got %q.", actual)
+	}
+}
+
+func TestAutoNoneMinApprovals(t *testing.T) {
+	approve := func(user string) github.Review {
+		return github.Review{User: github.User{Login: user}, State: github.ReviewStateApproved}
+	}
+	tests := []struct {
+		name        string
+		body        string
+		reviews     []github.Review
+		labelsAdded []string
+	}{
+		{
+			name:        "insufficient approvals keep the needed label",
+			body:        "```release-note\n```",
+			reviews:     []github.Review{approve("alice")},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name: "auto-none once the approvals are met",
+			body: "```release-note\n```",
+			reviews: []github.Review{
+				approve("alice"),
+				{User: github.User{Login: "alice"}, State: github.ReviewStateCommented},
+				approve("bob"),
+			},
+			labelsAdded: []string{releaseNoteNone},
+		},
+		{
+			name: "changes requested after approving don't count",
+			body: "```release-note\n```",
+			reviews: []github.Review{
+				approve("alice"),
+				approve("bob"),
+				{User: github.User{Login: "bob"}, State: github.ReviewStateChangesRequested},
+			},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "explicit none isn't gated",
+			body:        "```release-note\nNONE\n```",
+			labelsAdded: []string{releaseNoteNone},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		fc.PullRequestChanges = map[int][]github.PullRequestChange{1: {{Filename: "a.go", Additions: 5}}}
+		fc.Reviews = map[int][]github.Review{1: test.reviews}
+		cfg := &plugins.ReleaseNote{RequireNoteOverChangedLines: 100, AutoNoneMinApprovals: 2}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		expectLabels := formatLabels(1, test.labelsAdded...)
+		if !reflect.DeepEqual(fc.LabelsAdded, expectLabels) {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expectLabels, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestMaxBotComments(t *testing.T) {
+	tests := []struct {
+		name          string
+		posted        int
+		expectComment bool
+	}{
+		{
+			name:          "below the limit",
+			posted:        1,
+			expectComment: true,
+		},
+		{
+			name:   "at the limit",
+			posted: 2,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n```", "master", nil, nil, nil)
+		for i := 0; i < test.posted; i++ {
+			fc.IssueComments[1] = append(fc.IssueComments[1], github.IssueComment{
+				ID:   100 + i,
+				Body: "Some earlier guidance.\n" + botCommentMarker,
+				User: github.User{Login: "k8s-ci-robot"},
+			})
+		}
+		// Comments by others don't count towards the limit.
+		fc.IssueComments[1] = append(fc.IssueComments[1], github.IssueComment{
+			ID:   200,
+			Body: "Some earlier guidance.\n" + botCommentMarker,
+			User: github.User{Login: "someone"},
+		})
+		cfg := &plugins.ReleaseNote{MaxBotComments: 2}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if commented := len(fc.IssueCommentsAdded) > 0; commented != test.expectComment {
+			t.Errorf("(%s): Expected a comment: %t, but got %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+		if test.expectComment && !strings.HasSuffix(fc.IssueCommentsAdded[0], botCommentMarker) {
+			t.Errorf("(%s): Expected the comment to be marked, but got %q.", test.name, fc.IssueCommentsAdded[0])
+		}
+		if expected := formatLabels(1, releaseNoteLabelNeeded); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+	}
+}
+
+func TestMaxBotCommentsNoneCommand(t *testing.T) {
+	fc, pr := newFakeClient("```release-note\n```", "master", []string{releaseNoteLabelNeeded, "approved"}, nil, nil)
+	fc.OrgMembers = []string{"m"}
+	for i := 0; i < 2; i++ {
+		fc.IssueComments[1] = append(fc.IssueComments[1], github.IssueComment{
+			ID:   100 + i,
+			Body: "Some earlier guidance.\n" + botCommentMarker,
+			User: github.User{Login: "k8s-ci-robot"},
+		})
+	}
+	cfg := &plugins.ReleaseNote{
+		MaxBotComments:    2,
+		NonePreconditions: []plugins.NonePrecondition{{Labels: []string{"approved"}}},
+	}
+	ice := github.IssueCommentEvent{
+		Action:  github.IssueCommentActionCreated,
+		Comment: github.IssueComment{Body: "/release-note-none", User: github.User{Login: "m"}},
+		Issue: github.Issue{
+			User:        github.User{Login: "cjwagner"},
+			Number:      1,
+			Labels:      []github.Label{{Name: releaseNoteLabelNeeded}, {Name: "approved"}},
+			PullRequest: &struct{}{},
+		},
+		Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+	}
+	if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ice); err != nil {
+		t.Fatalf("Unexpected error from handleComment: %v", err)
+	}
+	if len(fc.IssueCommentsAdded) != 1 || !strings.Contains(fc.IssueCommentsAdded[0], acceptedNoneMarker) {
+		t.Fatalf("Expected the accepted command to be recorded despite the limit, but got %q.", fc.IssueCommentsAdded)
+	}
+	if strings.Contains(fc.IssueCommentsAdded[0], botCommentMarker) {
+		t.Errorf("Expected the record not to count towards the limit, but got %q.", fc.IssueCommentsAdded[0])
+	}
+
+	// A later PR event keeps the label.
+	fc.IssueComments[1] = append(fc.IssueComments[1], ice.Comment)
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	for _, l := range fc.LabelsRemoved {
+		if l == formatLabels(1, releaseNoteNone)[0] {
+			t.Errorf("Expected %q to be kept, but got labels removed %q.", releaseNoteNone, fc.LabelsRemoved)
+		}
+	}
+}
+
+func TestNotActionRequiredOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "override present",
+			body:     "<!-- release-note: not-action-required -->\n```release-note\nFoo now supports bar, no action required.\n```",
+			expected: releaseNote,
+		},
+		{
+			name:     "override absent",
+			body:     "```release-note\nFoo now supports bar, no action required.\n```",
+			expected: releaseNoteActionRequired,
+		},
+		{
+			name:     "override doesn't affect none",
+			body:     "<!--release-note:not-action-required-->\n```release-note\nNONE\n```",
+			expected: releaseNoteNone,
+		},
+	}
+	for _, test := range tests {
+		if actual := determineReleaseNoteLabel(&plugins.ReleaseNote{}, test.body); actual != test.expected {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestLatestCommandWins(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	none := func(minutes int) github.IssueComment {
+		return github.IssueComment{Body: "/release-note-none", User: github.User{Login: "cjwagner"}, CreatedAt: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+	recorded := func(minutes int) github.IssueComment {
+		return github.IssueComment{Body: recordedNoteMarker + "\nA recorded note.", User: github.User{Login: "k8s-ci-robot"}, CreatedAt: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+	tests := []struct {
+		name     string
+		comments []github.IssueComment
+		expected string
+	}{
+		{
+			name:     "none after a recorded note",
+			comments: []github.IssueComment{recorded(1), none(2)},
+			expected: releaseNoteNone,
+		},
+		{
+			name:     "recorded note after none",
+			comments: []github.IssueComment{none(1), recorded(2)},
+			expected: releaseNoteActionRequired,
+		},
+		{
+			name:     "interleaved, listed out of order",
+			comments: []github.IssueComment{none(3), recorded(1), none(2), recorded(4)},
+			expected: releaseNoteActionRequired,
+		},
+		{
+			name:     "interleaved, none is latest",
+			comments: []github.IssueComment{recorded(1), none(2), recorded(3), none(4)},
+			expected: releaseNoteNone,
+		},
+		{
+			name:     "no commands",
+			comments: []github.IssueComment{{Body: "lgtm", CreatedAt: start}},
+		},
+	}
+	for _, test := range tests {
+		fc, _ := newFakeClient("", "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{RecordActionRequiredNotes: true}
+		label, err := latestCommandLabel(fc, cfg, test.comments)
+		if err != nil {
+			t.Fatalf("(%s): Unexpected error: %v", test.name, err)
+		}
+		if label != test.expected {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expected, label)
+		}
+	}
+
+	// handlePR honors the latest command.
+	fc, pr := newFakeClient("```release-note\n```", "master", []string{releaseNoteLabelNeeded}, nil, nil)
+	fc.IssueComments[1] = []github.IssueComment{none(1), recorded(2)}
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{RecordActionRequiredNotes: true}, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if added := sliceDifference(fc.LabelsAdded, formatLabels(1, releaseNoteLabelNeeded)); !reflect.DeepEqual(added, formatLabels(1, releaseNoteActionRequired)) {
+		t.Errorf("Expected %q to be added, but got %q.", releaseNoteActionRequired, added)
+	}
+}
+
+func TestLatestCommandRecorded(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	raw := github.IssueComment{Body: "/release-note-none", User: github.User{Login: "cjwagner"}, CreatedAt: start}
+	record := github.IssueComment{Body: acceptedNoneMarker + "\n" + fmt.Sprintf(acceptedNoneFormat, "cjwagner"), User: github.User{Login: "k8s-ci-robot"}, CreatedAt: start}
+	forged := github.IssueComment{Body: record.Body, User: github.User{Login: "cjwagner"}, CreatedAt: start}
+	tests := []struct {
+		name     string
+		cfg      *plugins.ReleaseNote
+		comments []github.IssueComment
+		expected string
+	}{
+		{
+			name:     "raw command without preconditions",
+			cfg:      &plugins.ReleaseNote{},
+			comments: []github.IssueComment{raw},
+			expected: releaseNoteNone,
+		},
+		{
+			name:     "raw command with preconditions",
+			cfg:      &plugins.ReleaseNote{NonePreconditions: []plugins.NonePrecondition{{Approved: true}}},
+			comments: []github.IssueComment{raw},
+		},
+		{
+			name:     "raw command with allowed bots",
+			cfg:      &plugins.ReleaseNote{NoneCommandAllowedBots: []string{"release-bot"}},
+			comments: []github.IssueComment{raw},
+		},
+		{
+			name:     "recorded command with preconditions",
+			cfg:      &plugins.ReleaseNote{NonePreconditions: []plugins.NonePrecondition{{Approved: true}}},
+			comments: []github.IssueComment{raw, record},
+			expected: releaseNoteNone,
+		},
+		{
+			name:     "record not left by the bot",
+			cfg:      &plugins.ReleaseNote{NonePreconditions: []plugins.NonePrecondition{{Approved: true}}},
+			comments: []github.IssueComment{forged},
+		},
+	}
+	for _, test := range tests {
+		fc, _ := newFakeClient("", "master", nil, nil, nil)
+		label, err := latestCommandLabel(fc, test.cfg, test.comments)
+		if err != nil {
+			t.Fatalf("(%s): Unexpected error: %v", test.name, err)
+		}
+		if label != test.expected {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expected, label)
+		}
+	}
+}
+
+func TestWarnVerboseNotes(t *testing.T) {
+	description := "This PR refactors the foo controller so that it reconciles bar objects in parallel.\nIt also adds unit tests for the new worker pool and fixes a typo in the docs."
+	tests := []struct {
+		name             string
+		body             string
+		expectSuggestion bool
+	}{
+		{
+			name:             "note repeats the description",
+			body:             description + "\n```release-note\n" + description + "\n```",
+			expectSuggestion: true,
+		},
+		{
+			name: "concise distinct note",
+			body: description + "\n```release-note\nThe foo controller is faster for clusters with many bar objects.\n```",
+		},
+		{
+			name: "short description",
+			body: "Speeds up foo.\n```release-note\nSpeeds up foo.\n```",
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{WarnVerboseNotes: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, releaseNote); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		suggested := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), verboseNoteBody)
+		if suggested != test.expectSuggestion {
+			t.Errorf("(%s): Expected a suggestion: %t, but got %q.", test.name, test.expectSuggestion, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestSkipBranches(t *testing.T) {
+	tests := []struct {
+		name          string
+		branch        string
+		expectHandled bool
+	}{
+		{
+			name:   "skip branch",
+			branch: "docs-staging",
+		},
+		{
+			name:   "skip branch pattern",
+			branch: "docs-preview",
+		},
+		{
+			name:          "normal branch",
+			branch:        "master",
+			expectHandled: true,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n```", test.branch, nil, nil, nil)
+		cfg := &plugins.ReleaseNote{SkipBranches: []string{"docs-staging", "docs-p*"}, ReportStatus: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		handled := len(fc.LabelsAdded) > 0 || len(fc.IssueCommentsAdded) > 0 || len(fc.CreatedStatuses) > 0
+		if handled != test.expectHandled {
+			t.Errorf("(%s): Expected the PR to be handled: %t, but got labels %q, comments %q and statuses %v.", test.name, test.expectHandled, fc.LabelsAdded, fc.IssueCommentsAdded, fc.CreatedStatuses)
+		}
+	}
+}
+
+func TestMisfencedNote(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		expectHint bool
+	}{
+		{
+			name:       "note in a go fence",
+			body:       "Adds the bar flag.\n```go\nThe foo command now supports the --bar flag.\n```",
+			expectHint: true,
+		},
+		{
+			name:       "note in a plain fence",
+			body:       "Adds the bar flag.\n```\nThe foo command now supports the --bar flag.\n```\n```release-note\n```",
+			expectHint: true,
+		},
+		{
+			name: "correct note",
+			body: "```release-note\nThe foo command now supports the --bar flag.\n```",
+		},
+		{
+			name: "code in a fence",
+			body: "Adds the bar flag.\n```go\nfunc main() {\n\tfmt.Println(\"hello, world\")\n}\n```",
+		},
+		{
+			name: "several prose fences",
+			body: "```\nThe foo command now supports the --bar flag.\n```\n```\nThe baz command now supports the --qux flag.\n```",
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		hinted := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), misfencedNoteBody)
+		if hinted != test.expectHint {
+			t.Errorf("(%s): Expected a hint: %t, but got %q.", test.name, test.expectHint, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestBodyNoteWinsSilently(t *testing.T) {
+	for _, silent := range []bool{false, true} {
+		body := "```release-note\nThe foo command now supports the --bar flag.\n```"
+		fc, _ := newFakeClient(body, "master", nil, nil, nil)
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: "/release-note-none", User: github.User{Login: "a"}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      1,
+				Body:        body,
+				Labels:      []github.Label{{Name: releaseNote}},
+				PullRequest: &struct{}{},
+			},
+			Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+		}
+		cfg := &plugins.ReleaseNote{BodyNoteWinsSilently: silent}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), cfg, ice); err != nil {
+			t.Fatalf("(silent=%t): Unexpected error from handleComment: %v", silent, err)
+		}
+		if len(fc.LabelsAdded) > 0 || len(fc.LabelsRemoved) > 0 {
+			t.Errorf("(silent=%t): Expected the labels to be kept, but got %q added and %q removed.", silent, fc.LabelsAdded, fc.LabelsRemoved)
+		}
+		rejected := len(fc.IssueCommentsAdded) == 1 && strings.Contains(fc.IssueCommentsAdded[0], "if the release-note block in the PR body text is empty")
+		if rejected == silent {
+			t.Errorf("(silent=%t): Expected a rejection: %t, but got %q.", silent, !silent, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestFormFieldNotes(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expectNote  string
+		expectLabel string
+	}{
+		{
+			name:        "form-rendered note with an answer",
+			body:        "### Description\n\nAdds the bar flag.\n\n### Release note\n\nThe foo command now supports the --bar flag.\n\n### Checklist\n\n- [x] Tests",
+			expectNote:  "The foo command now supports the --bar flag.",
+			expectLabel: releaseNote,
+		},
+		{
+			name:        "form-rendered note without a response",
+			body:        "### Description\r\n\r\nFixes a typo.\r\n\r\n### Release note\r\n\r\n_No response_\r\n",
+			expectNote:  "NONE",
+			expectLabel: releaseNoteNone,
+		},
+		{
+			name:        "form-rendered note rendered as code",
+			body:        "### Release notes\n\n```text\nAction required: the baz flag was removed.\n```",
+			expectNote:  "Action required: the baz flag was removed.",
+			expectLabel: releaseNoteActionRequired,
+		},
+		{
+			name:        "fenced block takes precedence",
+			body:        "### Release note\n\n_No response_\n\n```release-note\nThe foo command now supports the --bar flag.\n```",
+			expectNote:  "The foo command now supports the --bar flag.",
+			expectLabel: releaseNote,
+		},
+		{
+			name:        "no form field",
+			body:        "### Description\n\nAdds the bar flag.",
+			expectLabel: releaseNoteLabelNeeded,
+		},
+	}
+	for _, test := range tests {
+		cfg := &plugins.ReleaseNote{FormFieldNotes: true}
+		if actual := getReleaseNote(cfg, test.body); actual != test.expectNote {
+			t.Errorf("(%s): Expected the note %q, but got %q.", test.name, test.expectNote, actual)
+		}
+		if actual := determineReleaseNoteLabel(cfg, test.body); actual != test.expectLabel {
+			t.Errorf("(%s): Expected %q, but got %q.", test.name, test.expectLabel, actual)
+		}
+	}
+	if actual := determineReleaseNoteLabel(&plugins.ReleaseNote{}, "### Release note\n\nA note."); actual != releaseNoteLabelNeeded {
+		t.Errorf("Expected form fields to be ignored by default, but got %q.", actual)
+	}
+}
+
+func TestStickyCommentRecreate(t *testing.T) {
+	guidance := func(id int) github.IssueComment {
+		return github.IssueComment{ID: id, Body: plugins.FormatResponse("cjwagner", releaseNoteBody(&plugins.ReleaseNote{}), releaseNoteSuffix(&plugins.ReleaseNote{})), User: github.User{Login: "k8s-ci-robot"}}
+	}
+	tests := []struct {
+		name          string
+		comments      []github.IssueComment
+		recreate      bool
+		expectComment bool
+		expectDeleted []string
+	}{
+		{
+			name:          "deleted guidance is recreated",
+			recreate:      true,
+			expectComment: true,
+		},
+		{
+			name:     "deleted guidance isn't recreated by default",
+			recreate: false,
+		},
+		{
+			name:     "present guidance isn't duplicated",
+			comments: []github.IssueComment{guidance(1)},
+			recreate: true,
+		},
+		{
+			name:          "duplicate guidance is deleted",
+			comments:      []github.IssueComment{guidance(1), {ID: 2, Body: "lgtm", User: github.User{Login: "someone"}}, guidance(3)},
+			recreate:      true,
+			expectDeleted: []string{"org/repo#3"},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n```", "master", []string{releaseNoteLabelNeeded}, nil, nil)
+		fc.IssueComments[1] = test.comments
+		cfg := &plugins.ReleaseNote{StickyCommentRecreate: test.recreate}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		commented := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), releaseNoteBody(&plugins.ReleaseNote{}))
+		if commented != test.expectComment {
+			t.Errorf("(%s): Expected the guidance to be posted: %t, but got %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+		if !reflect.DeepEqual(fc.IssueCommentsDeleted, test.expectDeleted) {
+			t.Errorf("(%s): Expected comments %q to be deleted, but got %q.", test.name, test.expectDeleted, fc.IssueCommentsDeleted)
+		}
+	}
+}
+
+func TestRequireNoteForPaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		files       []string
+		labelsAdded []string
+	}{
+		{
+			name:        "API change with empty block is blocked",
+			body:        "```release-note\n```",
+			files:       []string{"README.md", "pkg/apis/core/types.go"},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "API change matching a directory pattern is blocked",
+			body:        "```release-note\n```",
+			files:       []string{"staging/api/v1/types.go"},
+			labelsAdded: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:        "API change with a real note passes",
+			body:        "```release-note\nThe Foo type has a new Bar field.\n```",
+			files:       []string{"pkg/apis/core/types.go"},
+			labelsAdded: []string{releaseNote},
+		},
+		{
+			name:        "non-API change is auto-none",
+			body:        "```release-note\n```",
+			files:       []string{"pkg/kubelet/kubelet.go", "pkg/apis.go"},
+			labelsAdded: []string{releaseNoteNone},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		var changes []github.PullRequestChange
+		for _, file := range test.files {
+			changes = append(changes, github.PullRequestChange{Filename: file, Additions: 1})
+		}
+		fc.PullRequestChanges = map[int][]github.PullRequestChange{1: changes}
+		cfg := &plugins.ReleaseNote{RequireNoteOverChangedLines: 100, RequireNoteForPaths: []string{"pkg/apis/", "*/api"}}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		expectLabels := formatLabels(1, test.labelsAdded...)
+		if !reflect.DeepEqual(fc.LabelsAdded, expectLabels) {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, expectLabels, fc.LabelsAdded)
+		}
+	}
+
+	// PRs without the template aren't auto-none if they change API types.
+	fc, pr := newFakeClient("No template here.", "master", nil, nil, nil)
+	fc.PullRequestChanges = map[int][]github.PullRequestChange{1: {{Filename: "pkg/apis/core/types.go"}}}
+	cfg := &plugins.ReleaseNote{NoTemplateBehavior: noTemplateAutoNone, RequireNoteForPaths: []string{"pkg/apis"}}
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if expected := formatLabels(1, releaseNoteLabelNeeded); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+		t.Errorf("Expected labels %q to be added, but got %q.", expected, fc.LabelsAdded)
+	}
+}
+
+func TestWarnLinkPlaceholders(t *testing.T) {
+	tests := []struct {
+		name             string
+		note             string
+		expectSuggestion bool
+	}{
+		{
+			name:             "URL placeholder",
+			note:             "The foo command now supports the --bar flag, see the [docs](URL).",
+			expectSuggestion: true,
+		},
+		{
+			name:             "link placeholder",
+			note:             "The foo command now supports the --bar flag, see the [docs](link).",
+			expectSuggestion: true,
+		},
+		{
+			name:             "empty link target",
+			note:             "The foo command now supports the --bar flag, see the [docs]( ).",
+			expectSuggestion: true,
+		},
+		{
+			name: "real URL",
+			note: "The foo command now supports the --bar flag, see the [docs](https://example.com/docs/url).",
+		},
+		{
+			name: "parenthesized text",
+			note: "The foo command now supports the --bar flag (link).",
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n"+test.note+"\n```", "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{WarnLinkPlaceholders: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, releaseNote); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		suggested := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), linkPlaceholderBody)
+		if suggested != test.expectSuggestion {
+			t.Errorf("(%s): Expected a suggestion: %t, but got %q.", test.name, test.expectSuggestion, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestActionRequiredReviewTeam(t *testing.T) {
+	actionBody := "```release-note\naction required: run the migration before upgrading\n```"
+	tests := []struct {
+		name          string
+		body          string
+		initialLabels []string
+		requested     []github.User
+		expected      []string
+	}{
+		{
+			name:     "becomes action required",
+			body:     actionBody,
+			expected: []string{"org/repo#1:sig-lead"},
+		},
+		{
+			name:          "already action required",
+			body:          actionBody,
+			initialLabels: []string{releaseNoteActionRequired},
+		},
+		{
+			name:      "team member already requested",
+			body:      actionBody,
+			requested: []github.User{{Login: "Sig-Lead"}},
+		},
+		{
+			name: "not action required",
+			body: "```release-note\nThe foo command now supports the --bar flag.\n```",
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		pr.PullRequest.RequestedReviewers = test.requested
+		cfg := &plugins.ReleaseNote{ActionRequiredReviewTeamID: 42}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(fc.ReviewersRequested, test.expected) {
+			t.Errorf("(%s): Expected reviewers %q to be requested, but got %q.", test.name, test.expected, fc.ReviewersRequested)
+		}
+	}
+
+	// Later edits to the note don't request the review again.
+	fc, pr := newFakeClient(actionBody, "master", nil, nil, nil)
+	cfg := &plugins.ReleaseNote{ActionRequiredReviewTeamID: 42}
+	for i := 0; i < 2; i++ {
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("Unexpected error from handlePR: %v", err)
+		}
+		pr.PullRequest.Body = actionBody + "\nEdited."
+	}
+	if expected := []string{"org/repo#1:sig-lead"}; !reflect.DeepEqual(fc.ReviewersRequested, expected) {
+		t.Errorf("Expected reviewers %q to be requested once, but got %q.", expected, fc.ReviewersRequested)
+	}
+}
+
+// labelListFailer is a githubClient whose GetIssueLabels always fails.
+type labelListFailer struct {
+	githubClient
+}
+
+func (c *labelListFailer) GetIssueLabels(org, repo string, number int) ([]github.Label, error) {
+	return nil, fmt.Errorf("injected error")
+}
+
+func TestFallbackToEventLabels(t *testing.T) {
+	tests := []struct {
+		name            string
+		fallback        bool
+		batch           bool
+		eventLabels     []github.Label
+		expectErr       bool
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{
+			name:            "fallback with event labels",
+			fallback:        true,
+			eventLabels:     []github.Label{{Name: releaseNoteLabelNeeded}},
+			expectedAdded:   formatLabels(1, releaseNote),
+			expectedRemoved: formatLabels(1, releaseNoteLabelNeeded),
+		},
+		{
+			name:            "event labels are never written back in a batch",
+			fallback:        true,
+			batch:           true,
+			eventLabels:     []github.Label{{Name: "lgtm"}, {Name: releaseNoteLabelNeeded}, {Name: releaseNoteNone}},
+			expectedAdded:   formatLabels(1, releaseNote),
+			expectedRemoved: formatLabels(1, releaseNoteLabelNeeded, releaseNoteNone),
+		},
+		{
+			name:      "fallback without event labels",
+			fallback:  true,
+			expectErr: true,
+		},
+		{
+			name:        "no fallback",
+			eventLabels: []github.Label{{Name: releaseNoteLabelNeeded}},
+			expectErr:   true,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\nThe foo command now supports the --bar flag.\n```", "master", nil, nil, nil)
+		pr.PullRequest.Labels = test.eventLabels
+		cfg := &plugins.ReleaseNote{FallbackToEventLabels: test.fallback, BatchLabelChanges: test.batch}
+		err := handlePR(&labelListFailer{githubClient: fc}, logrus.WithField("plugin", pluginName), cfg, pr)
+		if err != nil && !test.expectErr {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		} else if err == nil && test.expectErr {
+			t.Fatalf("(%s): Expected an error from handlePR, but got none.", test.name)
+		}
+		if len(fc.LabelsAdded) != len(test.expectedAdded) || len(sliceDifference(fc.LabelsAdded, test.expectedAdded)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, test.expectedAdded, fc.LabelsAdded)
+		}
+		// The needed label may be removed more than once.
+		if len(sliceDifference(fc.LabelsRemoved, test.expectedRemoved)) > 0 || len(sliceDifference(test.expectedRemoved, fc.LabelsRemoved)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, test.expectedRemoved, fc.LabelsRemoved)
+		}
+		if len(fc.LabelsReplaced) > 0 {
+			t.Errorf("(%s): Unexpected label replacements %q.", test.name, fc.LabelsReplaced)
+		}
+	}
+}
+
+func TestBlockReferenceOnlyNotes(t *testing.T) {
+	tests := []struct {
+		name          string
+		note          string
+		expectedLabel string
+	}{
+		{
+			name:          "issue reference only",
+			note:          "#1234",
+			expectedLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:          "linked cross-repo reference only",
+			note:          "- [kubernetes/kubernetes#1234](https://github.com/kubernetes/kubernetes/pull/1234).",
+			expectedLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:          "URL only",
+			note:          "https://github.com/kubernetes/kubernetes/issues/1234",
+			expectedLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:          "reference with prose",
+			note:          "Fixed a crash when the config is empty (#1234).",
+			expectedLabel: releaseNote,
+		},
+		{
+			name:          "none",
+			note:          "NONE",
+			expectedLabel: releaseNoteNone,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n"+test.note+"\n```", "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{BlockReferenceOnlyNotes: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, test.expectedLabel); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		commented := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), referenceOnlyBody)
+		if expected := test.expectedLabel == releaseNoteLabelNeeded; commented != expected {
+			t.Errorf("(%s): Expected a comment asking for prose: %t, but got %q.", test.name, expected, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestHelpProvider(t *testing.T) {
+	config := &plugins.Configuration{
+		ReleaseNotes: []plugins.ReleaseNote{
+			{Repos: []string{"org/repo"}, PrimaryBranches: []string{"main"}, SecurityNotePatterns: []string{"CVE-"}},
+		},
+	}
+	help, err := helpProvider(config, []string{"org/repo", "other"})
+	if err != nil {
+		t.Fatalf("Unexpected error from helpProvider: %v", err)
+	}
+	for _, label := range allRNLabels {
+		if !strings.Contains(help.Description, label) {
+			t.Errorf("Expected the description to explain the label %q, but got %q.", label, help.Description)
+		}
+	}
+	if len(help.Commands) != 2 || help.Commands[0].Usage != "/release-note-none" || len(help.Commands[0].Examples) == 0 || help.Commands[0].WhoCanUse == "" {
+		t.Fatalf("Expected help for the /release-note-none command, but got %+v.", help.Commands)
+	}
+	if help.Commands[1].Usage != "/release-note-refresh" {
+		t.Errorf("Expected help for the /release-note-refresh command, but got %+v.", help.Commands[1])
+	}
+	if !strings.Contains(help.Commands[0].Description, "deprecated") {
+		t.Errorf("Expected the command help to mention the deprecated commands, but got %q.", help.Commands[0].Description)
+	}
+	if c := help.Config["org/repo"]; !strings.Contains(c, "main") || !strings.Contains(c, releaseNoteSecurity) {
+		t.Errorf("Expected the org/repo config to mention its branches and labels, but got %q.", c)
+	}
+	if c := help.Config["other"]; !strings.Contains(c, "master") {
+		t.Errorf("Expected the config of other to mention the default branch, but got %q.", c)
+	}
+}
+
+func TestBumpNotes(t *testing.T) {
+	bumps := []plugins.BumpNote{{
+		Authors:      []string{"k8s-ci-robot"},
+		TitlePattern: `^Bump (?P<name>\S+) to (?P<version>\S+)$`,
+		NoteTemplate: "Updated {{.Groups.name}} to version {{.Groups.version}}.",
+	}}
+	tests := []struct {
+		name          string
+		author        string
+		title         string
+		body          string
+		expectedLabel string
+		expectedNote  string
+	}{
+		{
+			name:          "matching bump PR",
+			author:        "k8s-ci-robot",
+			title:         "Bump gcr.io/k8s-prow/hook to v20180101-abcdef",
+			expectedLabel: releaseNote,
+			expectedNote:  "Updated gcr.io/k8s-prow/hook to version v20180101-abcdef.",
+		},
+		{
+			name:          "other author",
+			author:        "cjwagner",
+			title:         "Bump gcr.io/k8s-prow/hook to v20180101-abcdef",
+			expectedLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:          "other title",
+			author:        "k8s-ci-robot",
+			title:         "Update the hook deployment",
+			expectedLabel: releaseNoteLabelNeeded,
+		},
+		{
+			name:          "the body's note wins",
+			author:        "k8s-ci-robot",
+			title:         "Bump gcr.io/k8s-prow/hook to v20180101-abcdef",
+			body:          "```release-note\nNONE\n```",
+			expectedLabel: releaseNoteNone,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", nil, nil, nil)
+		pr.PullRequest.User.Login = test.author
+		pr.PullRequest.Title = test.title
+		cfg := &plugins.ReleaseNote{BumpNotes: bumps}
+		// The synthesized note is only recorded once.
+		for i := 0; i < 2; i++ {
+			if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+				t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+			}
+		}
+		if expected := formatLabels(1, test.expectedLabel); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		var recorded []string
+		for _, c := range fc.IssueCommentsAdded {
+			if strings.Contains(c, bumpNoteMarker) {
+				recorded = append(recorded, c)
+			}
+		}
+		var expected []string
+		if test.expectedNote != "" {
+			expected = []string{fmt.Sprintf("org/repo#1:%s\n%s\n```release-note\n%s\n```", bumpNoteMarker, bumpNoteBody, test.expectedNote)}
+		}
+		if !reflect.DeepEqual(recorded, expected) {
+			t.Errorf("(%s): Expected the recorded notes %q, but got %q.", test.name, expected, recorded)
+		}
+	}
+}
+
+func TestReleaseNoteRefresh(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		branch          string
+		commenter       string
+		initialLabels   []string
+		comments        []string
+		parentPRs       map[int]string
+		expectedAdded   []string
+		expectedRemoved []string
+		expectRejection bool
+	}{
+		{
+			name:            "author refreshes a PR whose note was added",
+			body:            "```release-note\nThe foo command now supports the --bar flag.\n```",
+			commenter:       "a",
+			initialLabels:   []string{releaseNoteLabelNeeded},
+			expectedAdded:   formatLabels(1, releaseNoteLabelNeeded, releaseNote),
+			expectedRemoved: formatLabels(1, releaseNoteLabelNeeded),
+		},
+		{
+			name:          "refresh clears stale guidance",
+			body:          "```release-note\nThe foo command now supports the --bar flag.\n```",
+			commenter:     "a",
+			initialLabels: []string{releaseNote},
+			comments:      []string{releaseNoteBody(&plugins.ReleaseNote{})},
+			expectedAdded: formatLabels(1, releaseNote),
+		},
+		{
+			name:          "member refreshes a PR without a note",
+			body:          "```release-note\n\n```",
+			commenter:     "m",
+			expectedAdded: formatLabels(1, releaseNoteLabelNeeded),
+		},
+		{
+			name:            "refresh of a cherry-pick whose parent has a note",
+			body:            "Cherry pick of #2 on release-1.20.",
+			branch:          "release-1.20",
+			commenter:       "a",
+			initialLabels:   []string{releaseNoteLabelNeeded},
+			parentPRs:       map[int]string{2: releaseNote},
+			expectedAdded:   append(formatLabels(1, releaseNoteLabelNeeded), formatLabels(2, releaseNote)...),
+			expectedRemoved: formatLabels(1, releaseNoteLabelNeeded),
+		},
+		{
+			name:            "others can't refresh",
+			body:            "```release-note\nThe foo command now supports the --bar flag.\n```",
+			commenter:       "x",
+			initialLabels:   []string{releaseNoteLabelNeeded},
+			expectedAdded:   formatLabels(1, releaseNoteLabelNeeded),
+			expectRejection: true,
+		},
+	}
+	for _, test := range tests {
+		branch := test.branch
+		if branch == "" {
+			branch = "master"
+		}
+		fc, pr := newFakeClient(test.body, branch, test.initialLabels, nil, test.parentPRs)
+		for _, c := range test.comments {
+			fc.IssueComments[1] = append(fc.IssueComments[1], github.IssueComment{Body: c, User: github.User{Login: "k8s-ci-robot"}})
+		}
+		fc.OrgMembers = []string{"m"}
+		pr.PullRequest.User.Login = "a"
+		fc.PullRequests = map[int]*github.PullRequest{1: &pr.PullRequest}
+		ice := github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Comment: github.IssueComment{Body: "/release-note-refresh", User: github.User{Login: test.commenter}},
+			Issue: github.Issue{
+				User:        github.User{Login: "a"},
+				Number:      1,
+				Body:        test.body,
+				PullRequest: &struct{}{},
+			},
+			Repo: pr.Repo,
+		}
+		if err := handleComment(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, ice); err != nil {
+			t.Fatalf("(%s): Unexpected error from handleComment: %v", test.name, err)
+		}
+		if len(sliceDifference(fc.LabelsAdded, test.expectedAdded)) > 0 || len(sliceDifference(test.expectedAdded, fc.LabelsAdded)) > 0 {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, test.expectedAdded, fc.LabelsAdded)
+		}
+		// The needed label may be removed more than once.
+		if len(sliceDifference(fc.LabelsRemoved, test.expectedRemoved)) > 0 || len(sliceDifference(test.expectedRemoved, fc.LabelsRemoved)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, test.expectedRemoved, fc.LabelsRemoved)
+		}
+		rejected := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), "you can only refresh")
+		if rejected != test.expectRejection {
+			t.Errorf("(%s): Expected a rejection: %t, but got comments %q.", test.name, test.expectRejection, fc.IssueCommentsAdded)
+		}
+		if test.comments != nil && !test.expectRejection {
+			for _, c := range fc.IssueComments[1] {
+				if strings.Contains(c.Body, releaseNoteBody(&plugins.ReleaseNote{})) {
+					t.Errorf("(%s): Expected the stale guidance to be deleted, but got %q.", test.name, c.Body)
+				}
+			}
+		}
+	}
+}
+
+func TestWarnMalformedMarkdown(t *testing.T) {
+	tests := []struct {
+		name             string
+		note             string
+		expectSuggestion bool
+	}{
+		{
+			name:             "unbalanced emphasis",
+			note:             "The **foo command now supports the --bar flag.",
+			expectSuggestion: true,
+		},
+		{
+			name: "well-formed note",
+			note: "The **foo** command now supports the `--bar` flag.",
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\n"+test.note+"\n```", "master", nil, nil, nil)
+		cfg := &plugins.ReleaseNote{WarnMalformedMarkdown: true}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		if expected := formatLabels(1, releaseNote); !reflect.DeepEqual(fc.LabelsAdded, expected) {
+			t.Errorf("(%s): Expected labels %q, but got %q.", test.name, expected, fc.LabelsAdded)
+		}
+		suggested := strings.Contains(strings.Join(fc.IssueCommentsAdded, "\n"), malformedMarkdownBody)
+		if suggested != test.expectSuggestion {
+			t.Errorf("(%s): Expected a suggestion: %t, but got %q.", test.name, test.expectSuggestion, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+// labelFailer is a githubClient that tracks the labels on PR #1 and fails to
+// remove the labels in failRemove.
+type labelFailer struct {
+	githubClient
+	labels     map[string]bool
+	failRemove map[string]bool
+}
+
+func (c *labelFailer) AddLabel(org, repo string, number int, label string) error {
+	if err := c.githubClient.AddLabel(org, repo, number, label); err != nil {
+		return err
+	}
+	c.labels[label] = true
+	return nil
+}
+
+func (c *labelFailer) RemoveLabel(org, repo string, number int, label string) error {
+	if c.failRemove[label] {
+		return fmt.Errorf("injected error")
+	}
+	delete(c.labels, label)
+	return c.githubClient.RemoveLabel(org, repo, number, label)
+}
+
+func TestReconcileLabelsRollback(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            *plugins.ReleaseNote
+		initialLabels  []string
+		failRemove     []string
+		expectErr      string
+		expectedLabels []string
+	}{
+		{
+			name:           "no failure",
+			cfg:            &plugins.ReleaseNote{},
+			initialLabels:  []string{releaseNoteLabelNeeded},
+			expectedLabels: []string{releaseNote},
+		},
+		{
+			name:           "failure removing the needed label",
+			cfg:            &plugins.ReleaseNote{},
+			initialLabels:  []string{releaseNoteLabelNeeded},
+			failRemove:     []string{releaseNoteLabelNeeded},
+			expectErr:      "rolled back",
+			expectedLabels: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:           "failure after removing another label",
+			cfg:            &plugins.ReleaseNote{},
+			initialLabels:  []string{releaseNoteNone, releaseNoteActionRequired},
+			failRemove:     []string{releaseNoteActionRequired},
+			expectErr:      "rolled back",
+			expectedLabels: []string{releaseNoteNone, releaseNoteActionRequired},
+		},
+		{
+			name:           "resolved needed label is rolled back",
+			cfg:            &plugins.ReleaseNote{ResolveInsteadOfRemove: true},
+			initialLabels:  []string{releaseNoteLabelNeeded},
+			failRemove:     []string{releaseNoteLabelNeeded},
+			expectErr:      "rolled back",
+			expectedLabels: []string{releaseNoteLabelNeeded},
+		},
+		{
+			name:           "failed rollback",
+			cfg:            &plugins.ReleaseNote{},
+			initialLabels:  []string{releaseNoteLabelNeeded},
+			failRemove:     []string{releaseNoteLabelNeeded, releaseNote},
+			expectErr:      "encountered 1 errors rolling back",
+			expectedLabels: []string{releaseNoteLabelNeeded, releaseNote},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient("```release-note\nThe foo command now supports the --bar flag.\n```", "master", test.initialLabels, nil, nil)
+		fc.ExistingLabels = append(fc.ExistingLabels, releaseNoteWasNeeded)
+		gc := &labelFailer{githubClient: fc, labels: map[string]bool{}, failRemove: map[string]bool{}}
+		for _, l := range test.initialLabels {
+			gc.labels[l] = true
+		}
+		for _, l := range test.failRemove {
+			gc.failRemove[l] = true
+		}
+		err := handlePR(gc, logrus.WithField("plugin", pluginName), test.cfg, pr)
+		if test.expectErr == "" && err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		} else if test.expectErr != "" && (err == nil || !strings.Contains(err.Error(), test.expectErr)) {
+			t.Errorf("(%s): Expected an error containing %q from handlePR, but got %v.", test.name, test.expectErr, err)
+		}
+		var labels []string
+		for l := range gc.labels {
+			labels = append(labels, l)
+		}
+		if len(labels) != len(test.expectedLabels) || len(sliceDifference(labels, test.expectedLabels)) > 0 {
+			t.Errorf("(%s): Expected the PR to end up with labels %q, but got %q.", test.name, test.expectedLabels, labels)
+		}
+	}
+}
+
+func TestRequireOnlyWhenLabeled(t *testing.T) {
+	const trigger = "needs-release-note"
+	tests := []struct {
+		name            string
+		trigger         string
+		action          github.PullRequestEventAction
+		eventLabel      string
+		body            string
+		initialLabels   []string
+		expectedAdded   []string
+		expectedRemoved []string
+		expectComment   bool
+	}{
+		{
+			name:          "default mode requires a note",
+			body:          "Some text.",
+			expectedAdded: formatLabels(1, releaseNoteLabelNeeded),
+			expectComment: true,
+		},
+		{
+			name:    "unlabeled PR is left alone",
+			trigger: trigger,
+			body:    "Some text.",
+		},
+		{
+			name:          "labeled PR requires a note",
+			trigger:       trigger,
+			body:          "Some text.",
+			initialLabels: []string{"Needs-Release-Note"},
+			expectedAdded: formatLabels(1, releaseNoteLabelNeeded),
+			expectComment: true,
+		},
+		{
+			name:          "labeled PR with a note",
+			trigger:       trigger,
+			body:          "```release-note\nThe foo command now supports the --bar flag.\n```",
+			initialLabels: []string{trigger},
+			expectedAdded: formatLabels(1, releaseNote),
+		},
+		{
+			name:          "adding the trigger label enforces the process",
+			trigger:       trigger,
+			action:        github.PullRequestActionLabeled,
+			eventLabel:    trigger,
+			body:          "Some text.",
+			initialLabels: []string{trigger},
+			expectedAdded: formatLabels(1, releaseNoteLabelNeeded),
+			expectComment: true,
+		},
+		{
+			name:          "adding another label is ignored",
+			trigger:       trigger,
+			action:        github.PullRequestActionLabeled,
+			eventLabel:    lgtmLabel,
+			body:          "Some text.",
+			initialLabels: []string{trigger},
+		},
+		{
+			name:            "removing the trigger label unblocks the PR",
+			trigger:         trigger,
+			action:          github.PullRequestActionUnlabeled,
+			eventLabel:      trigger,
+			body:            "Some text.",
+			initialLabels:   []string{releaseNoteLabelNeeded},
+			expectedRemoved: formatLabels(1, releaseNoteLabelNeeded),
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		if test.action != "" {
+			pr.Action = test.action
+		}
+		pr.Label.Name = test.eventLabel
+		cfg := &plugins.ReleaseNote{RequireOnlyWhenLabeled: test.trigger}
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		added := fc.LabelsAdded[len(test.initialLabels):]
+		if len(added) != len(test.expectedAdded) || len(sliceDifference(added, test.expectedAdded)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, test.expectedAdded, added)
+		}
+		if len(sliceDifference(fc.LabelsRemoved, test.expectedRemoved)) > 0 || len(sliceDifference(test.expectedRemoved, fc.LabelsRemoved)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, test.expectedRemoved, fc.LabelsRemoved)
+		}
+		if len(fc.IssueCommentsAdded) > 0 != test.expectComment {
+			t.Errorf("(%s): Expected a comment: %t, but got comments %q.", test.name, test.expectComment, fc.IssueCommentsAdded)
+		}
+	}
+}
+
+func TestReopenedPR(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		initialLabels   []string
+		comments        []string
+		expectedAdded   []string
+		expectedRemoved []string
+		expectDeleted   bool
+	}{
+		{
+			name:            "note added while closed",
+			body:            "```release-note\nThe foo command now supports the --bar flag.\n```",
+			initialLabels:   []string{releaseNoteLabelNeeded},
+			expectedAdded:   formatLabels(1, releaseNote),
+			expectedRemoved: formatLabels(1, releaseNoteLabelNeeded),
+		},
+		{
+			name:            "note removed while closed",
+			body:            "```release-note\n\n```",
+			initialLabels:   []string{releaseNote},
+			expectedAdded:   formatLabels(1, releaseNoteLabelNeeded),
+			expectedRemoved: formatLabels(1, releaseNote),
+		},
+		{
+			name:          "stale guidance is cleared",
+			body:          "```release-note\nThe foo command now supports the --bar flag.\n```",
+			initialLabels: []string{releaseNote},
+			comments:      []string{releaseNoteBody(&plugins.ReleaseNote{})},
+			expectDeleted: true,
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		for i, c := range test.comments {
+			fc.IssueComments[1] = append(fc.IssueComments[1], github.IssueComment{ID: i + 1, Body: c, User: github.User{Login: "k8s-ci-robot"}})
+		}
+		pr.Action = github.PullRequestActionReopened
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", test.name, err)
+		}
+		added := fc.LabelsAdded[len(test.initialLabels):]
+		if len(added) != len(test.expectedAdded) || len(sliceDifference(added, test.expectedAdded)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, test.expectedAdded, added)
+		}
+		// The needed label may be removed more than once.
+		if len(sliceDifference(fc.LabelsRemoved, test.expectedRemoved)) > 0 || len(sliceDifference(test.expectedRemoved, fc.LabelsRemoved)) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, test.expectedRemoved, fc.LabelsRemoved)
+		}
+		if deleted := len(fc.IssueCommentsDeleted) > 0; deleted != test.expectDeleted {
+			t.Errorf("(%s): Expected stale comments to be deleted: %t, but got %q deleted.", test.name, test.expectDeleted, fc.IssueCommentsDeleted)
+		}
+	}
+}
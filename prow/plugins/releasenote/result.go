@@ -0,0 +1,110 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// ProcessResult describes the changes the plugin made while handling an
+// event.
+type ProcessResult struct {
+	// LabelsAdded and LabelsRemoved are the labels added to and removed from
+	// the issue or PR, in order.
+	LabelsAdded   []string
+	LabelsRemoved []string
+	// LabelsReplaced is the label set the issue or PR's labels were replaced
+	// with, if they were.
+	LabelsReplaced []string
+	// CommentsCreated are the bodies of the comments created, in order.
+	CommentsCreated []string
+	// StaleCommentsDeleted are the IDs of the stale comments deleted.
+	StaleCommentsDeleted []int
+}
+
+// ProcessPullRequest handles a pull request event like the plugin does and
+// returns the changes it made. The event is never debounced.
+func ProcessPullRequest(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, pr github.PullRequestEvent) (*ProcessResult, error) {
+	rc := &resultClient{githubClient: gc}
+	err := handlePR(rc, log, withoutDebounce(cfg), &pr)
+	return &rc.result, err
+}
+
+// ProcessIssueComment handles an issue comment event like the plugin does
+// and returns the changes it made.
+func ProcessIssueComment(gc githubClient, log *logrus.Entry, cfg *plugins.ReleaseNote, ic github.IssueCommentEvent) (*ProcessResult, error) {
+	rc := &resultClient{githubClient: gc}
+	err := handleComment(rc, log, cfg, ic)
+	return &rc.result, err
+}
+
+// resultClient is a githubClient that records the changes successfully made
+// through it in a ProcessResult.
+type resultClient struct {
+	githubClient
+	result ProcessResult
+}
+
+func (c *resultClient) AddLabel(org, repo string, number int, label string) error {
+	err := c.githubClient.AddLabel(org, repo, number, label)
+	if err == nil {
+		c.result.LabelsAdded = append(c.result.LabelsAdded, label)
+	}
+	return err
+}
+
+func (c *resultClient) RemoveLabel(org, repo string, number int, label string) error {
+	err := c.githubClient.RemoveLabel(org, repo, number, label)
+	if err == nil {
+		c.result.LabelsRemoved = append(c.result.LabelsRemoved, label)
+	}
+	return err
+}
+
+func (c *resultClient) ReplaceLabels(org, repo string, number int, labels []string) error {
+	err := c.githubClient.ReplaceLabels(org, repo, number, labels)
+	if err == nil {
+		c.result.LabelsReplaced = append([]string{}, labels...)
+	}
+	return err
+}
+
+func (c *resultClient) CreateComment(org, repo string, number int, comment string) error {
+	err := c.githubClient.CreateComment(org, repo, number, comment)
+	if err == nil {
+		c.result.CommentsCreated = append(c.result.CommentsCreated, comment)
+	}
+	return err
+}
+
+func (c *resultClient) DeleteStaleComments(org, repo string, number int, comments []github.IssueComment, isStale func(github.IssueComment) bool) error {
+	var stale []int
+	err := c.githubClient.DeleteStaleComments(org, repo, number, comments, func(ic github.IssueComment) bool {
+		if isStale(ic) {
+			stale = append(stale, ic.ID)
+			return true
+		}
+		return false
+	})
+	if err == nil {
+		c.result.StaleCommentsDeleted = append(c.result.StaleCommentsDeleted, stale...)
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// trimIssue strips the "org/repo#number:" prefix the fake client records.
+func trimIssue(ops []string) []string {
+	var out []string
+	for _, op := range ops {
+		out = append(out, op[strings.Index(op, ":")+1:])
+	}
+	return out
+}
+
+// checkResult checks that res matches the operations recorded by fc after
+// the initial labels.
+func checkResult(t *testing.T, name string, fc *fakegithub.FakeClient, initialLabels []string, res *ProcessResult) {
+	if added := trimIssue(fc.LabelsAdded[len(initialLabels):]); !reflect.DeepEqual(res.LabelsAdded, added) {
+		t.Errorf("(%s): Expected added labels %q, but got %q.", name, added, res.LabelsAdded)
+	}
+	if removed := trimIssue(fc.LabelsRemoved); !reflect.DeepEqual(res.LabelsRemoved, removed) {
+		t.Errorf("(%s): Expected removed labels %q, but got %q.", name, removed, res.LabelsRemoved)
+	}
+	if created := trimIssue(fc.IssueCommentsAdded); !reflect.DeepEqual(res.CommentsCreated, created) {
+		t.Errorf("(%s): Expected created comments %q, but got %q.", name, created, res.CommentsCreated)
+	}
+	var deleted []string
+	for _, id := range res.StaleCommentsDeleted {
+		deleted = append(deleted, fmt.Sprintf("org/repo#%d", id))
+	}
+	if !reflect.DeepEqual(deleted, fc.IssueCommentsDeleted) {
+		t.Errorf("(%s): Expected deleted comments %q, but got %q.", name, fc.IssueCommentsDeleted, deleted)
+	}
+}
+
+func TestProcessPullRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		initialLabels []string
+		staleComment  bool
+
+		expectAdded   []string
+		expectRemoved []string
+		expectComment bool
+		expectDeleted []int
+	}{
+		{
+			name:          "missing note",
+			body:          "```release-note\n```",
+			expectAdded:   []string{releaseNoteLabelNeeded},
+			expectComment: true,
+		},
+		{
+			name:          "note added after guidance",
+			body:          "```release-note\nA note.\n```",
+			initialLabels: []string{releaseNote, releaseNoteLabelNeeded},
+			staleComment:  true,
+			expectRemoved: []string{releaseNoteLabelNeeded},
+			expectDeleted: []int{4},
+		},
+		{
+			name:          "none note",
+			body:          "```release-note\nNONE\n```",
+			initialLabels: []string{releaseNote},
+			expectAdded:   []string{releaseNoteNone},
+			expectRemoved: []string{releaseNote},
+		},
+	}
+	for _, test := range tests {
+		fc, pr := newFakeClient(test.body, "master", test.initialLabels, nil, nil)
+		if test.staleComment {
+			fc.IssueComments[1] = []github.IssueComment{{ID: 4, Body: releaseNoteBody(&plugins.ReleaseNote{}), User: github.User{Login: "k8s-ci-robot"}}}
+		}
+		res, err := ProcessPullRequest(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, *pr)
+		if err != nil {
+			t.Fatalf("(%s): Unexpected error: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(res.LabelsAdded, test.expectAdded) {
+			t.Errorf("(%s): Expected labels %q to be added, but got %q.", test.name, test.expectAdded, res.LabelsAdded)
+		}
+		if missing := sliceDifference(test.expectRemoved, res.LabelsRemoved); len(missing) > 0 {
+			t.Errorf("(%s): Expected labels %q to be removed, but got %q.", test.name, test.expectRemoved, res.LabelsRemoved)
+		}
+		if test.expectComment != (len(res.CommentsCreated) > 0) {
+			t.Errorf("(%s): Expected a comment: %t, but got %q.", test.name, test.expectComment, res.CommentsCreated)
+		}
+		if !reflect.DeepEqual(res.StaleCommentsDeleted, test.expectDeleted) {
+			t.Errorf("(%s): Expected comments %v to be deleted, but got %v.", test.name, test.expectDeleted, res.StaleCommentsDeleted)
+		}
+		checkResult(t, test.name, fc, test.initialLabels, res)
+	}
+}
+
+func TestProcessIssueComment(t *testing.T) {
+	fc, _ := newFakeClient("```release-note\n```", "master", []string{releaseNoteLabelNeeded}, nil, nil)
+	ic := github.IssueCommentEvent{
+		Action:  github.IssueCommentActionCreated,
+		Comment: github.IssueComment{Body: "/release-note-none", User: github.User{Login: "cjwagner"}},
+		Issue: github.Issue{
+			User:        github.User{Login: "cjwagner"},
+			Number:      1,
+			PullRequest: &struct{}{},
+			Labels:      []github.Label{{Name: releaseNoteLabelNeeded}},
+		},
+		Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+	}
+	res, err := ProcessIssueComment(fc, logrus.WithField("plugin", pluginName), &plugins.ReleaseNote{}, ic)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{releaseNoteNone}; !reflect.DeepEqual(res.LabelsAdded, expected) {
+		t.Errorf("Expected labels %q to be added, but got %q.", expected, res.LabelsAdded)
+	}
+	if expected := []string{releaseNoteLabelNeeded}; !reflect.DeepEqual(res.LabelsRemoved, expected) {
+		t.Errorf("Expected labels %q to be removed, but got %q.", expected, res.LabelsRemoved)
+	}
+	checkResult(t, "comment", fc, []string{releaseNoteLabelNeeded}, res)
+}
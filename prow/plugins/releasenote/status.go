@@ -0,0 +1,204 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// Reasons returned by BlockReason for a PR that is blocked on its release note.
+const (
+	// BlockReasonEmptyNote means the PR has a release note block, but it is empty.
+	BlockReasonEmptyNote = "empty-note"
+	// BlockReasonMalformedNote means no release note block could be found in the PR body.
+	BlockReasonMalformedNote = "malformed-note"
+	// BlockReasonNotelessParents means the PR is a cherry-pick without a release
+	// note, and some of its parents don't have a release note either.
+	BlockReasonNotelessParents = "noteless-parents"
+	// BlockReasonMissingDelimiter means the action required release note
+	// doesn't contain cfg.ActionRequiredDelimiter.
+	BlockReasonMissingDelimiter = "missing-action-delimiter"
+	// BlockReasonForceNeededLabel means the PR has a release note of none, but
+	// a label in cfg.ForceNeededLabels asks for a real one.
+	BlockReasonForceNeededLabel = "force-needed-label"
+	// BlockReasonStrictMilestone means the PR has a release note of none, but
+	// its milestone is in cfg.StrictMilestones.
+	BlockReasonStrictMilestone = "strict-milestone"
+	// BlockReasonForbiddenFlags means the release note mentions flags matching
+	// cfg.ForbiddenFlagPatterns, and cfg.HardEnforceFlags is set.
+	BlockReasonForbiddenFlags = "forbidden-flags"
+	// BlockReasonEmptyActionRequired means the action required release note
+	// doesn't describe the action.
+	BlockReasonEmptyActionRequired = "empty-action-required"
+	// BlockReasonReferenceOnlyNote means the release note only consists of
+	// issue or PR references and URLs.
+	BlockReasonReferenceOnlyNote = "reference-only-note"
+)
+
+// BlockReason determines whether the release note process is blocking a PR
+// and, if so, returns a machine-friendly reason code for dashboards. It never
+// modifies the PR.
+func BlockReason(gc githubClient, cfg *plugins.Configuration, org, repo string, number int) (blocked bool, reason string, err error) {
+	rnCfg := cfg.ReleaseNoteFor(org, repo)
+	gc = withLabelNames(gc, rnCfg)
+	pr, err := gc.GetPullRequest(org, repo, number)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get %s/%s#%d: %v", org, repo, number, err)
+	}
+	if pr == nil {
+		return false, "", fmt.Errorf("failed to get %s/%s#%d: not found", org, repo, number)
+	}
+	prLabels, err := gc.GetIssueLabels(org, repo, number)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to list labels on %s/%s#%d: %v", org, repo, number, err)
+	}
+
+	log := logrus.WithField("plugin", pluginName).WithField("pr", number)
+	label, comments, err := decideLabel(gc, log, rnCfg, editedEvent(org, repo, pr), prLabels, false)
+	if err != nil {
+		return false, "", err
+	}
+	if label != releaseNoteLabelNeeded {
+		return false, "", nil
+	}
+	note := getReleaseNote(rnCfg, pr.Body)
+	bodyLabel := determineReleaseNoteLabel(rnCfg, pr.Body)
+	none := bodyLabel == releaseNoteNone
+	if !none {
+		commandLabel, err := latestCommandLabel(gc, rnCfg, comments)
+		if err != nil {
+			return false, "", err
+		}
+		none = commandLabel == releaseNoteNone
+	}
+	noted := bodyLabel == releaseNote || bodyLabel == releaseNoteActionRequired
+	switch {
+	case actionRequiredWithoutDelimiter(rnCfg, pr.Body, prLabels):
+		return true, BlockReasonMissingDelimiter, nil
+	case rnCfg.EmptyActionRequiredBehavior == emptyActionBlock && bodyLabel == releaseNoteActionRequired && emptyActionRequired(rnCfg, pr.Body):
+		return true, BlockReasonEmptyActionRequired, nil
+	case noted && rnCfg.HardEnforceFlags && len(forbiddenFlags(rnCfg, note)) > 0:
+		return true, BlockReasonForbiddenFlags, nil
+	case noted && rnCfg.BlockReferenceOnlyNotes && referenceOnlyNote(note):
+		return true, BlockReasonReferenceOnlyNote, nil
+	case none && inStrictMilestone(rnCfg, pr):
+		return true, BlockReasonStrictMilestone, nil
+	case none && hasAnyLabel(rnCfg.ForceNeededLabels, prLabels):
+		return true, BlockReasonForceNeededLabel, nil
+	case isReleaseBranch(rnCfg, pr.Base.Ref) && len(getCherrypickParentPRNums(pr.Body)) > 0:
+		return true, BlockReasonNotelessParents, nil
+	case regexesFor(rnCfg).noteMatcher.MatchString(pr.Body):
+		return true, BlockReasonEmptyNote, nil
+	default:
+		return true, BlockReasonMalformedNote, nil
+	}
+}
+
+// CherryPickIssue describes an open cherry-pick whose parents don't all have
+// a release note.
+type CherryPickIssue struct {
+	Number int
+	Title  string
+	// NotelessParents are the parent PRs labeled with neither release-note
+	// nor release-note-action-required.
+	NotelessParents []int
+}
+
+// ValidateCherryPicks finds the open cherry-picks into releaseBranch and
+// reports those with parents that don't have a release note, eg. so that
+// release managers can chase them before cutting a release. It never modifies
+// any PR.
+func ValidateCherryPicks(gc githubClient, cfg *plugins.Configuration, org, repo, releaseBranch string) ([]CherryPickIssue, error) {
+	gc = withLabelNames(gc, cfg.ReleaseNoteFor(org, repo))
+	query := fmt.Sprintf("repo:%s/%s is:pr is:open base:%s", org, repo, releaseBranch)
+	issues, err := gc.FindAllIssues(query, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for PRs into %s in %s/%s: %v", releaseBranch, org, repo, err)
+	}
+
+	var out []CherryPickIssue
+	for _, issue := range issues {
+		if !issue.IsPullRequest() {
+			continue
+		}
+		var noteless []int
+		for _, parent := range getCherrypickParentPRNums(issue.Body) {
+			labels, err := gc.GetIssueLabels(org, repo, parent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list labels on %s/%s#%d (parent of #%d): %v", org, repo, parent, issue.Number, err)
+			}
+			if !hasReleaseNote(labels) {
+				noteless = append(noteless, parent)
+			}
+		}
+		if len(noteless) > 0 {
+			out = append(out, CherryPickIssue{
+				Number:          issue.Number,
+				Title:           issue.Title,
+				NotelessParents: noteless,
+			})
+		}
+	}
+	return out, nil
+}
+
+// NeededStat is the key of MilestoneNoteStats's count of PRs that don't have
+// a release note label yet.
+const NeededStat = "needed"
+
+// MilestoneNoteStats counts the PRs in a milestone by their release note
+// label, eg. for release note coverage reports. PRs without a release note
+// label, including those labeled as needing one, are counted as NeededStat.
+// The labels are keyed by their names in the repo's config. It never
+// modifies any PR.
+func MilestoneNoteStats(gc githubClient, cfg *plugins.Configuration, org, repo string, milestone string) (map[string]int, error) {
+	rnCfg := cfg.ReleaseNoteFor(org, repo)
+	names := newLabelNames(rnCfg)
+	gc = withLabelNames(gc, rnCfg)
+	query := fmt.Sprintf("repo:%s/%s is:pr milestone:%q", org, repo, milestone)
+	issues, err := gc.FindAllIssues(query, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for PRs in milestone %q in %s/%s: %v", milestone, org, repo, err)
+	}
+
+	stats := map[string]int{
+		names.name(releaseNote):               0,
+		names.name(releaseNoteActionRequired): 0,
+		names.name(releaseNoteNone):           0,
+		NeededStat:                            0,
+	}
+	for _, issue := range issues {
+		if !issue.IsPullRequest() {
+			continue
+		}
+		switch {
+		case hasLabel(releaseNoteActionRequired, issue.Labels):
+			stats[names.name(releaseNoteActionRequired)]++
+		case hasLabel(releaseNote, issue.Labels):
+			stats[names.name(releaseNote)]++
+		case hasLabel(releaseNoteNone, issue.Labels):
+			stats[names.name(releaseNoteNone)]++
+		default:
+			stats[NeededStat]++
+		}
+	}
+	return stats, nil
+}
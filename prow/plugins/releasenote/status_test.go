@@ -0,0 +1,240 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestBlockReason(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		branch        string
+		parentPRs     map[int]string
+		issueComments []string
+		cfg           plugins.ReleaseNote
+		labels        []string
+		milestone     string
+		blocked       bool
+		reason        string
+	}{
+		{
+			name:    "empty block",
+			body:    "```release-note\n```",
+			blocked: true,
+			reason:  BlockReasonEmptyNote,
+		},
+		{
+			name:    "no release note block",
+			body:    "I didn't use the template.",
+			blocked: true,
+			reason:  BlockReasonMalformedNote,
+		},
+		{
+			name:      "cherry-pick with a noteless parent",
+			body:      "Cherry pick of #2 on release-1.8.",
+			branch:    "release-1.8",
+			parentPRs: map[int]string{2: releaseNoteNone},
+			blocked:   true,
+			reason:    BlockReasonNotelessParents,
+		},
+		{
+			name:      "cherry-pick whose parents have release notes",
+			body:      "Cherry pick of #2 on release-1.8.",
+			branch:    "release-1.8",
+			parentPRs: map[int]string{2: releaseNote},
+		},
+		{
+			name: "release note present",
+			body: "```release-note\nA note.\n```",
+		},
+		{
+			name:          "release-note-none command",
+			body:          "```release-note\n```",
+			issueComments: []string{"/release-note-none"},
+		},
+		{
+			name:    "action required note without the delimiter",
+			body:    "```release-note\nAction required: run the migration.\n```",
+			cfg:     plugins.ReleaseNote{ActionRequiredDelimiter: "**Action required:**"},
+			blocked: true,
+			reason:  BlockReasonMissingDelimiter,
+		},
+		{
+			name:    "action required note without the action",
+			body:    "```release-note\nAction required\n```",
+			cfg:     plugins.ReleaseNote{EmptyActionRequiredBehavior: emptyActionBlock},
+			blocked: true,
+			reason:  BlockReasonEmptyActionRequired,
+		},
+		{
+			name:    "hard-enforced forbidden flag",
+			body:    "```release-note\nThe --feature-gates=FooAlpha=true flag is now on by default.\n```",
+			cfg:     plugins.ReleaseNote{ForbiddenFlagPatterns: []string{`--feature-gates=\S*Alpha\S*`}, HardEnforceFlags: true},
+			blocked: true,
+			reason:  BlockReasonForbiddenFlags,
+		},
+		{
+			name:    "reference-only note",
+			body:    "```release-note\n#1234\n```",
+			cfg:     plugins.ReleaseNote{BlockReferenceOnlyNotes: true},
+			blocked: true,
+			reason:  BlockReasonReferenceOnlyNote,
+		},
+		{
+			name:      "none in a strict milestone",
+			body:      "```release-note\nNONE\n```",
+			cfg:       plugins.ReleaseNote{StrictMilestones: []string{"v1.9"}},
+			milestone: "v1.9",
+			blocked:   true,
+			reason:    BlockReasonStrictMilestone,
+		},
+		{
+			name:          "none command in a strict milestone",
+			body:          "```release-note\n```",
+			issueComments: []string{"/release-note-none"},
+			cfg:           plugins.ReleaseNote{StrictMilestones: []string{"v1.9"}},
+			milestone:     "v1.9",
+			blocked:       true,
+			reason:        BlockReasonStrictMilestone,
+		},
+		{
+			name:    "none with a force-needed label",
+			body:    "```release-note\nNONE\n```",
+			cfg:     plugins.ReleaseNote{ForceNeededLabels: []string{"needs-release-note"}},
+			labels:  []string{"needs-release-note"},
+			blocked: true,
+			reason:  BlockReasonForceNeededLabel,
+		},
+	}
+	for _, test := range tests {
+		if test.branch == "" {
+			test.branch = "master"
+		}
+		fc, pr := newFakeClient(test.body, test.branch, test.labels, test.issueComments, test.parentPRs)
+		if test.milestone != "" {
+			pr.PullRequest.Milestone = &github.Milestone{Title: test.milestone}
+		}
+		fc.PullRequests = map[int]*github.PullRequest{1: &pr.PullRequest}
+		test.cfg.Repos = []string{"org/repo"}
+
+		blocked, reason, err := BlockReason(fc, &plugins.Configuration{ReleaseNotes: []plugins.ReleaseNote{test.cfg}}, "org", "repo", 1)
+		if err != nil {
+			t.Fatalf("(%s): Unexpected error from BlockReason: %v", test.name, err)
+		}
+		if blocked != test.blocked || reason != test.reason {
+			t.Errorf("(%s): Expected blocked=%t with reason %q, but got blocked=%t with reason %q.", test.name, test.blocked, test.reason, blocked, reason)
+		}
+		if len(fc.IssueCommentsAdded) > 0 || len(fc.LabelsAdded) != len(test.parentPRs)+len(test.labels) || len(fc.LabelsRemoved) > 0 {
+			t.Errorf("(%s): Expected the PR to be unmodified, but got comments %q, labels %q, removed %q.", test.name, fc.IssueCommentsAdded, fc.LabelsAdded, fc.LabelsRemoved)
+		}
+	}
+}
+
+// pagedSearchClient is a fake client whose search results span several pages,
+// so FindIssues only returns the first page of them.
+type pagedSearchClient struct {
+	*fakegithub.FakeClient
+	pageSize int
+}
+
+func (c *pagedSearchClient) FindIssues(query, sort string, asc bool) ([]github.Issue, error) {
+	issues, err := c.FakeClient.FindIssues(query, sort, asc)
+	if len(issues) > c.pageSize {
+		issues = issues[:c.pageSize]
+	}
+	return issues, err
+}
+
+func TestValidateCherryPicks(t *testing.T) {
+	fc := &fakegithub.FakeClient{
+		Issues: []github.Issue{
+			{Number: 10, Title: "noted parent", Body: "Cherry pick of #1 on release-1.8.", PullRequest: &struct{}{}},
+			{Number: 11, Title: "noteless parent", Body: "Cherry pick of #2 on release-1.8.", PullRequest: &struct{}{}},
+			{Number: 12, Title: "mixed parents", Body: "Cherry pick of #1 on release-1.8.\nCherry pick of #3 on release-1.8.", PullRequest: &struct{}{}},
+			{Number: 13, Title: "unlabeled parent", Body: "Cherry pick of #4 on release-1.8.", PullRequest: &struct{}{}},
+			{Number: 14, Title: "not a cherry-pick", Body: "```release-note\nA note.\n```", PullRequest: &struct{}{}},
+			{Number: 15, Title: "an issue", Body: "Cherry pick of #2 on release-1.8."},
+		},
+		LabelsAdded: append(append(append(
+			formatLabels(1, releaseNote),
+			formatLabels(2, releaseNoteNone)...),
+			formatLabels(3, releaseNoteLabelNeeded)...),
+			formatLabels(5, releaseNoteActionRequired)...),
+	}
+
+	issues, err := ValidateCherryPicks(&pagedSearchClient{FakeClient: fc, pageSize: 2}, &plugins.Configuration{}, "org", "repo", "release-1.8")
+	if err != nil {
+		t.Fatalf("Unexpected error from ValidateCherryPicks: %v", err)
+	}
+	expected := []CherryPickIssue{
+		{Number: 11, Title: "noteless parent", NotelessParents: []int{2}},
+		{Number: 12, Title: "mixed parents", NotelessParents: []int{3}},
+		{Number: 13, Title: "unlabeled parent", NotelessParents: []int{4}},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("Expected %+v, but got %+v.", expected, issues)
+	}
+	if len(fc.LabelsRemoved) > 0 || len(fc.IssueCommentsAdded) > 0 {
+		t.Errorf("Expected no changes, but got removed labels %q and comments %q.", fc.LabelsRemoved, fc.IssueCommentsAdded)
+	}
+}
+
+func TestMilestoneNoteStats(t *testing.T) {
+	pr := func(number int, labels ...string) github.Issue {
+		issue := github.Issue{Number: number, PullRequest: &struct{}{}}
+		for _, l := range labels {
+			issue.Labels = append(issue.Labels, github.Label{Name: l})
+		}
+		return issue
+	}
+	fc := &fakegithub.FakeClient{
+		Issues: []github.Issue{
+			pr(1, releaseNote),
+			pr(2, releaseNote, lgtmLabel),
+			pr(3, releaseNoteActionRequired),
+			pr(4, releaseNoteNone),
+			pr(5, releaseNoteLabelNeeded),
+			pr(6, deprecatedReleaseNoteLabelNeeded),
+			pr(7),
+			{Number: 8, Labels: []github.Label{{Name: releaseNote}}},
+		},
+	}
+
+	stats, err := MilestoneNoteStats(&pagedSearchClient{FakeClient: fc, pageSize: 3}, &plugins.Configuration{}, "org", "repo", "v1.8")
+	if err != nil {
+		t.Fatalf("Unexpected error from MilestoneNoteStats: %v", err)
+	}
+	expected := map[string]int{
+		releaseNote:               2,
+		releaseNoteActionRequired: 1,
+		releaseNoteNone:           1,
+		NeededStat:                3,
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Expected stats %v, but got %v.", expected, stats)
+	}
+	if len(fc.LabelsAdded) > 0 || len(fc.LabelsRemoved) > 0 || len(fc.IssueCommentsAdded) > 0 {
+		t.Errorf("Expected no changes, but got added labels %q, removed labels %q and comments %q.", fc.LabelsAdded, fc.LabelsRemoved, fc.IssueCommentsAdded)
+	}
+}
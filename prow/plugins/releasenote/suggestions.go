@@ -0,0 +1,156 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+const (
+	suggestionsBody   = "Some suggestions for the release note:"
+	suggestionsReason = "These suggestions don't block the PR. This comment is updated as the release note changes."
+	// suggestionsMarker identifies the consolidated suggestions comment.
+	suggestionsMarker = "<!-- release-note: suggestions -->"
+)
+
+// suggester posts non-blocking suggestions about a PR's release note.
+type suggester interface {
+	// suggest makes the suggestion with body for reason, or withdraws it if
+	// reason is empty.
+	suggest(body, reason string) error
+}
+
+// commentSuggester posts each suggestion as its own comment, edits it when
+// the reason changes and deletes it once it is withdrawn. The bot name and
+// comments are fetched once, so a commentSuggester serves a single event.
+type commentSuggester struct {
+	gc githubClient
+	pr *github.PullRequestEvent
+
+	loaded   bool
+	botName  string
+	comments []github.IssueComment
+}
+
+func (s *commentSuggester) load() error {
+	if s.loaded {
+		return nil
+	}
+	botName, err := s.gc.BotName()
+	if err != nil {
+		return err
+	}
+	comments, err := s.gc.ListIssueComments(s.pr.Repo.Owner.Login, s.pr.Repo.Name, s.pr.Number)
+	if err != nil {
+		return err
+	}
+	s.loaded, s.botName, s.comments = true, botName, comments
+	return nil
+}
+
+func (s *commentSuggester) suggest(body, reason string) error {
+	org := s.pr.Repo.Owner.Login
+	repo := s.pr.Repo.Name
+	if err := s.load(); err != nil {
+		return err
+	}
+	isSuggestion := func(c github.IssueComment) bool {
+		return c.User.Login == s.botName && strings.Contains(c.Body, body)
+	}
+
+	if reason == "" {
+		return s.gc.DeleteStaleComments(org, repo, s.pr.Number, s.comments, isSuggestion)
+	}
+	comment := plugins.FormatResponse(s.pr.PullRequest.User.Login, body, reason)
+	for _, c := range s.comments {
+		if !isSuggestion(c) {
+			continue
+		}
+		// Keep the marker counted by commentLimitClient.
+		if strings.HasSuffix(c.Body, "\n"+botCommentMarker) {
+			comment += "\n" + botCommentMarker
+		}
+		if c.Body == comment {
+			return nil
+		}
+		return s.gc.EditComment(org, repo, c.ID, comment)
+	}
+	return s.gc.CreateComment(org, repo, s.pr.Number, comment)
+}
+
+// suggestionCollector gathers suggestions so that post can make them in a
+// single comment, which is edited in place as they change.
+type suggestionCollector struct {
+	suggestions []suggestion
+}
+
+type suggestion struct {
+	body, reason string
+}
+
+func (c *suggestionCollector) suggest(body, reason string) error {
+	if reason != "" {
+		c.suggestions = append(c.suggestions, suggestion{body: body, reason: reason})
+	}
+	return nil
+}
+
+// post creates or updates the suggestions comment on the PR, or deletes it if
+// there are no suggestions.
+func (c *suggestionCollector) post(gc githubClient, pr *github.PullRequestEvent) error {
+	org := pr.Repo.Owner.Login
+	repo := pr.Repo.Name
+	botName, err := gc.BotName()
+	if err != nil {
+		return err
+	}
+	comments, err := gc.ListIssueComments(org, repo, pr.Number)
+	if err != nil {
+		return err
+	}
+	isSuggestions := func(ic github.IssueComment) bool {
+		return ic.User.Login == botName && strings.Contains(ic.Body, suggestionsMarker)
+	}
+
+	if len(c.suggestions) == 0 {
+		return gc.DeleteStaleComments(org, repo, pr.Number, comments, isSuggestions)
+	}
+	var items []string
+	for _, s := range c.suggestions {
+		items = append(items, fmt.Sprintf("- %s %s", s.body, s.reason))
+	}
+	message := suggestionsBody + "\n\n" + strings.Join(items, "\n")
+	comment := plugins.FormatResponse(pr.PullRequest.User.Login, message, suggestionsReason) + "\n" + suggestionsMarker
+	for _, ic := range comments {
+		if !isSuggestions(ic) {
+			continue
+		}
+		// Keep the marker counted by commentLimitClient.
+		if strings.HasSuffix(ic.Body, "\n"+botCommentMarker) {
+			comment += "\n" + botCommentMarker
+		}
+		if ic.Body == comment {
+			return nil
+		}
+		return gc.EditComment(org, repo, ic.ID, comment)
+	}
+	return gc.CreateComment(org, repo, pr.Number, comment)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenote
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestConsolidateSuggestions(t *testing.T) {
+	cfg := &plugins.ReleaseNote{
+		ConsolidateSuggestions: true,
+		WarnRelativeLinks:      true,
+		WarnLinkPlaceholders:   true,
+	}
+	steps := []struct {
+		name           string
+		note           string
+		expectedAdded  int
+		expectedEdited int
+		expectBodies   []string
+		expectDeleted  bool
+	}{
+		{
+			name:          "several suggestions are posted in one comment",
+			note:          "The foo command now supports the --bar flag, see the [docs](docs/foo.md) and the [design]().",
+			expectedAdded: 1,
+			expectBodies:  []string{relativeLinkBody, linkPlaceholderBody},
+		},
+		{
+			name:          "re-running doesn't change the comment",
+			note:          "The foo command now supports the --bar flag, see the [docs](docs/foo.md) and the [design]().",
+			expectedAdded: 1,
+			expectBodies:  []string{relativeLinkBody, linkPlaceholderBody},
+		},
+		{
+			name:           "the comment is edited as suggestions are resolved",
+			note:           "The foo command now supports the --bar flag, see the [docs](https://example.com/docs) and the [design]().",
+			expectedAdded:  1,
+			expectedEdited: 1,
+			expectBodies:   []string{linkPlaceholderBody},
+		},
+		{
+			name:           "the comment is deleted once all are resolved",
+			note:           "The foo command now supports the --bar flag, see the [docs](https://example.com/docs).",
+			expectedAdded:  1,
+			expectedEdited: 1,
+			expectDeleted:  true,
+		},
+	}
+	fc, pr := newFakeClient("", "master", nil, nil, nil)
+	for _, step := range steps {
+		pr.PullRequest.Body = "```release-note\n" + step.note + "\n```"
+		if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+			t.Fatalf("(%s): Unexpected error from handlePR: %v", step.name, err)
+		}
+		if len(fc.IssueCommentsAdded) != step.expectedAdded {
+			t.Errorf("(%s): Expected %d comments to be added, but got %q.", step.name, step.expectedAdded, fc.IssueCommentsAdded)
+		}
+		if len(fc.IssueCommentsEdited) != step.expectedEdited {
+			t.Errorf("(%s): Expected %d comments to be edited, but got %q.", step.name, step.expectedEdited, fc.IssueCommentsEdited)
+		}
+		var current []string
+		for _, c := range fc.IssueComments[1] {
+			if strings.Contains(c.Body, suggestionsMarker) {
+				current = append(current, c.Body)
+			}
+		}
+		if step.expectDeleted {
+			if len(current) != 0 {
+				t.Errorf("(%s): Expected the suggestions comment to be deleted, but got %q.", step.name, current)
+			}
+			continue
+		}
+		if len(current) != 1 {
+			t.Fatalf("(%s): Expected one suggestions comment, but got %q.", step.name, current)
+		}
+		for _, body := range []string{relativeLinkBody, linkPlaceholderBody} {
+			expected := false
+			for _, b := range step.expectBodies {
+				expected = expected || b == body
+			}
+			if strings.Contains(current[0], body) != expected {
+				t.Errorf("(%s): Expected the suggestions comment to contain %q: %t, but got %q.", step.name, body, expected, current[0])
+			}
+		}
+	}
+}
+
+func TestConsolidateSuggestionsKeepsCommentLimitMarker(t *testing.T) {
+	cfg := &plugins.ReleaseNote{
+		ConsolidateSuggestions: true,
+		WarnLinkPlaceholders:   true,
+		MaxBotComments:         5,
+	}
+	fc, pr := newFakeClient("```release-note\nSee the [design](URL) and the [docs](link).\n```", "master", nil, nil, nil)
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	pr.PullRequest.Body = "```release-note\nSee the [design](URL).\n```"
+	if err := handlePR(fc, logrus.WithField("plugin", pluginName), cfg, pr); err != nil {
+		t.Fatalf("Unexpected error from handlePR: %v", err)
+	}
+	if len(fc.IssueCommentsEdited) != 1 {
+		t.Fatalf("Expected the suggestions comment to be edited once, but got %q.", fc.IssueCommentsEdited)
+	}
+	if !strings.HasSuffix(fc.IssueCommentsEdited[0], botCommentMarker) {
+		t.Errorf("Expected the edited comment to keep %q, but got %q.", botCommentMarker, fc.IssueCommentsEdited[0])
+	}
+}
+
+// commentListCounter is a githubClient that counts ListIssueComments calls.
+type commentListCounter struct {
+	githubClient
+	calls int
+}
+
+func (c *commentListCounter) ListIssueComments(org, repo string, number int) ([]github.IssueComment, error) {
+	c.calls++
+	return c.githubClient.ListIssueComments(org, repo, number)
+}
+
+func TestCommentSuggesterEditsChangedReason(t *testing.T) {
+	fc, pr := newFakeClient("", "master", nil, nil, nil)
+	first := &commentSuggester{gc: fc, pr: pr}
+	if err := first.suggest(forbiddenFlagBody, "Found --foo."); err != nil {
+		t.Fatalf("Unexpected error from suggest: %v", err)
+	}
+
+	// The next event finds another reason for the same suggestion.
+	gc := &commentListCounter{githubClient: fc}
+	next := &commentSuggester{gc: gc, pr: pr}
+	if err := next.suggest(forbiddenFlagBody, "Found --bar."); err != nil {
+		t.Fatalf("Unexpected error from suggest: %v", err)
+	}
+	if err := next.suggest(relativeLinkBody, ""); err != nil {
+		t.Fatalf("Unexpected error from suggest: %v", err)
+	}
+	if len(fc.IssueCommentsAdded) != 1 {
+		t.Errorf("Expected one comment to be added, but got %q.", fc.IssueCommentsAdded)
+	}
+	if len(fc.IssueCommentsEdited) != 1 || !strings.Contains(fc.IssueCommentsEdited[0], "Found --bar.") {
+		t.Errorf("Expected the comment to be edited with the new reason, but got %q.", fc.IssueCommentsEdited)
+	}
+	if gc.calls != 1 {
+		t.Errorf("Expected the comments to be listed once per event, but got %d calls.", gc.calls)
+	}
+}